@@ -7,7 +7,7 @@ import (
 	"math/rand"
 	"time"
 
-	"github.com/Shopify/sarama"
+	"github.com/IBM/sarama"
 	"github.com/cswank/kcli/dev/person"
 	"github.com/golang/protobuf/proto"
 )
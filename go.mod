@@ -3,20 +3,23 @@ module github.com/cswank/kcli
 go 1.13
 
 require (
-	github.com/Shopify/sarama v1.24.1
+	github.com/IBM/sarama v1.44.0
 	github.com/alecthomas/template v0.0.0-20190718012654-fb15b899a751 // indirect
 	github.com/alecthomas/units v0.0.0-20190924025748-f65c72e2690d // indirect
+	github.com/antonmedv/expr v1.12.5
 	github.com/benburkert/dns v0.0.0-20190225204957-d356cf78cdfc
 	github.com/fatih/color v1.7.0
 	github.com/golang/protobuf v1.5.3
 	github.com/jroimartin/gocui v0.4.0
-	github.com/klauspost/cpuid v1.3.1 // indirect
+	github.com/linkedin/goavro/v2 v2.15.0
 	github.com/mattn/go-colorable v0.1.4 // indirect
 	github.com/mattn/go-isatty v0.0.11 // indirect
-	github.com/mattn/go-runewidth v0.0.7 // indirect
+	github.com/mattn/go-runewidth v0.0.8 // indirect
 	github.com/nsf/termbox-go v0.0.0-20190817171036-93860e161317 // indirect
 	github.com/xdg/scram v0.0.0-20180814205039-7eeb5667e42c
-	golang.org/x/crypto v0.0.0-20190404164418-38d8ce5564a5
-	golang.org/x/sync v0.2.0 // indirect
+	github.com/xdg/stringprep v1.0.0 // indirect
+	golang.org/x/crypto v0.31.0
+	golang.org/x/net v0.33.0
+	golang.org/x/sync v0.10.0
 	gopkg.in/alecthomas/kingpin.v2 v2.2.6
 )
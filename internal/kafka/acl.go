@@ -0,0 +1,296 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/IBM/sarama"
+)
+
+// ErrNoAuthorizer is returned by the ACL methods when the cluster has no
+// authorizer configured.
+var ErrNoAuthorizer = errors.New("kafka: the cluster has no authorizer configured")
+
+// ACL is a single access control entry.  ResourceType is one of topic,
+// group, cluster or transactional_id, PatternType is literal or prefixed,
+// Operation is one of all, read, write, create, delete, alter, describe,
+// cluster_action, describe_configs, alter_configs or idempotent_write and
+// Permission is allow or deny.
+type ACL struct {
+	Principal    string `json:"principal"`
+	Host         string `json:"host"`
+	ResourceType string `json:"resource_type"`
+	ResourceName string `json:"resource_name"`
+	PatternType  string `json:"pattern_type"`
+	Operation    string `json:"operation"`
+	Permission   string `json:"permission"`
+}
+
+// ACLFilter selects ACLs.  Empty fields match anything, and PatternType
+// may also be match, which selects every ACL that applies to
+// ResourceName (literal, prefixed and wildcard).
+type ACLFilter ACL
+
+var (
+	aclResourceTypes = map[string]int{
+		"":                 int(sarama.AclResourceAny),
+		"any":              int(sarama.AclResourceAny),
+		"topic":            int(sarama.AclResourceTopic),
+		"group":            int(sarama.AclResourceGroup),
+		"cluster":          int(sarama.AclResourceCluster),
+		"transactional_id": int(sarama.AclResourceTransactionalID),
+	}
+
+	aclPatternTypes = map[string]int{
+		"":         int(sarama.AclPatternAny),
+		"any":      int(sarama.AclPatternAny),
+		"match":    int(sarama.AclPatternMatch),
+		"literal":  int(sarama.AclPatternLiteral),
+		"prefixed": int(sarama.AclPatternPrefixed),
+	}
+
+	aclOperations = map[string]int{
+		"":                 int(sarama.AclOperationAny),
+		"any":              int(sarama.AclOperationAny),
+		"all":              int(sarama.AclOperationAll),
+		"read":             int(sarama.AclOperationRead),
+		"write":            int(sarama.AclOperationWrite),
+		"create":           int(sarama.AclOperationCreate),
+		"delete":           int(sarama.AclOperationDelete),
+		"alter":            int(sarama.AclOperationAlter),
+		"describe":         int(sarama.AclOperationDescribe),
+		"cluster_action":   int(sarama.AclOperationClusterAction),
+		"describe_configs": int(sarama.AclOperationDescribeConfigs),
+		"alter_configs":    int(sarama.AclOperationAlterConfigs),
+		"idempotent_write": int(sarama.AclOperationIdempotentWrite),
+	}
+
+	aclPermissions = map[string]int{
+		"":      int(sarama.AclPermissionAny),
+		"any":   int(sarama.AclPermissionAny),
+		"deny":  int(sarama.AclPermissionDeny),
+		"allow": int(sarama.AclPermissionAllow),
+	}
+)
+
+// ListACLs returns the ACLs that match filter.
+func (c *Client) ListACLs(filter ACLFilter) ([]ACL, error) {
+	f, err := filter.sarama()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := c.sarama.Controller()
+	if err != nil {
+		return nil, wrapErr("list acls", "", -1, -1, err)
+	}
+
+	resp, err := b.DescribeAcls(&sarama.DescribeAclsRequest{Version: int(c.aclVersion()), AclFilter: f})
+	if err != nil {
+		return nil, wrapErr("list acls", "", -1, -1, err)
+	}
+
+	if err := aclError(resp.Err, resp.ErrMsg); err != nil {
+		return nil, wrapErr("list acls", "", -1, -1, err)
+	}
+
+	var out []ACL
+	for _, r := range resp.ResourceAcls {
+		for _, a := range r.Acls {
+			out = append(out, newACL(r.Resource, *a))
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ResourceType != out[j].ResourceType {
+			return out[i].ResourceType < out[j].ResourceType
+		}
+		if out[i].ResourceName != out[j].ResourceName {
+			return out[i].ResourceName < out[j].ResourceName
+		}
+		return out[i].Principal < out[j].Principal
+	})
+	return out, nil
+}
+
+// CreateACL adds a. It requires AllowDestructive.
+func (c *Client) CreateACL(a ACL) error {
+	if err := c.checkDestructive(); err != nil {
+		return err
+	}
+
+	r, acl, err := a.sarama()
+	if err != nil {
+		return err
+	}
+
+	b, err := c.sarama.Controller()
+	if err != nil {
+		return wrapErr("create acl", "", -1, -1, err)
+	}
+
+	resp, err := b.CreateAcls(&sarama.CreateAclsRequest{
+		Version:      c.aclVersion(),
+		AclCreations: []*sarama.AclCreation{{Resource: r, Acl: acl}},
+	})
+	if err != nil {
+		return wrapErr("create acl", "", -1, -1, err)
+	}
+
+	for _, cr := range resp.AclCreationResponses {
+		if err := aclError(cr.Err, cr.ErrMsg); err != nil {
+			return wrapErr("create acl", "", -1, -1, err)
+		}
+	}
+	return nil
+}
+
+// DeleteACLs deletes every ACL that matches filter and returns how many
+// were deleted.  It requires AllowDestructive.
+func (c *Client) DeleteACLs(filter ACLFilter) (int, error) {
+	if err := c.checkDestructive(); err != nil {
+		return 0, err
+	}
+
+	f, err := filter.sarama()
+	if err != nil {
+		return 0, err
+	}
+
+	b, err := c.sarama.Controller()
+	if err != nil {
+		return 0, wrapErr("delete acls", "", -1, -1, err)
+	}
+
+	resp, err := b.DeleteAcls(&sarama.DeleteAclsRequest{Version: int(c.aclVersion()), Filters: []*sarama.AclFilter{&f}})
+	if err != nil {
+		return 0, wrapErr("delete acls", "", -1, -1, err)
+	}
+
+	var n int
+	for _, fr := range resp.FilterResponses {
+		if err := aclError(fr.Err, fr.ErrMsg); err != nil {
+			return n, wrapErr("delete acls", "", -1, -1, err)
+		}
+		for _, m := range fr.MatchingAcls {
+			if err := aclError(m.Err, m.ErrMsg); err != nil {
+				return n, wrapErr("delete acls", "", -1, -1, err)
+			}
+			n++
+		}
+	}
+	return n, nil
+}
+
+// aclVersion is the version of the ACL requests to send.  Pattern types
+// other than literal need version 1 (kafka 2.0).
+func (c *Client) aclVersion() int16 {
+	if c.cfg.Version.IsAtLeast(sarama.V2_0_0_0) {
+		return 1
+	}
+	return 0
+}
+
+func aclError(kerr sarama.KError, msg *string) error {
+	switch {
+	case kerr == sarama.ErrNoError:
+		return nil
+	case kerr == sarama.ErrSecurityDisabled:
+		return ErrNoAuthorizer
+	case msg != nil && *msg != "":
+		return fmt.Errorf("%w: %s", kerr, *msg)
+	}
+	return kerr
+}
+
+func (a ACL) sarama() (sarama.Resource, sarama.Acl, error) {
+	f, err := ACLFilter(a).sarama()
+	if err != nil {
+		return sarama.Resource{}, sarama.Acl{}, err
+	}
+
+	switch {
+	case f.ResourceType == sarama.AclResourceAny:
+		return sarama.Resource{}, sarama.Acl{}, fmt.Errorf("acl needs a resource type")
+	case f.Operation == sarama.AclOperationAny:
+		return sarama.Resource{}, sarama.Acl{}, fmt.Errorf("acl needs an operation")
+	case f.PermissionType == sarama.AclPermissionAny:
+		return sarama.Resource{}, sarama.Acl{}, fmt.Errorf("acl needs a permission")
+	case a.Principal == "":
+		return sarama.Resource{}, sarama.Acl{}, fmt.Errorf("acl needs a principal")
+	}
+
+	pattern := f.ResourcePatternTypeFilter
+	if pattern == sarama.AclPatternAny {
+		pattern = sarama.AclPatternLiteral
+	} else if pattern == sarama.AclPatternMatch {
+		return sarama.Resource{}, sarama.Acl{}, fmt.Errorf("acl pattern type must be literal or prefixed")
+	}
+
+	host := a.Host
+	if host == "" {
+		host = "*"
+	}
+
+	r := sarama.Resource{ResourceType: f.ResourceType, ResourceName: a.ResourceName, ResourcePatternType: pattern}
+	acl := sarama.Acl{Principal: a.Principal, Host: host, Operation: f.Operation, PermissionType: f.PermissionType}
+	return r, acl, nil
+}
+
+func (f ACLFilter) sarama() (sarama.AclFilter, error) {
+	rt, ok := aclResourceTypes[f.ResourceType]
+	if !ok {
+		return sarama.AclFilter{}, fmt.Errorf("invalid acl resource type %q", f.ResourceType)
+	}
+	pt, ok := aclPatternTypes[f.PatternType]
+	if !ok {
+		return sarama.AclFilter{}, fmt.Errorf("invalid acl pattern type %q", f.PatternType)
+	}
+	op, ok := aclOperations[f.Operation]
+	if !ok {
+		return sarama.AclFilter{}, fmt.Errorf("invalid acl operation %q", f.Operation)
+	}
+	perm, ok := aclPermissions[f.Permission]
+	if !ok {
+		return sarama.AclFilter{}, fmt.Errorf("invalid acl permission %q", f.Permission)
+	}
+
+	out := sarama.AclFilter{
+		ResourceType:              sarama.AclResourceType(rt),
+		ResourcePatternTypeFilter: sarama.AclResourcePatternType(pt),
+		Operation:                 sarama.AclOperation(op),
+		PermissionType:            sarama.AclPermissionType(perm),
+	}
+	if f.ResourceName != "" {
+		out.ResourceName = &f.ResourceName
+	}
+	if f.Principal != "" {
+		out.Principal = &f.Principal
+	}
+	if f.Host != "" {
+		out.Host = &f.Host
+	}
+	return out, nil
+}
+
+func newACL(r sarama.Resource, a sarama.Acl) ACL {
+	return ACL{
+		Principal:    a.Principal,
+		Host:         a.Host,
+		ResourceType: aclName(aclResourceTypes, int(r.ResourceType)),
+		ResourceName: r.ResourceName,
+		PatternType:  aclName(aclPatternTypes, int(r.ResourcePatternType)),
+		Operation:    aclName(aclOperations, int(a.Operation)),
+		Permission:   aclName(aclPermissions, int(a.PermissionType)),
+	}
+}
+
+func aclName(names map[string]int, v int) string {
+	for name, n := range names {
+		if n == v && name != "" {
+			return name
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,152 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// activityLookback is how far before the high water mark TopicActivity
+// starts reading, so that a partition ending in transaction markers still
+// has a record to read.
+const activityLookback = 8
+
+// TopicActivity is when a topic was last written to.  LastWrite is the
+// newest timestamp of the last records of its partitions and is zero,
+// like DaysSinceWrite, when the topic is empty.  Messages is the sum of
+// End - Start over the partitions, which counts compacted away records
+// too.  Sample is the newest message (only when asked for).  Error is
+// set, and the rest may be incomplete, if a partition couldn't be read.
+type TopicActivity struct {
+	Topic          string    `json:"topic"`
+	Partitions     int       `json:"partitions"`
+	Messages       int64     `json:"messages"`
+	Empty          bool      `json:"empty"`
+	LastWrite      time.Time `json:"last_write"`
+	DaysSinceWrite float64   `json:"days_since_write"`
+	Sample         *Message  `json:"sample,omitempty"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// TopicActivity reads the last record of every partition of topics (or
+// of every topic if none are given) and reports, in name order, when
+// each topic was last written to.  With sample the newest message of
+// each topic is included.  Empty partitions aren't read at all and the
+// partition tails are read by the Client's concurrency pool.  WithProgress
+// reports one message per non-empty partition.
+func (c *Client) TopicActivity(ctx context.Context, topics []string, sample bool, opts ...CallOpt) ([]TopicActivity, error) {
+	if len(topics) == 0 {
+		var err error
+		if topics, err = c.GetTopics(); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]TopicActivity, len(topics))
+	var parts []Partition
+	index := map[string]int{}
+	for i, topic := range topics {
+		out[i] = TopicActivity{Topic: topic, Empty: true}
+		index[topic] = i
+
+		tp, err := c.topicPartitions(topic)
+		if err != nil {
+			out[i].Error = err.Error()
+			continue
+		}
+
+		out[i].Partitions = len(tp)
+		for _, p := range tp {
+			out[i].Messages += p.End - p.Start
+			if p.End > p.Start {
+				parts = append(parts, p)
+			}
+		}
+	}
+
+	o, done := getCallOpts(opts).withProgress(int64(len(parts)))
+	defer done()
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.concurrency)
+	for _, p := range parts {
+		wg.Add(1)
+		go func(p Partition) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			last, err := c.lastMessage(ctx, p, o)
+			<-sem
+
+			lock.Lock()
+			defer lock.Unlock()
+			a := &out[index[p.Topic]]
+			switch {
+			case err != nil:
+				if a.Error == "" {
+					a.Error = err.Error()
+				}
+			case last != nil:
+				a.Empty = false
+				if last.Timestamp.After(a.LastWrite) {
+					a.LastWrite = last.Timestamp
+					if sample {
+						a.Sample = last
+					}
+				}
+			}
+		}(p)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range out {
+		if !out[i].LastWrite.IsZero() {
+			out[i].DaysSinceWrite = now.Sub(out[i].LastWrite).Hours() / 24
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Topic < out[j].Topic })
+	return out, nil
+}
+
+// lastMessage returns the newest record of p, or nil if the last few
+// offsets before its End hold none.
+func (c *Client) lastMessage(ctx context.Context, p Partition, o callOpts) (*Message, error) {
+	p.Offset = p.End - activityLookback
+	if p.Offset < p.Start {
+		p.Offset = p.Start
+	}
+
+	var last *sarama.ConsumerMessage
+	err := c.consume(ctx, p, p.End-p.Offset, func(msg *sarama.ConsumerMessage) bool {
+		last = msg
+		return false
+	})
+	if err != nil || last == nil {
+		return nil, err
+	}
+
+	o.prog.scan(last)
+	val, ok, err := c.decode(ctx, o, last.Topic, last.Offset, last.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	m := newMessage(p, last)
+	if ok {
+		m.Value = val
+	}
+	return &m, nil
+}
@@ -0,0 +1,162 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/IBM/sarama"
+)
+
+// ErrDestructive is returned by operations that change or remove data
+// when the Client wasn't created with AllowDestructive.
+var ErrDestructive = errors.New("destructive operations are not enabled, see AllowDestructive")
+
+// ErrUnsupportedVersion is returned by operations whose API is newer than
+// the KafkaVersion the Client speaks.
+var ErrUnsupportedVersion = errors.New("kafka: unsupported broker version")
+
+// AllowDestructive enables the operations that change or remove data
+// (deleting records, changing ACLs, electing leaders, etc).  kcli is a
+// read only browser unless this is set.
+func AllowDestructive() func(*Client) {
+	return func(c *Client) {
+		c.destructive = true
+	}
+}
+
+// checkVersion returns ErrUnsupportedVersion, with a hint about
+// KafkaVersion, unless the Client speaks at least v.  what names the
+// operation that needs it.
+func (c *Client) checkVersion(v sarama.KafkaVersion, what string) error {
+	if c.cfg.Version.IsAtLeast(v) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s needs kafka %s or later, raise it with KafkaVersion (it is %s)", ErrUnsupportedVersion, what, v, c.cfg.Version)
+}
+
+func (c *Client) checkDestructive() error {
+	if !c.destructive {
+		return ErrDestructive
+	}
+	return c.checkClusterID()
+}
+
+// DeleteRecords deletes the records of topic before the given offset of
+// each partition, which advances the partition's Start.  Every offset is
+// checked against the partition's current End before anything is deleted.
+// The requests go to each partition's leader, so some partitions can fail
+// (ie if leadership moved mid-call) while others succeed; the returned
+// map holds the error, or nil, for each partition.
+func (c *Client) DeleteRecords(topic string, partitionToOffset map[int32]int64) (map[int32]error, error) {
+	if err := c.checkDestructive(); err != nil {
+		return nil, err
+	}
+
+	byLeader := map[*sarama.Broker]map[int32]int64{}
+	for p, offset := range partitionToOffset {
+		_, end, err := c.watermarks(topic, p)
+		if err != nil {
+			return nil, err
+		}
+
+		if offset < 0 || offset > end {
+			return nil, fmt.Errorf("offset %d is past the end (%d) of %s partition %d", offset, end, topic, p)
+		}
+
+		leader, err := c.sarama.Leader(topic, p)
+		if err != nil {
+			return nil, err
+		}
+
+		if byLeader[leader] == nil {
+			byLeader[leader] = map[int32]int64{}
+		}
+		byLeader[leader][p] = offset
+	}
+
+	out := map[int32]error{}
+	for leader, offsets := range byLeader {
+		resp, err := leader.DeleteRecords(&sarama.DeleteRecordsRequest{
+			Topics:  map[string]*sarama.DeleteRecordsRequestTopic{topic: {PartitionOffsets: offsets}},
+			Timeout: c.cfg.Admin.Timeout,
+		})
+
+		for p := range offsets {
+			switch {
+			case err != nil:
+				out[p] = err
+			case resp.Topics[topic] == nil || resp.Topics[topic].Partitions[p] == nil:
+				out[p] = sarama.ErrIncompleteResponse
+			case resp.Topics[topic].Partitions[p].Err != sarama.ErrNoError:
+				out[p] = resp.Topics[topic].Partitions[p].Err
+			default:
+				out[p] = nil
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// ElectLeaders triggers a preferred leader election for the given
+// partitions of topic (all of them when partitions is empty) and returns
+// the outcome for each partition.  A partition that is already led by
+// its preferred replica succeeds.  It requires AllowDestructive and kafka
+// 2.3 or later, with an older KafkaVersion it returns
+// ErrUnsupportedVersion without sending anything.
+func (c *Client) ElectLeaders(topic string, partitions []int32) (map[int32]error, error) {
+	if err := c.checkDestructive(); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkVersion(sarama.V2_3_0_0, "electing leaders"); err != nil {
+		return nil, wrapErr("elect leaders", topic, -1, -1, err)
+	}
+
+	all, err := c.sarama.Partitions(topic)
+	if err != nil {
+		return nil, wrapErr("elect leaders", topic, -1, -1, err)
+	}
+
+	if len(partitions) == 0 {
+		partitions = all
+	}
+
+	known := map[int32]bool{}
+	for _, p := range all {
+		known[p] = true
+	}
+
+	for _, p := range partitions {
+		if !known[p] {
+			return nil, wrapErr("elect leaders", topic, p, -1, sarama.ErrUnknownTopicOrPartition)
+		}
+	}
+
+	admin, err := c.newAdmin()
+	if err != nil {
+		return nil, wrapErr("elect leaders", topic, -1, -1, err)
+	}
+	defer admin.Close()
+
+	resp, err := admin.ElectLeaders(sarama.PreferredElection, map[string][]int32{topic: partitions})
+	if err != nil {
+		return nil, wrapErr("elect leaders", topic, -1, -1, err)
+	}
+
+	out := map[int32]error{}
+	for _, p := range partitions {
+		r := resp[topic][p]
+		switch {
+		case r == nil:
+			out[p] = sarama.ErrIncompleteResponse
+		case r.ErrorCode == sarama.ErrNoError, r.ErrorCode == sarama.ErrElectionNotNeeded:
+			out[p] = nil
+		case r.ErrorMessage != nil && *r.ErrorMessage != "":
+			out[p] = fmt.Errorf("%w: %s", r.ErrorCode, *r.ErrorMessage)
+		default:
+			out[p] = r.ErrorCode
+		}
+	}
+	return out, nil
+}
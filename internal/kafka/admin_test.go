@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestElectLeadersVersion(t *testing.T) {
+	m := newMockCluster()
+	m.createTopic("t", 2)
+
+	tests := []struct {
+		name string
+		opts []Opt
+		want error
+	}{
+		{name: "not destructive", opts: []Opt{KafkaVersion("2.3.0")}, want: ErrDestructive},
+		{name: "default version", opts: []Opt{AllowDestructive()}, want: ErrUnsupportedVersion},
+		{name: "2.2.0", opts: []Opt{AllowDestructive(), KafkaVersion("2.2.0")}, want: ErrUnsupportedVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli, err := m.client(tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cli.Close()
+
+			_, err = cli.ElectLeaders("t", nil)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+			if tt.want == ErrUnsupportedVersion && !strings.Contains(err.Error(), "2.3.0") {
+				t.Errorf("%q doesn't say which version is needed", err)
+			}
+		})
+	}
+}
+
+func TestListPartitionReassignmentsVersion(t *testing.T) {
+	m := newMockCluster()
+	m.createTopic("t", 1)
+
+	cli, err := m.client(KafkaVersion("2.3.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	_, err = cli.ListPartitionReassignments("t")
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Fatalf("got %v, want %v", err, ErrUnsupportedVersion)
+	}
+	if !strings.Contains(err.Error(), "2.4.0") || !strings.Contains(err.Error(), "KafkaVersion") {
+		t.Errorf("%q doesn't say which version is needed", err)
+	}
+}
+
+func TestClientQuotasVersion(t *testing.T) {
+	cli, err := newMockCluster().client(AllowDestructive(), KafkaVersion("2.5.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	_, err = cli.DescribeClientQuotas(QuotaFilter{})
+	if !errors.Is(err, ErrUnsupportedVersion) || !strings.Contains(err.Error(), "2.6.0") {
+		t.Errorf("describe: got %v, want %v needing 2.6.0", err, ErrUnsupportedVersion)
+	}
+
+	err = cli.AlterClientQuotas(nil)
+	if !errors.Is(err, ErrUnsupportedVersion) || !strings.Contains(err.Error(), "2.6.0") {
+		t.Errorf("alter: got %v, want %v needing 2.6.0", err, ErrUnsupportedVersion)
+	}
+}
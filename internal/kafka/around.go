@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+)
+
+// FetchAround returns up to before messages preceding offset, the message
+// at offset (with Target set) and up to after messages following it,
+// clamped to the partition's watermarks.  before and after count records
+// rather than offsets, so on a compacted topic the window reaches back
+// past the gaps.  If the message at offset was compacted away none is
+// marked and the following messages start at the next one.  opts are as
+// for FetchN.
+func (c *Client) FetchAround(ctx context.Context, topic string, partition int32, offset int64, before, after int, opts ...CallOpt) ([]Message, error) {
+	start, end, err := c.watermarks(topic, partition)
+	if err != nil {
+		return nil, err
+	}
+
+	if offset < start || offset >= end {
+		return nil, wrapErr("fetch around", topic, partition, offset, fmt.Errorf("%w: partition holds %d to %d", ErrOffsetOutOfRange, start, end-1))
+	}
+
+	o := getCallOpts(opts)
+	part := Partition{Topic: topic, Partition: partition, Start: start, End: end}
+
+	prev, err := c.preceding(ctx, part, offset, before, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var next []Message
+	part.Offset = offset
+	if _, err := c.fetch(ctx, part, int64(after)+1, o, func(m Message) bool {
+		m.Target = m.Offset == offset
+		next = append(next, m)
+		return false
+	}); err != nil {
+		return nil, err
+	}
+
+	// without the target the last message is one too many
+	if len(next) > after && !next[0].Target {
+		next = next[:after]
+	}
+	return append(prev, next...), nil
+}
+
+// preceding returns the last n messages before offset, starting the
+// consumer further back (doubling the distance each time) until n records
+// have been read or it reaches the start of the partition.
+func (c *Client) preceding(ctx context.Context, part Partition, offset int64, n int, o callOpts) ([]Message, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	if offset == part.Start {
+		return nil, nil
+	}
+
+	window := int64(n)
+	for {
+		from := offset - window
+		if from < part.Start {
+			from = part.Start
+		}
+
+		var out []Message
+		p := part
+		p.Offset, p.End = from, offset
+		if _, err := c.fetch(ctx, p, offset-from, o, func(m Message) bool {
+			out = append(out, m)
+			return false
+		}); err != nil {
+			return nil, err
+		}
+
+		if len(out) >= n || from == part.Start {
+			if len(out) > n {
+				out = out[len(out)-n:]
+			}
+			return out, nil
+		}
+		window *= 2
+	}
+}
@@ -0,0 +1,150 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/IBM/sarama"
+	"github.com/linkedin/goavro/v2"
+)
+
+const defaultSyncInterval = 1000
+
+// AvroOpts configures an ExportAvro
+type AvroOpts struct {
+	// SyncInterval is the number of records written per block of the
+	// container file.  Defaults to 1000.
+	SyncInterval int
+
+	// Rejects, when set, gets the messages that don't conform to the
+	// schema as JSON lines.
+	Rejects io.Writer
+}
+
+// AvroStats reports what an ExportAvro did
+type AvroStats struct {
+	Written  int64 `json:"written"`
+	Rejected int64 `json:"rejected"`
+}
+
+// ExportAvro writes the decoded messages of parts, from each partition's
+// Offset to its End, to w as a deflate compressed Avro object container
+// file with the given writer schema.  Values must be JSON, either plain
+// or in Avro's JSON encoding (ie from the schema registry Decoder).
+// Messages that don't conform to the schema are counted and skipped.
+func (c *Client) ExportAvro(ctx context.Context, parts []Partition, w io.Writer, schema string, opts AvroOpts, callOpts ...CallOpt) (AvroStats, error) {
+	var st AvroStats
+	plain, err := goavro.NewCodecForStandardJSON(schema)
+	if err != nil {
+		return st, fmt.Errorf("invalid avro schema: %s", err)
+	}
+
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return st, fmt.Errorf("invalid avro schema: %s", err)
+	}
+
+	ocf, err := goavro.NewOCFWriter(goavro.OCFConfig{W: w, Schema: schema, CompressionName: goavro.CompressionDeflateLabel})
+	if err != nil {
+		return st, err
+	}
+
+	interval := opts.SyncInterval
+	if interval <= 0 {
+		interval = defaultSyncInterval
+	}
+
+	var rejects *json.Encoder
+	if opts.Rejects != nil {
+		rejects = json.NewEncoder(opts.Rejects)
+	}
+
+	block := make([]interface{}, 0, interval)
+	flush := func() error {
+		if len(block) == 0 {
+			return nil
+		}
+		if err := ocf.Append(block); err != nil {
+			return err
+		}
+		st.Written += int64(len(block))
+		block = block[:0]
+		return nil
+	}
+
+	o := getCallOpts(callOpts)
+	for _, p := range parts {
+		var werr error
+		_, err := c.fetch(ctx, p, p.End-p.Offset, o, func(m Message) bool {
+			native, _, err := plain.NativeFromTextual(m.Value)
+			if err != nil {
+				native, _, err = codec.NativeFromTextual(m.Value)
+			}
+
+			if err != nil {
+				st.Rejected++
+				if rejects != nil {
+					werr = rejects.Encode(m)
+				}
+				return werr != nil
+			}
+
+			block = append(block, native)
+			if len(block) >= interval {
+				werr = flush()
+			}
+			return werr != nil
+		})
+
+		if werr != nil {
+			return st, werr
+		}
+
+		if err != nil {
+			return st, err
+		}
+	}
+
+	return st, flush()
+}
+
+// AvroEncoder turns JSON into a message value for a schema registry
+// subject, see schemaregistry.Encoder.
+type AvroEncoder interface {
+	Encode(ctx context.Context, subject string, value []byte) ([]byte, error)
+}
+
+// WithAvroEncoder sets the encoder ProduceAvro uses.
+func WithAvroEncoder(e AvroEncoder) Opt {
+	return func(c *Client) {
+		c.avroEncoder = e
+	}
+}
+
+// ProduceAvro encodes jsonValue with the schema of subject (see
+// WithAvroEncoder), produces it to topic with key and returns where it
+// was written.
+func (c *Client) ProduceAvro(topic string, key []byte, jsonValue []byte, subject string) (int32, int64, error) {
+	if c.avroEncoder == nil {
+		return 0, 0, fmt.Errorf("produce avro to %s: no AvroEncoder, see WithAvroEncoder", topic)
+	}
+
+	val, err := c.avroEncoder.Encode(context.Background(), subject, jsonValue)
+	if err != nil {
+		return 0, 0, fmt.Errorf("produce avro to %s: %w", topic, err)
+	}
+
+	prod, err := c.newProducer(func(*sarama.Config) {})
+	if err != nil {
+		return 0, 0, wrapErr("create producer", topic, -1, -1, err)
+	}
+	defer prod.Close()
+
+	p, o, err := prod.SendMessage(producerMessage(topic, 0, key, val, nil))
+	if err != nil {
+		return 0, 0, wrapErr("produce", topic, -1, -1, err)
+	}
+	return p, o, nil
+}
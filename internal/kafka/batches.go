@@ -0,0 +1,256 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// batchHeaderSize is the size of a v2 record batch without its records
+const batchHeaderSize = 61
+
+// BatchInfo describes a record batch as the broker stores it.  Bytes is
+// the size of the batch with its records uncompressed and WireBytes its
+// size as it was fetched, so WireBytes/Bytes is the compression ratio.
+// WireBytes is 0 when it isn't known, which only happens with brokers
+// older than 0.10.1 that send more than one batch per fetch.  Batches in
+// the old message format (before kafka 0.11) have Version 0 or 1, no
+// producer and, when compressed, are the wrapper message and its inner
+// messages.
+type BatchInfo struct {
+	FirstOffset    int64     `json:"first_offset"`
+	LastOffset     int64     `json:"last_offset"`
+	Records        int       `json:"records"`
+	Bytes          int       `json:"bytes"`
+	WireBytes      int       `json:"wire_bytes"`
+	Codec          string    `json:"codec"`
+	Version        int8      `json:"version"`
+	ProducerID     int64     `json:"producer_id"`
+	ProducerEpoch  int16     `json:"producer_epoch"`
+	FirstSequence  int32     `json:"first_sequence"`
+	Transactional  bool      `json:"transactional"`
+	Control        bool      `json:"control"`
+	FirstTimestamp time.Time `json:"first_timestamp"`
+	MaxTimestamp   time.Time `json:"max_timestamp"`
+}
+
+// InspectBatches sends fetch requests straight to the leader of part and
+// calls cb with the next n batches (the first of which may start before
+// part.Offset if the offset is in the middle of a batch).  It stops at
+// the high water mark.  Each fetch asks for a single batch so its size
+// on the wire is known, which makes it a round trip per batch.
+func (c *Client) InspectBatches(ctx context.Context, part Partition, n int, cb func(BatchInfo)) error {
+	leader, err := c.sarama.Leader(part.Topic, part.Partition)
+	if err != nil {
+		return wrapErr("inspect batches", part.Topic, part.Partition, -1, err)
+	}
+
+	b, sizes, err := c.inspectConn(leader)
+	if err != nil {
+		return wrapErr("inspect batches", part.Topic, part.Partition, -1, err)
+	}
+	defer b.Close()
+
+	// brokers return the first batch whole however small this is
+	// (KIP-74), older ones cut it off and it has to grow
+	var max int32 = 1
+	offset := part.Offset
+	for n > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		req := &sarama.FetchRequest{
+			Version:     4,
+			MaxWaitTime: int32(c.cfg.Consumer.MaxWaitTime.Nanoseconds() / 1e6),
+			MinBytes:    1,
+			MaxBytes:    sarama.MaxResponseSize,
+		}
+		req.AddBlock(part.Topic, part.Partition, offset, max, -1)
+
+		sizes.clear()
+		start := time.Now()
+		resp, err := b.Fetch(req)
+		c.traced(TraceFetchBatch, b, part.Topic, part.Partition, start, err)
+		if err != nil {
+			return wrapErr("inspect batches", part.Topic, part.Partition, offset, err)
+		}
+
+		block := resp.GetBlock(part.Topic, part.Partition)
+		switch {
+		case block == nil:
+			return wrapErr("inspect batches", part.Topic, part.Partition, offset, fmt.Errorf("broker %d sent no data", b.ID()))
+		case block.Err != sarama.ErrNoError:
+			return wrapErr("inspect batches", part.Topic, part.Partition, offset, block.Err)
+		}
+
+		batches := blockBatches(block)
+		if len(batches) == 0 {
+			if offset >= block.HighWaterMarkOffset {
+				return nil
+			}
+			// the batch didn't fit
+			if max, err = c.growFetch(max); err != nil {
+				return wrapErr("inspect batches", part.Topic, part.Partition, offset, err)
+			}
+			continue
+		}
+
+		if len(batches) == 1 && len(block.RecordsSet) == 1 {
+			batches[0].WireBytes = sizes.last()
+		}
+
+		for _, bi := range batches {
+			if bi.LastOffset < offset {
+				continue
+			}
+			cb(bi)
+			offset = bi.LastOffset + 1
+			if n--; n == 0 {
+				break
+			}
+		}
+
+		if offset >= block.HighWaterMarkOffset {
+			return nil
+		}
+	}
+	return nil
+}
+
+// inspectConn opens a connection to broker with a metric registry of its
+// own.  sarama drops the compressed bytes of a batch once it has
+// decompressed them, the only record of how big the records of a fetch
+// response were is the consumer-fetch-response-size histogram, and with
+// a registry of its own nothing else adds to it.
+func (c *Client) inspectConn(broker *sarama.Broker) (*sarama.Broker, *responseSizes, error) {
+	cfg := *c.cfg
+	cfg.MetricRegistry = sarama.NewConfig().MetricRegistry
+
+	b := sarama.NewBroker(broker.Addr())
+	if err := b.Open(&cfg); err != nil {
+		return nil, nil, err
+	}
+	return b, &responseSizes{registry: cfg.MetricRegistry}, nil
+}
+
+// histogram is the part of a go-metrics Histogram responseSizes uses
+type histogram interface {
+	Clear()
+	Count() int64
+	Max() int64
+}
+
+// responseSizes reads the size of the records in the last fetch
+// response from a broker's metric registry.
+type responseSizes struct {
+	registry interface{ Get(string) interface{} }
+}
+
+func (r *responseSizes) histogram() histogram {
+	h, _ := r.registry.Get("consumer-fetch-response-size").(histogram)
+	return h
+}
+
+func (r *responseSizes) clear() {
+	if h := r.histogram(); h != nil {
+		h.Clear()
+	}
+}
+
+// last returns the size of the records of the last response, or 0 if
+// there isn't exactly one.
+func (r *responseSizes) last() int {
+	h := r.histogram()
+	if h == nil || h.Count() != 1 {
+		return 0
+	}
+	return int(h.Max())
+}
+
+// blockBatches describes the complete batches of a fetch response block
+func blockBatches(block *sarama.FetchResponseBlock) []BatchInfo {
+	var out []BatchInfo
+	for _, records := range block.RecordsSet {
+		if batch := records.RecordBatch; batch != nil {
+			if batch.PartialTrailingRecord {
+				continue
+			}
+
+			bi := BatchInfo{
+				FirstOffset:    batch.FirstOffset,
+				LastOffset:     batch.FirstOffset + int64(batch.LastOffsetDelta),
+				Records:        len(batch.Records),
+				Bytes:          batchHeaderSize,
+				Codec:          batch.Codec.String(),
+				Version:        batch.Version,
+				ProducerID:     batch.ProducerID,
+				ProducerEpoch:  batch.ProducerEpoch,
+				FirstSequence:  batch.FirstSequence,
+				Transactional:  batch.IsTransactional,
+				Control:        batch.Control,
+				FirstTimestamp: batch.FirstTimestamp,
+				MaxTimestamp:   batch.MaxTimestamp,
+			}
+			for _, rec := range batch.Records {
+				bi.Bytes += recordSize(rec)
+			}
+			out = append(out, bi)
+		}
+
+		if set := records.MsgSet; set != nil {
+			if set.PartialTrailingMessage {
+				continue
+			}
+
+			for _, outer := range set.Messages {
+				inner := outer.Messages()
+				if len(inner) == 0 {
+					inner = []*sarama.MessageBlock{outer}
+				}
+
+				bi := BatchInfo{
+					LastOffset:     outer.Offset,
+					Records:        len(inner),
+					Codec:          outer.Msg.Codec.String(),
+					Version:        outer.Msg.Version,
+					ProducerID:     -1,
+					FirstTimestamp: inner[0].Msg.Timestamp,
+					MaxTimestamp:   outer.Msg.Timestamp,
+				}
+				bi.FirstOffset = outer.Offset - int64(len(inner)) + 1
+				for _, mb := range inner {
+					bi.Bytes += len(mb.Msg.Key) + len(mb.Msg.Value)
+				}
+				out = append(out, bi)
+			}
+		}
+	}
+	return out
+}
+
+// recordSize is the size of rec in the v2 record format
+func recordSize(rec *sarama.Record) int {
+	n := 1 + varintSize(int64(rec.TimestampDelta/time.Millisecond)) + varintSize(rec.OffsetDelta)
+	n += bytesSize(rec.Key) + bytesSize(rec.Value) + varintSize(int64(len(rec.Headers)))
+	for _, h := range rec.Headers {
+		n += bytesSize(h.Key) + bytesSize(h.Value)
+	}
+	return varintSize(int64(n)) + n
+}
+
+// bytesSize is the size of b with its varint length (-1 for nil)
+func bytesSize(b []byte) int {
+	if b == nil {
+		return varintSize(-1)
+	}
+	return varintSize(int64(len(b))) + len(b)
+}
+
+func varintSize(x int64) int {
+	var buf [binary.MaxVarintLen64]byte
+	return binary.PutVarint(buf[:], x)
+}
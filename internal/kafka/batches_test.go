@@ -0,0 +1,56 @@
+package kafka
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func TestInspectBatches(t *testing.T) {
+	tests := []struct {
+		codec sarama.CompressionCodec
+		check func(BatchInfo) bool
+	}{
+		{codec: sarama.CompressionNone, check: func(bi BatchInfo) bool { return bi.WireBytes == bi.Bytes }},
+		{codec: sarama.CompressionGZIP, check: func(bi BatchInfo) bool { return bi.WireBytes > batchHeaderSize && bi.WireBytes < bi.Bytes/10 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.codec.String(), func(t *testing.T) {
+			resp := &sarama.FetchResponse{Version: 4}
+			for i := 0; i < 3; i++ {
+				resp.AddRecord("t", 0, nil, sarama.StringEncoder(strings.Repeat("x", 2048)), int64(i))
+			}
+			batch := resp.GetBlock("t", 0).RecordsSet[0].RecordBatch
+			batch.Codec = tt.codec
+			batch.CompressionLevel = sarama.CompressionLevelDefault
+			resp.SetLastOffsetDelta("t", 0, 2)
+			resp.GetBlock("t", 0).HighWaterMarkOffset = 3
+			proxy := newFetchBroker(t, resp)
+
+			cli, err := New([]string{proxy.Addr()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cli.Close()
+
+			var got []BatchInfo
+			if err := cli.InspectBatches(context.Background(), Partition{Topic: "t", Partition: 0}, 5, func(bi BatchInfo) { got = append(got, bi) }); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("got %d batches, want 1", len(got))
+			}
+
+			bi := got[0]
+			if bi.Records != 3 || bi.LastOffset != 2 || bi.Codec != tt.codec.String() {
+				t.Errorf("got %+v, want 3 records up to offset 2 compressed with %s", bi, tt.codec)
+			}
+			if !tt.check(bi) {
+				t.Errorf("got %d bytes on the wire for %d bytes of records", bi.WireBytes, bi.Bytes)
+			}
+		})
+	}
+}
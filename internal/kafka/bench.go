@@ -0,0 +1,151 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// benchSamples is how many latencies a benchmark keeps for its
+// percentiles.  Longer runs keep a uniform sample.
+const benchSamples = 100000
+
+// BenchResult is the outcome of Benchmark or BenchmarkProduce.  For a
+// consume benchmark the latencies are the time between each message's
+// timestamp and when it was read, so they include the message's age when
+// reading a backlog.  For a produce benchmark they are the time it took
+// the broker to acknowledge each message.
+type BenchResult struct {
+	Messages       int64         `json:"messages"`
+	Bytes          int64         `json:"bytes"`
+	Elapsed        time.Duration `json:"elapsed"`
+	MessagesPerSec float64       `json:"messages_per_sec"`
+	MBPerSec       float64       `json:"mb_per_sec"`
+	LatencyP50     time.Duration `json:"latency_p50"`
+	LatencyP95     time.Duration `json:"latency_p95"`
+	LatencyP99     time.Duration `json:"latency_p99"`
+	LatencyMax     time.Duration `json:"latency_max"`
+}
+
+// Benchmark consumes part, from its Offset, as fast as it can for
+// duration (or until it reaches End) and reports the throughput.  Pass
+// RawBytes to leave the Decoder out of the measurement.
+func (c *Client) Benchmark(ctx context.Context, part Partition, duration time.Duration, opts ...CallOpt) (BenchResult, error) {
+	o := getCallOpts(opts)
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	b := newBench()
+	var derr error
+	err := c.consume(ctx, part, part.End-part.Offset, func(msg *sarama.ConsumerMessage) bool {
+		if _, _, derr = c.decode(ctx, o, part.Topic, msg.Offset, msg.Value); derr != nil {
+			return true
+		}
+		b.add(len(msg.Key)+len(msg.Value), time.Since(msg.Timestamp))
+		return false
+	})
+
+	if derr != nil {
+		return b.result(), derr
+	}
+
+	if err == context.DeadlineExceeded {
+		err = nil
+	}
+	return b.result(), err
+}
+
+// BenchmarkProduce writes messages of size bytes to topic at up to rate
+// messages per second (0 means as fast as possible) for duration and
+// reports the throughput.  topic should be a scratch topic, the messages
+// are padding.
+func (c *Client) BenchmarkProduce(ctx context.Context, topic string, rate int, size int, duration time.Duration) (BenchResult, error) {
+	prod, err := c.newProducer(func(*sarama.Config) {})
+	if err != nil {
+		return BenchResult{}, wrapErr("create producer", topic, -1, -1, err)
+	}
+	defer prod.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	val := bytes.Repeat([]byte("x"), size)
+	var interval time.Duration
+	if rate > 0 {
+		interval = time.Second / time.Duration(rate)
+	}
+
+	b := newBench()
+	next := time.Now()
+	for ctx.Err() == nil {
+		if interval > 0 {
+			select {
+			case <-time.After(time.Until(next)):
+			case <-ctx.Done():
+				continue
+			}
+			next = next.Add(interval)
+		}
+
+		start := time.Now()
+		if _, _, err := prod.SendMessage(producerMessage(topic, 0, nil, val, nil)); err != nil {
+			return b.result(), wrapErr("produce", topic, -1, -1, err)
+		}
+		b.add(size, time.Since(start))
+	}
+
+	return b.result(), nil
+}
+
+type bench struct {
+	start     time.Time
+	messages  int64
+	bytes     int64
+	latencies []time.Duration
+	rnd       *rand.Rand
+}
+
+func newBench() *bench {
+	return &bench{start: time.Now(), rnd: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func (b *bench) add(size int, latency time.Duration) {
+	b.messages++
+	b.bytes += int64(size)
+	if len(b.latencies) < benchSamples {
+		b.latencies = append(b.latencies, latency)
+	} else if i := b.rnd.Int63n(b.messages); i < benchSamples {
+		b.latencies[i] = latency
+	}
+}
+
+func (b *bench) result() BenchResult {
+	r := BenchResult{Messages: b.messages, Bytes: b.bytes, Elapsed: time.Since(b.start)}
+	if secs := r.Elapsed.Seconds(); secs > 0 {
+		r.MessagesPerSec = float64(r.Messages) / secs
+		r.MBPerSec = float64(r.Bytes) / secs / (1 << 20)
+	}
+
+	if len(b.latencies) == 0 {
+		return r
+	}
+
+	sort.Slice(b.latencies, func(i, j int) bool { return b.latencies[i] < b.latencies[j] })
+	at := func(p int) time.Duration {
+		i := (len(b.latencies)*p + 99) / 100
+		if i > 0 {
+			i--
+		}
+		return b.latencies[i]
+	}
+
+	r.LatencyP50 = at(50)
+	r.LatencyP95 = at(95)
+	r.LatencyP99 = at(99)
+	r.LatencyMax = b.latencies[len(b.latencies)-1]
+	return r
+}
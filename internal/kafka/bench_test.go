@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBenchPercentiles(t *testing.T) {
+	b := newBench()
+	for _, i := range []int{100, 1, 50, 99, 2, 95} {
+		b.add(10, time.Duration(i)*time.Millisecond)
+	}
+	for i := 3; i <= 98; i++ {
+		if i != 50 && i != 95 {
+			b.add(10, time.Duration(i)*time.Millisecond)
+		}
+	}
+
+	r := b.result()
+	if r.Messages != 100 || r.Bytes != 1000 {
+		t.Errorf("got %d messages and %d bytes, want 100 and 1000", r.Messages, r.Bytes)
+	}
+	if r.LatencyP50 != 50*time.Millisecond || r.LatencyP95 != 95*time.Millisecond || r.LatencyP99 != 99*time.Millisecond || r.LatencyMax != 100*time.Millisecond {
+		t.Errorf("got p50 %s, p95 %s, p99 %s and max %s", r.LatencyP50, r.LatencyP95, r.LatencyP99, r.LatencyMax)
+	}
+
+	if r := newBench().result(); r.LatencyMax != 0 || r.Messages != 0 {
+		t.Errorf("an empty benchmark reported %+v", r)
+	}
+}
+
+func TestBenchmark(t *testing.T) {
+	m := newMockCluster()
+	for i := 0; i < 5; i++ {
+		m.produce("t", 0, []byte("k"), []byte("value"))
+	}
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	r, err := cli.Benchmark(context.Background(), Partition{Topic: "t", Partition: 0, End: 5}, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Messages != 5 || r.Bytes != 30 || r.MessagesPerSec <= 0 {
+		t.Errorf("got %+v, want 5 messages of 6 bytes", r)
+	}
+}
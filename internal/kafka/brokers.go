@@ -0,0 +1,165 @@
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"golang.org/x/net/proxy"
+)
+
+// addrSchemes are the schemes an address passed to New may start with
+var addrSchemes = map[string]bool{"tls": true, "plaintext": false}
+
+// Addrs returns the addresses of every broker the Client knows about:
+// the ones passed to New followed by the ones learned from the cluster's
+// metadata.  The consumers, producers and admin connections the Client
+// opens for individual calls bootstrap from all of them, so they still
+// connect when the seed brokers are down.
+func (c *Client) Addrs() []string {
+	c.addrsMu.Lock()
+	defer c.addrsMu.Unlock()
+	return append([]string(nil), c.addrs...)
+}
+
+// learnAddrs adds the brokers in the sarama client's metadata to the
+// known addresses and returns them all.
+func (c *Client) learnAddrs() []string {
+	var found []string
+	for _, b := range c.sarama.Brokers() {
+		found = append(found, b.Addr())
+	}
+	sort.Strings(found)
+
+	c.addrsMu.Lock()
+	defer c.addrsMu.Unlock()
+
+	known := map[string]bool{}
+	for _, a := range c.addrs {
+		known[a] = true
+	}
+
+	for _, a := range found {
+		if !known[a] {
+			c.logf("learned broker address %s", a)
+			c.addrs = append(c.addrs, a)
+			known[a] = true
+		}
+	}
+	return append([]string(nil), c.addrs...)
+}
+
+// parseAddrs strips the scheme (tls:// or plaintext://) from the
+// addresses that have one and returns, for those, whether to use TLS.
+func parseAddrs(addrs []string) ([]string, map[string]bool, error) {
+	out := make([]string, len(addrs))
+	var hints map[string]bool
+	for i, a := range addrs {
+		out[i] = a
+		j := strings.Index(a, "://")
+		if j < 0 {
+			continue
+		}
+
+		useTLS, ok := addrSchemes[a[:j]]
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid broker address %q: unknown scheme %q (accepted schemes are tls:// and plaintext://)", a, a[:j])
+		}
+
+		if hints == nil {
+			hints = map[string]bool{}
+		}
+		out[i] = a[j+3:]
+		hints[out[i]] = useTLS
+	}
+	return out, hints, nil
+}
+
+// useDialer makes cfg connect to the brokers in hints with or without
+// TLS as they say, to every other broker as cfg says, and through
+// forward when it isn't nil.  sarama has one TLS setting for all brokers
+// and ignores the proxy when TLS is on, so the dialing is done by a
+// brokerDialer posing as a proxy that does its own TLS.
+func useDialer(cfg *sarama.Config, hints map[string]bool, forward proxy.ContextDialer) {
+	if len(hints) == 0 && forward == nil {
+		return
+	}
+
+	d := &brokerDialer{
+		hints:   hints,
+		tls:     cfg.Net.TLS.Enable,
+		config:  cfg.Net.TLS.Config,
+		forward: forward,
+		timeout: cfg.Net.DialTimeout,
+	}
+	if d.config == nil {
+		d.config = &tls.Config{}
+	}
+
+	if d.forward == nil {
+		d.forward = &net.Dialer{
+			Timeout:   cfg.Net.DialTimeout,
+			KeepAlive: cfg.Net.KeepAlive,
+			LocalAddr: cfg.Net.LocalAddr,
+		}
+	}
+
+	cfg.Net.TLS.Enable = false
+	cfg.Net.TLS.Config = nil
+	cfg.Net.Proxy.Enable = true
+	cfg.Net.Proxy.Dialer = d
+}
+
+// brokerDialer dials each broker, with or without TLS, via forward
+type brokerDialer struct {
+	hints   map[string]bool
+	tls     bool
+	config  *tls.Config
+	forward proxy.ContextDialer
+	timeout time.Duration
+}
+
+// Dial is what sarama calls; it has no context of its own, so the dial
+// is cancelled after the dial timeout.
+func (d *brokerDialer) Dial(network, addr string) (net.Conn, error) {
+	ctx := context.Background()
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+	return d.DialContext(ctx, network, addr)
+}
+
+func (d *brokerDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := d.forward.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	useTLS, ok := d.hints[addr]
+	if !ok {
+		useTLS = d.tls
+	}
+	if !useTLS {
+		return conn, nil
+	}
+
+	cfg := d.config
+	if cfg.ServerName == "" {
+		cfg = cfg.Clone()
+		cfg.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+
+	tconn := tls.Client(conn, cfg)
+	if err := tconn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tconn, nil
+}
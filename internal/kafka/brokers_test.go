@@ -0,0 +1,52 @@
+package kafka
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+// TestSeedBrokerDown connects through a seed broker that then goes away
+// and reads a partition led by a broker only the metadata knows about.
+func TestSeedBrokerDown(t *testing.T) {
+	seed := sarama.NewMockBroker(t, 1)
+	other := sarama.NewMockBroker(t, 2)
+	defer other.Close()
+
+	metadata := sarama.NewMockMetadataResponse(t).
+		SetBroker(seed.Addr(), seed.BrokerID()).
+		SetBroker(other.Addr(), other.BrokerID()).
+		SetController(other.BrokerID()).
+		SetLeader("t", 0, other.BrokerID())
+	seed.SetHandlerByMap(map[string]sarama.MockResponse{"MetadataRequest": metadata})
+	other.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": metadata,
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("t", 0, sarama.OffsetOldest, 0).
+			SetOffset("t", 0, sarama.OffsetNewest, 2),
+		"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			SetMessage("t", 0, 0, sarama.StringEncoder("a")).
+			SetMessage("t", 0, 1, sarama.StringEncoder("b")).
+			SetHighWaterMark("t", 0, 2),
+	})
+
+	cli, err := New([]string{seed.Addr()})
+	seed.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	if want := []string{seed.Addr(), other.Addr()}; !reflect.DeepEqual(cli.Addrs(), want) {
+		t.Fatalf("got addrs %v, want %v", cli.Addrs(), want)
+	}
+
+	msgs, err := cli.GetPartition(Partition{Topic: "t", Partition: 0, End: 2}, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(values(msgs), want) {
+		t.Errorf("got %v, want %v", values(msgs), want)
+	}
+}
@@ -0,0 +1,129 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// SearchCheckpoint records how far a SearchTopicResumable got so that an
+// interrupted search can be continued.  It is meant to be saved as JSON.
+type SearchCheckpoint struct {
+	Search     string                `json:"search"`
+	Partitions []PartitionCheckpoint `json:"partitions"`
+}
+
+// PartitionCheckpoint is the progress of a search of one partition.  Next
+// is the next offset to scan.  Found is set (and Match is the offset of
+// the match) once a match has been found and Done once the partition was
+// scanned to its End without one.  Restarted is set when retention moved
+// the partition's Start past Next so the scan had to start again at the
+// new Start.
+type PartitionCheckpoint struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Next      int64  `json:"next"`
+	Found     bool   `json:"found"`
+	Match     int64  `json:"match"`
+	Done      bool   `json:"done"`
+	Restarted bool   `json:"restarted,omitempty"`
+}
+
+// SearchTopicResumable searches parts for the first message that contains
+// s, like SearchTopic, continuing from cp (which can be empty for a new
+// search).  It returns the partitions that matched, with their Offset
+// set to the match, and the updated checkpoint.  If ctx is cancelled, or a
+// partition fails, the checkpoint still records the progress that was
+// made so it can be passed to a later call.
+func (c *Client) SearchTopicResumable(ctx context.Context, parts []Partition, s string, cp SearchCheckpoint, opts ...CallOpt) (SearchCheckpoint, []Partition, error) {
+	if len(cp.Partitions) > 0 && cp.Search != s {
+		return cp, nil, fmt.Errorf("the checkpoint is for a search for %q, not %q", cp.Search, s)
+	}
+
+	prev := map[string]PartitionCheckpoint{}
+	for _, pc := range cp.Partitions {
+		prev[checkpointKey(pc.Topic, pc.Partition)] = pc
+	}
+
+	out := SearchCheckpoint{Search: s, Partitions: make([]PartitionCheckpoint, len(parts))}
+	errs := make([]error, len(parts))
+	sem := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+	for i, p := range parts {
+		pc, ok := prev[checkpointKey(p.Topic, p.Partition)]
+		if !ok {
+			pc = PartitionCheckpoint{Topic: p.Topic, Partition: p.Partition, Next: p.Offset}
+		}
+
+		if pc.Found || pc.Done {
+			out.Partitions[i] = pc
+			continue
+		}
+
+		if pc.Next < p.Start {
+			pc.Next = p.Start
+			pc.Restarted = true
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, p Partition, pc PartitionCheckpoint) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			out.Partitions[i], errs[i] = c.searchCheckpointed(ctx, p, s, pc, opts)
+		}(i, p, pc)
+	}
+	wg.Wait()
+
+	var results []Partition
+	for i, pc := range out.Partitions {
+		if pc.Found {
+			p := parts[i]
+			p.Offset = pc.Match
+			results = append(results, p)
+		}
+	}
+	sortPartitions(results)
+
+	for i, err := range errs {
+		if err != nil {
+			if err == context.Canceled || err == context.DeadlineExceeded {
+				return out, results, err
+			}
+			return out, results, SearchFailure{Partition: parts[i], Err: err}
+		}
+	}
+
+	return out, results, nil
+}
+
+func (c *Client) searchCheckpointed(ctx context.Context, p Partition, s string, pc PartitionCheckpoint, opts []CallOpt) (PartitionCheckpoint, error) {
+	o := getCallOpts(opts)
+	o.scanned = func(offset int64) { pc.Next = offset + 1 }
+
+	p.Offset = pc.Next
+	offsets, err := c.searchAll(ctx, p, []byte(s), 1, o, func(int64) {})
+	if len(offsets) > 0 {
+		pc.Found = true
+		pc.Match = offsets[0]
+		return pc, nil
+	}
+
+	if err != nil {
+		return pc, err
+	}
+
+	if ctx.Err() == nil {
+		pc.Done = true
+		if pc.Next < p.End {
+			pc.Next = p.End
+		}
+	}
+	return pc, ctx.Err()
+}
+
+func checkpointKey(topic string, partition int32) string {
+	return fmt.Sprintf("%s/%d", topic, partition)
+}
@@ -0,0 +1,27 @@
+package kafka
+
+import "github.com/IBM/sarama"
+
+// saramaClient is the part of sarama.Client that the Client uses.
+type saramaClient interface {
+	Topics() ([]string, error)
+	Partitions(topic string) ([]int32, error)
+	GetOffset(topic string, partition int32, time int64) (int64, error)
+	Replicas(topic string, partition int32) ([]int32, error)
+	RefreshMetadata(topics ...string) error
+	Brokers() []*sarama.Broker
+	Controller() (*sarama.Broker, error)
+	Leader(topic string, partition int32) (*sarama.Broker, error)
+	Coordinator(group string) (*sarama.Broker, error)
+	Close() error
+}
+
+// withSaramaClient makes New use sc instead of connecting to kafka and
+// newConsumer (given the consumer's config) instead of
+// sarama.NewConsumer.  It lets the Client run against a mockCluster.
+func withSaramaClient(sc saramaClient, newConsumer func(*sarama.Config) (sarama.Consumer, error)) Opt {
+	return func(c *Client) {
+		c.sarama = sc
+		c.newConsumerF = newConsumer
+	}
+}
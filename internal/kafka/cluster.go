@@ -0,0 +1,148 @@
+package kafka
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/IBM/sarama"
+)
+
+// ClusterInfo identifies the cluster the Client is connected to.  ID is
+// empty if the brokers are too old (before 0.10.1) to report one.
+type ClusterInfo struct {
+	ID             string          `json:"id"`
+	Controller     int32           `json:"controller"`
+	ControllerAddr string          `json:"controller_addr"`
+	Brokers        int             `json:"brokers"`
+	Versions       []BrokerVersion `json:"versions"`
+}
+
+// BrokerVersion is the range of kafka releases a broker can be, worked
+// out from the highest fetch request version it supports.  MaxVersion is
+// empty when the broker is newer than the table kcli knows.  Error is set
+// if the broker couldn't be asked.
+type BrokerVersion struct {
+	ID         int32  `json:"id"`
+	Addr       string `json:"addr"`
+	MinVersion string `json:"min_version,omitempty"`
+	MaxVersion string `json:"max_version,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// fetchVersions maps the first release to support each max fetch
+// request version to the range of releases that share it.
+var fetchVersions = []struct {
+	fetch    int16
+	min, max string
+}{
+	{2, "0.10.0", "0.10.0"},
+	{3, "0.10.1", "0.10.2"},
+	{4, "0.11.0", "0.11.0"},
+	{6, "1.0.0", "1.0.x"},
+	{7, "1.1.0", "1.1.x"},
+	{8, "2.0.0", "2.0.x"},
+	{10, "2.1.0", "2.2.x"},
+	{11, "2.3.0", "2.6.x"},
+	{12, "2.7.0", "3.0.x"},
+	{13, "3.1.0", ""},
+}
+
+// ClusterInfo fetches the cluster ID and controller from the metadata and
+// asks every broker which API versions it supports.
+func (c *Client) ClusterInfo() (ClusterInfo, error) {
+	resp, err := c.clusterMetadata()
+	if err != nil {
+		return ClusterInfo{}, wrapErr("cluster info", "", -1, -1, err)
+	}
+
+	info := ClusterInfo{Controller: resp.ControllerID, Brokers: len(resp.Brokers)}
+	if resp.ClusterID != nil {
+		info.ID = *resp.ClusterID
+	}
+
+	for _, broker := range c.sarama.Brokers() {
+		if broker.ID() == info.Controller {
+			info.ControllerAddr = broker.Addr()
+		}
+		info.Versions = append(info.Versions, c.brokerVersion(broker))
+	}
+	sort.Slice(info.Versions, func(i, j int) bool { return info.Versions[i].ID < info.Versions[j].ID })
+	return info, nil
+}
+
+// clusterMetadata asks the controller for the cluster's metadata without
+// any topics.
+func (c *Client) clusterMetadata() (*sarama.MetadataResponse, error) {
+	b, err := c.sarama.Controller()
+	if err != nil {
+		return nil, err
+	}
+	return b.GetMetadata(&sarama.MetadataRequest{Version: 2, Topics: []string{}})
+}
+
+// ExpectClusterID makes New fail unless the brokers belong to the cluster
+// with id, which guards against a profile pointing at the wrong
+// cluster.  The ID is checked again before every destructive operation.
+// Brokers too old to report an ID fail the check.
+func ExpectClusterID(id string) Opt {
+	return func(c *Client) {
+		c.expectClusterID = id
+	}
+}
+
+// checkClusterID compares the cluster's ID with ExpectClusterID.  The
+// errors wrap ErrWrongCluster.
+func (c *Client) checkClusterID() error {
+	if c.expectClusterID == "" {
+		return nil
+	}
+
+	resp, err := c.clusterMetadata()
+	if err != nil {
+		return fmt.Errorf("%w: cannot verify cluster id %q: %s", ErrWrongCluster, c.expectClusterID, err)
+	}
+
+	if resp.ClusterID == nil || *resp.ClusterID == "" {
+		return fmt.Errorf("%w: cannot verify cluster id %q, the brokers don't report one (kafka 0.10.1 or later is needed)", ErrWrongCluster, c.expectClusterID)
+	}
+
+	if *resp.ClusterID != c.expectClusterID {
+		return fmt.Errorf("%w: connected to %q but expected %q (addresses %v)", ErrWrongCluster, *resp.ClusterID, c.expectClusterID, c.Addrs())
+	}
+	return nil
+}
+
+func (c *Client) brokerVersion(b *sarama.Broker) BrokerVersion {
+	v := BrokerVersion{ID: b.ID(), Addr: b.Addr()}
+	if err := b.Open(c.cfg); err != nil && err != sarama.ErrAlreadyConnected {
+		v.Error = err.Error()
+		return v
+	}
+
+	resp, err := b.ApiVersions(&sarama.ApiVersionsRequest{})
+	if err != nil {
+		// ApiVersions is new in 0.10.0
+		v.Error = err.Error()
+		v.MaxVersion = "0.9.x"
+		return v
+	}
+
+	if kerr := sarama.KError(resp.ErrorCode); kerr != sarama.ErrNoError {
+		v.Error = kerr.Error()
+		return v
+	}
+
+	fetch := int16(-1)
+	for _, api := range resp.ApiKeys {
+		if api.ApiKey == 1 {
+			fetch = api.MaxVersion
+		}
+	}
+
+	for _, fv := range fetchVersions {
+		if fetch >= fv.fetch {
+			v.MinVersion, v.MaxVersion = fv.min, fv.max
+		}
+	}
+	return v
+}
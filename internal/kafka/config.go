@@ -0,0 +1,148 @@
+package kafka
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+var invalidClientID = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// defaultClientID returns kcli-<hostname> so that brokers can tell kcli
+// connections apart.
+func defaultClientID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "kcli"
+	}
+	return "kcli-" + invalidClientID.ReplaceAllString(host, "_")
+}
+
+// ClientID sets the client.id sent on every connection (consumers,
+// producers and admin requests).  It defaults to kcli-<hostname>.
+func ClientID(id string) Opt {
+	return func(c *Client) {
+		c.cfg.ClientID = id
+	}
+}
+
+// RackID sets the rack the client is in so that clusters that support
+// fetching from followers (KIP-392) can serve reads from a replica in the
+// same rack.  The rack is only sent by kafka 2.4.0 and later, so New
+// returns an error unless KafkaVersion is at least that rather than
+// silently fetching across racks.
+func RackID(id string) Opt {
+	return func(c *Client) {
+		c.cfg.RackID = id
+	}
+}
+
+// KafkaVersion sets the protocol version the Client speaks (ie "2.1.0").
+// It defaults to 1.0.0, reading zstd compressed topics needs 2.1.0 or
+// later.  New returns an error if v isn't a version sarama knows.
+func KafkaVersion(v string) Opt {
+	return func(c *Client) {
+		version, err := sarama.ParseKafkaVersion(v)
+		if err != nil {
+			c.versionErr = err
+			return
+		}
+		c.versionErr = nil
+		c.cfg.Version = version
+	}
+}
+
+// checkConfig validates the settings that sarama doesn't know about.
+func (c *Client) checkConfig() error {
+	if c.versionErr != nil {
+		return c.versionErr
+	}
+	if c.proxyErr != nil {
+		return c.proxyErr
+	}
+	if c.cfg.RackID != "" && !c.cfg.Version.IsAtLeast(sarama.V2_4_0_0) {
+		return fmt.Errorf("rack id %q needs kafka 2.4.0 or later, raise it with KafkaVersion (it is %s)", c.cfg.RackID, c.cfg.Version)
+	}
+	return nil
+}
+
+// FetchDefaultBytes sets how many bytes are asked for in each fetch
+// request.  It grows as needed (up to FetchMaxBytes) when a message
+// doesn't fit.
+func FetchDefaultBytes(n int32) Opt {
+	return func(c *Client) {
+		c.cfg.Consumer.Fetch.Default = n
+	}
+}
+
+// FetchMaxBytes caps the size of a single fetch and so the size of the
+// largest message that can be read.  0 means no limit.
+func FetchMaxBytes(n int32) Opt {
+	return func(c *Client) {
+		c.cfg.Consumer.Fetch.Max = n
+	}
+}
+
+// MaxWaitTime sets how long the broker waits for FetchDefaultBytes to be
+// available before answering a fetch.
+func MaxWaitTime(d time.Duration) Opt {
+	return func(c *Client) {
+		c.cfg.Consumer.MaxWaitTime = d
+	}
+}
+
+// growFetch doubles max, the size of a partition fetch that was too
+// small for the next batch.  It returns ErrMessageTooLarge once max has
+// reached Fetch.Max, or sarama.MaxResponseSize when there is no Fetch.Max.
+func (c *Client) growFetch(max int32) (int32, error) {
+	limit := sarama.MaxResponseSize
+	if m := c.cfg.Consumer.Fetch.Max; m > 0 && m < limit {
+		limit = m
+	}
+
+	switch {
+	case max >= limit && c.cfg.Consumer.Fetch.Max > 0:
+		return 0, c.fetchError(sarama.ErrMessageTooLarge)
+	case max >= limit:
+		return 0, fmt.Errorf("%w (a batch is bigger than the largest response sarama accepts, %d bytes)", sarama.ErrMessageTooLarge, limit)
+	case max < 1:
+		return 1, nil
+	case max > limit/2:
+		return limit, nil
+	}
+	return max * 2, nil
+}
+
+// fetchError adds a hint about the fetch settings to errors caused by
+// messages that are too big to fetch or compressed with a codec the
+// protocol version can't carry, and marks checksum failures with
+// ErrCorruptBatch.
+func (c *Client) fetchError(err error) error {
+	switch {
+	case err == sarama.ErrMessageTooLarge:
+		return fmt.Errorf("%w (the limit is %d bytes, raise it with FetchMaxBytes or set it to 0 for no limit)", err, c.cfg.Consumer.Fetch.Max)
+	case isCRCError(err):
+		return fmt.Errorf("%w: %s", ErrCorruptBatch, err)
+	case err == sarama.ErrUnsupportedCompressionType, isCompressionError(err):
+		return fmt.Errorf("%w (the topic uses a compression codec that kafka version %s can't fetch, zstd needs 2.1.0 or later: raise it with KafkaVersion)", err, c.cfg.Version)
+	}
+	return err
+}
+
+// isCRCError is true for the decoding error sarama returns when a batch
+// or message doesn't match its checksum.
+func isCRCError(err error) bool {
+	perr, ok := err.(sarama.PacketDecodingError)
+	return ok && strings.Contains(perr.Info, "CRC")
+}
+
+// isCompressionError is true for the decoding error sarama returns for a
+// codec it doesn't know.
+func isCompressionError(err error) bool {
+	perr, ok := err.(sarama.PacketDecodingError)
+	return ok && strings.Contains(perr.Info, "compression")
+}
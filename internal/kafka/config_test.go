@@ -0,0 +1,171 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+func TestFetchSizes(t *testing.T) {
+	cli, err := newMockCluster().client(FetchDefaultBytes(1<<10), FetchMaxBytes(1<<20), MaxWaitTime(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	f := cli.cfg.Consumer.Fetch
+	if f.Default != 1<<10 || f.Max != 1<<20 || cli.cfg.Consumer.MaxWaitTime != time.Second {
+		t.Errorf("got fetch %+v and max wait %s", f, cli.cfg.Consumer.MaxWaitTime)
+	}
+}
+
+func TestMessageTooLarge(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, nil, []byte("a"))
+	m.injectError("t", 0, sarama.ErrMessageTooLarge)
+
+	cli, err := m.client(FetchMaxBytes(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	_, _, err = cli.FetchN(context.Background(), Partition{Topic: "t", Partition: 0, End: 2}, 10, func(Message) bool { return false })
+	if !errors.Is(err, sarama.ErrMessageTooLarge) {
+		t.Fatalf("got %v, want %v", err, sarama.ErrMessageTooLarge)
+	}
+	if !strings.Contains(err.Error(), "the limit is 100 bytes") || !strings.Contains(err.Error(), "FetchMaxBytes") {
+		t.Errorf("%q doesn't explain the limit", err)
+	}
+}
+
+// TestLargeMessage reads a 5MB message through a broker that cuts
+// responses off at the fetch size, so the consumer has to grow it past
+// FetchDefaultBytes.
+func TestLargeMessage(t *testing.T) {
+	big := strings.Repeat("x", 5<<20)
+	resp := &sarama.FetchResponse{Version: 6}
+	resp.AddRecord("t", 0, nil, sarama.StringEncoder(big), 0)
+	resp.GetBlock("t", 0).HighWaterMarkOffset = 1
+	proxy := newFetchBroker(t, resp)
+
+	cli, err := New([]string{proxy.Addr()}, FetchDefaultBytes(1<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	msgs, err := cli.GetPartition(Partition{Topic: "t", Partition: 0, End: 1}, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 || string(msgs[0].Value) != big {
+		t.Fatalf("got %d messages, want the 5MB one", len(msgs))
+	}
+
+	capped, err := New([]string{proxy.Addr()}, FetchDefaultBytes(1<<20), FetchMaxBytes(2<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer capped.Close()
+
+	_, _, err = capped.FetchN(context.Background(), Partition{Topic: "t", Partition: 0, End: 1}, 1, func(Message) bool { return false })
+	if !errors.Is(err, sarama.ErrMessageTooLarge) {
+		t.Errorf("got %v with a 2MB FetchMaxBytes, want %v", err, sarama.ErrMessageTooLarge)
+	}
+}
+
+func TestKafkaVersion(t *testing.T) {
+	cli, err := newMockCluster().client(KafkaVersion("2.1.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	if cli.cfg.Version != sarama.V2_1_0_0 {
+		t.Errorf("got version %s, want 2.1.0", cli.cfg.Version)
+	}
+
+	if _, err := newMockCluster().client(KafkaVersion("two")); err == nil {
+		t.Error("expected an error for an invalid version")
+	}
+
+	if _, err := newMockCluster().client(KafkaVersion("2.1.0"), RackID("r1")); err == nil {
+		t.Error("expected an error for a rack id with kafka 2.1.0")
+	}
+}
+
+// TestCompressionRoundTrip pages batches compressed with each codec
+// through GetPartition.
+func TestCompressionRoundTrip(t *testing.T) {
+	codecs := []sarama.CompressionCodec{
+		sarama.CompressionGZIP,
+		sarama.CompressionSnappy,
+		sarama.CompressionLZ4,
+		sarama.CompressionZSTD,
+	}
+
+	want := []string{"a", strings.Repeat("b", 1024), "c"}
+	for _, codec := range codecs {
+		t.Run(codec.String(), func(t *testing.T) {
+			// zstd needs fetch version 10, kafka 2.1.0
+			resp := &sarama.FetchResponse{Version: 10}
+			for i, v := range want {
+				resp.AddRecord("t", 0, nil, sarama.StringEncoder(v), int64(i))
+			}
+			resp.SetLastOffsetDelta("t", 0, int32(len(want)-1))
+			batch := resp.GetBlock("t", 0).RecordsSet[0].RecordBatch
+			batch.Codec = codec
+			batch.CompressionLevel = sarama.CompressionLevelDefault
+			resp.GetBlock("t", 0).HighWaterMarkOffset = int64(len(want))
+			proxy := newFetchBroker(t, resp)
+
+			cli, err := New([]string{proxy.Addr()}, KafkaVersion("2.1.0"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer cli.Close()
+
+			msgs, err := cli.GetPartition(Partition{Topic: "t", Partition: 0, End: int64(len(want))}, 10, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := values(msgs); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %d messages, want %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+func TestFetchErrorHints(t *testing.T) {
+	cli, err := newMockCluster().client(KafkaVersion("1.0.0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	tests := []struct {
+		err  error
+		is   error
+		hint string
+	}{
+		{err: sarama.ErrUnsupportedCompressionType, is: sarama.ErrUnsupportedCompressionType, hint: "KafkaVersion"},
+		{err: sarama.PacketDecodingError{Info: "invalid compression specified (4)"}, hint: "kafka version 1.0.0"},
+		{err: sarama.PacketDecodingError{Info: "CRC didn't match"}, is: ErrCorruptBatch, hint: "CRC"},
+		{err: errBoom, is: errBoom, hint: "boom"},
+	}
+
+	for _, tt := range tests {
+		got := cli.fetchError(tt.err)
+		if tt.is != nil && !errors.Is(got, tt.is) {
+			t.Errorf("fetchError(%v) = %v, want it to wrap %v", tt.err, got, tt.is)
+		}
+		if !strings.Contains(got.Error(), tt.hint) {
+			t.Errorf("fetchError(%v) = %q, want it to mention %q", tt.err, got, tt.hint)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/IBM/sarama"
+)
+
+const defaultCopyBatch = 100
+
+// CopyOpts configures a Copy
+type CopyOpts struct {
+	// Transform, when set, is called with each raw (not decoded) source
+	// message and returns the key and value to produce.  Returning skip
+	// drops the message.
+	Transform func(Message) (key, value []byte, skip bool)
+
+	// BatchSize bounds the number of messages that are in flight to
+	// the destination at once.  Defaults to 100.
+	BatchSize int
+
+	// PreservePartition produces each message to the same partition
+	// number it was read from instead of hashing the key.
+	PreservePartition bool
+}
+
+// CopyStats reports what a Copy did.  Partitions is keyed by topic and
+// partition because the source partitions can come from several topics.
+type CopyStats struct {
+	Copied     int64                                 `json:"copied"`
+	Skipped    int64                                 `json:"skipped"`
+	Partitions map[TopicPartition]PartitionCopyStats `json:"-"`
+}
+
+// MarshalJSON writes Partitions as a list, in topic and partition order,
+// because json can't have a TopicPartition as a key.
+func (s CopyStats) MarshalJSON() ([]byte, error) {
+	type partition struct {
+		TopicPartition
+		PartitionCopyStats
+	}
+
+	parts := make([]partition, 0, len(s.Partitions))
+	for tp, st := range s.Partitions {
+		parts = append(parts, partition{TopicPartition: tp, PartitionCopyStats: st})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		if parts[i].Topic != parts[j].Topic {
+			return parts[i].Topic < parts[j].Topic
+		}
+		return parts[i].Partition < parts[j].Partition
+	})
+
+	type stats CopyStats
+	return json.Marshal(struct {
+		stats
+		Partitions []partition `json:"partitions"`
+	}{stats: stats(s), Partitions: parts})
+}
+
+// PartitionCopyStats reports what a Copy did for a single source partition.
+// LastOffset is the last source offset that was copied (or skipped) and
+// is -1 if nothing was read.
+type PartitionCopyStats struct {
+	Copied     int64 `json:"copied"`
+	Skipped    int64 `json:"skipped"`
+	LastOffset int64 `json:"last_offset"`
+}
+
+// Resume returns copies of parts with their offsets set to just past the
+// last copied offset so an interrupted Copy can be restarted.
+func (s CopyStats) Resume(parts []Partition) []Partition {
+	out := make([]Partition, len(parts))
+	for i, p := range parts {
+		if ps, ok := s.Partitions[TopicPartition{Topic: p.Topic, Partition: p.Partition}]; ok && ps.LastOffset >= p.Offset {
+			p.Offset = ps.LastOffset + 1
+		}
+		out[i] = p
+	}
+	return out
+}
+
+// Copy reads the messages in from (starting at each partition's Offset up
+// to its End) using src and produces them to toTopic using dst, preserving
+// keys and headers.  src and dst may point at different clusters.
+func Copy(ctx context.Context, src, dst *Client, from []Partition, toTopic string, opts CopyOpts) (CopyStats, error) {
+	stats := CopyStats{Partitions: map[TopicPartition]PartitionCopyStats{}}
+
+	batch := opts.BatchSize
+	if batch <= 0 {
+		batch = defaultCopyBatch
+	}
+
+	prod, err := dst.newProducer(func(cfg *sarama.Config) {
+		if opts.PreservePartition {
+			cfg.Producer.Partitioner = sarama.NewManualPartitioner
+		}
+	})
+	if err != nil {
+		return stats, err
+	}
+	defer prod.Close()
+
+	for _, part := range from {
+		ps, err := copyPartition(ctx, src, prod, part, toTopic, batch, opts)
+		stats.Partitions[TopicPartition{Topic: part.Topic, Partition: part.Partition}] = ps
+		stats.Copied += ps.Copied
+		stats.Skipped += ps.Skipped
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	return stats, nil
+}
+
+func copyPartition(ctx context.Context, src *Client, prod sarama.SyncProducer, part Partition, toTopic string, batch int, opts CopyOpts) (PartitionCopyStats, error) {
+	ps := PartitionCopyStats{LastOffset: -1}
+	msgs := make([]*sarama.ProducerMessage, 0, batch)
+	last := int64(-1)
+
+	flush := func() error {
+		if len(msgs) > 0 {
+			if err := prod.SendMessages(msgs); err != nil {
+				return err
+			}
+		}
+		ps.Copied += int64(len(msgs))
+		ps.LastOffset = last
+		msgs = msgs[:0]
+		return nil
+	}
+
+	var perr error
+	err := src.consume(ctx, part, part.End, func(msg *sarama.ConsumerMessage) bool {
+		m := newMessage(part, msg)
+		key, val := m.Key, m.Value
+		if opts.Transform != nil {
+			var skip bool
+			key, val, skip = opts.Transform(m)
+			if skip {
+				ps.Skipped++
+				last = msg.Offset
+				return false
+			}
+		}
+
+		msgs = append(msgs, producerMessage(toTopic, msg.Partition, key, val, m.Headers))
+		last = msg.Offset
+		if len(msgs) < batch {
+			return false
+		}
+
+		perr = flush()
+		return perr != nil
+	})
+
+	if perr != nil {
+		return ps, perr
+	}
+
+	if ferr := flush(); ferr != nil {
+		return ps, ferr
+	}
+
+	return ps, err
+}
+
+func producerMessage(topic string, partition int32, key, val []byte, headers []Header) *sarama.ProducerMessage {
+	msg := &sarama.ProducerMessage{
+		Topic:     topic,
+		Partition: partition,
+		Value:     sarama.ByteEncoder(val),
+	}
+
+	if key != nil {
+		msg.Key = sarama.ByteEncoder(key)
+	}
+
+	for _, h := range headers {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(h.Key), Value: h.Value})
+	}
+
+	return msg
+}
@@ -0,0 +1,36 @@
+package kafka
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// TestCopyStatsTopics resumes a copy of the same partition number of two
+// topics, each from its own last offset.
+func TestCopyStatsTopics(t *testing.T) {
+	stats := CopyStats{
+		Copied: 7,
+		Partitions: map[TopicPartition]PartitionCopyStats{
+			{Topic: "b", Partition: 0}: {Copied: 2, LastOffset: 11},
+			{Topic: "a", Partition: 0}: {Copied: 5, LastOffset: 4},
+		},
+	}
+
+	parts := []Partition{{Topic: "a", Partition: 0, End: 20}, {Topic: "b", Partition: 0, Offset: 10, End: 20}, {Topic: "c", Partition: 0, End: 20}}
+	got := stats.Resume(parts)
+	if want := []int64{5, 12, 0}; !reflect.DeepEqual([]int64{got[0].Offset, got[1].Offset, got[2].Offset}, want) {
+		t.Errorf("resumed at %d, %d and %d, want %v", got[0].Offset, got[1].Offset, got[2].Offset, want)
+	}
+
+	buf, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"copied":7,"skipped":0,"partitions":[` +
+		`{"topic":"a","partition":0,"copied":5,"skipped":0,"last_offset":4},` +
+		`{"topic":"b","partition":0,"copied":2,"skipped":0,"last_offset":11}]}`
+	if string(buf) != want {
+		t.Errorf("got %s, want %s", buf, want)
+	}
+}
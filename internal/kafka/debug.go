@@ -0,0 +1,32 @@
+package kafka
+
+import (
+	"io"
+	"log"
+
+	"github.com/IBM/sarama"
+)
+
+// Debug logs sarama's internals and kcli's own activity (consumers being
+// created, partitions being read, search workers and retries) to w.
+// Logging is off by default.  sarama's logger is global so it is shared
+// by every Client in the process.
+func Debug(w io.Writer) Opt {
+	return WithLogger(log.New(w, "[kcli] ", log.LstdFlags|log.Lmicroseconds))
+}
+
+// WithLogger is Debug with a caller supplied logger.
+func WithLogger(l *log.Logger) Opt {
+	return func(c *Client) {
+		c.logger = l
+		sarama.Logger = log.New(l.Writer(), "[sarama] ", l.Flags())
+	}
+}
+
+// logf writes to the Client's logger, if there is one.  log.Logger is safe
+// for concurrent use.
+func (c *Client) logf(format string, args ...interface{}) {
+	if c.logger != nil {
+		c.logger.Printf(format, args...)
+	}
+}
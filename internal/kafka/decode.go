@@ -0,0 +1,91 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM/sarama"
+)
+
+// DecodePolicy controls what happens when the Decoder fails on a message.
+type DecodePolicy int
+
+const (
+	// DecodeFail stops the operation and returns the decoder's error (the default)
+	DecodeFail DecodePolicy = iota
+	// DecodeSkip drops the message that failed to decode
+	DecodeSkip
+	// DecodeRaw uses the raw, undecoded, bytes of the message
+	DecodeRaw
+)
+
+// DecodeErrors sets the policy used by Fetch, GetPartition and the
+// searches when the Decoder fails.
+func DecodeErrors(p DecodePolicy) func(*Client) {
+	return func(c *Client) {
+		c.decodePolicy = p
+	}
+}
+
+// RawBytes skips the Decoder for a single call, so a search matches the
+// bytes as they are stored in kafka.
+func RawBytes() CallOpt {
+	return func(o *callOpts) {
+		o.raw = true
+	}
+}
+
+// WithCallDecoder decodes the messages of a single call with d instead
+// of the Client's Decoder.  The Client's DecodePolicy still applies.
+// RawBytes wins over it.
+func WithCallDecoder(d Decoder) CallOpt {
+	return func(o *callOpts) {
+		o.decoder = d
+	}
+}
+
+// decode runs val through the call's decoder according to the Client's
+// DecodePolicy.  ok is false when the message should be skipped.
+func (c *Client) decode(ctx context.Context, o callOpts, topic string, offset int64, val []byte) (out []byte, ok bool, err error) {
+	if o.raw {
+		return val, true, nil
+	}
+
+	dec := c.decoder
+	if d := c.prefs.decoder(topic); d != nil {
+		dec = d
+	}
+	if o.decoder != nil {
+		dec = o.decoder
+	}
+
+	if cd, isCtx := dec.(ContextDecoder); isCtx {
+		out, err = cd.DecodeContext(ctx, topic, val)
+	} else {
+		out, err = dec.Decode(topic, val)
+	}
+	if err == nil {
+		return out, true, nil
+	}
+
+	switch c.decodePolicy {
+	case DecodeSkip:
+		return nil, false, nil
+	case DecodeRaw:
+		return val, true, nil
+	default:
+		return nil, false, fmt.Errorf("unable to decode message at offset %d: %s", offset, err)
+	}
+}
+
+// decodeMessage returns a copy of msg with a decoded Value.
+func (c *Client) decodeMessage(ctx context.Context, o callOpts, msg *sarama.ConsumerMessage) (*sarama.ConsumerMessage, bool, error) {
+	val, ok, err := c.decode(ctx, o, msg.Topic, msg.Offset, msg.Value)
+	if !ok || err != nil {
+		return nil, ok, wrapErr("decode", msg.Topic, msg.Partition, msg.Offset, err)
+	}
+
+	out := *msg
+	out.Value = val
+	return &out, true, nil
+}
@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"fmt"
+	"sort"
+)
+
+const defaultDedupKeys = 1000000
+
+// DedupByKey makes FetchTopic deliver only the newest message for each
+// key in the fetched range.  Within a partition the newest is the one
+// with the highest offset, across partitions it is the one with the
+// latest timestamp.  Messages without a key are never deduplicated and
+// are delivered as they are read, the rest are delivered, in partition
+// and offset order, once every partition has been read.  At most
+// 1,000,000 keys (or the number set with MaxKeys) are held before the
+// fetch fails with ErrTooManyKeys.
+func DedupByKey() CallOpt {
+	return func(o *callOpts) {
+		o.dedup = true
+	}
+}
+
+// deduper keeps the newest message of each key
+type deduper struct {
+	max        int
+	msgs       map[string]Message
+	suppressed int64
+}
+
+func newDeduper(max int) *deduper {
+	if max <= 0 {
+		max = defaultDedupKeys
+	}
+	return &deduper{max: max, msgs: map[string]Message{}}
+}
+
+func (d *deduper) add(m Message) error {
+	k := string(m.Key)
+	prev, ok := d.msgs[k]
+	if !ok {
+		if len(d.msgs) >= d.max {
+			return fmt.Errorf("%w: more than %d keys to deduplicate", ErrTooManyKeys, d.max)
+		}
+		d.msgs[k] = m
+		return nil
+	}
+
+	d.suppressed++
+	if newer(prev, m) {
+		d.msgs[k] = m
+	}
+	return nil
+}
+
+// newer reports whether b supersedes a
+func newer(a, b Message) bool {
+	if a.Partition.Topic == b.Partition.Topic && a.Partition.Partition == b.Partition.Partition {
+		return b.Offset > a.Offset
+	}
+	return b.Timestamp.After(a.Timestamp)
+}
+
+// messages returns the surviving messages ordered by topic, partition
+// and offset.
+func (d *deduper) messages() []Message {
+	out := make([]Message, 0, len(d.msgs))
+	for _, m := range d.msgs {
+		out = append(out, m)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Partition.Topic != b.Partition.Topic {
+			return a.Partition.Topic < b.Partition.Topic
+		}
+		if a.Partition.Partition != b.Partition.Partition {
+			return a.Partition.Partition < b.Partition.Partition
+		}
+		return a.Offset < b.Offset
+	})
+	return out
+}
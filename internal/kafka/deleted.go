@@ -0,0 +1,66 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/IBM/sarama"
+)
+
+// topicMisses is how many idle checks in a row have to find a consumer's
+// topic missing from the metadata before the read fails with
+// ErrTopicDeleted.  sarama keeps retrying a deleted partition's leader
+// rather than reporting an error so without them the read would hang.
+const topicMisses = 2
+
+// topicDeleted refreshes the metadata and reports whether topic is gone.
+// Errors getting the metadata don't count as the topic being gone.
+func (c *Client) topicDeleted(topic string) bool {
+	if err := c.sarama.RefreshMetadata(); err != nil {
+		return false
+	}
+
+	topics, err := c.sarama.Topics()
+	if err != nil {
+		return false
+	}
+
+	for _, t := range topics {
+		if t == topic {
+			return false
+		}
+	}
+	return true
+}
+
+// deletedErr turns an unknown topic or partition error into
+// ErrTopicDeleted when the topic is no longer in the metadata.
+func (c *Client) deletedErr(topic string, err error) error {
+	if !errors.Is(err, sarama.ErrUnknownTopicOrPartition) || !c.topicDeleted(topic) {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrTopicDeleted, topic)
+}
+
+// deletionCheck watches for a consumer's topic disappearing while the
+// consumer is idle.
+type deletionCheck struct {
+	c      *Client
+	topic  string
+	misses int
+}
+
+// idle is called when the consumer has gone quiet without catching up
+// and returns ErrTopicDeleted once the topic has been missing for
+// topicMisses checks in a row.
+func (d *deletionCheck) idle() error {
+	if !d.c.topicDeleted(d.topic) {
+		d.misses = 0
+		return nil
+	}
+
+	if d.misses++; d.misses < topicMisses {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrTopicDeleted, d.topic)
+}
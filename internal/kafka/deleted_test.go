@@ -0,0 +1,64 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func TestMessagesTopicDeleted(t *testing.T) {
+	m, cli := newTestClient(t, "t", []string{"a"})
+
+	it, err := cli.Messages(context.Background(), Partition{Topic: "t", Partition: 0, End: 3}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if msg, ok := it.Next(); !ok || string(msg.Value) != "a" {
+		t.Fatalf("got %q %v, want a", msg.Value, ok)
+	}
+	m.deleteTopic("t")
+
+	if msg, ok := it.Next(); ok {
+		t.Fatalf("got %q, want the read to stop", msg.Value)
+	}
+	if !errors.Is(it.Err(), ErrTopicDeleted) {
+		t.Errorf("got %v, want %v", it.Err(), ErrTopicDeleted)
+	}
+}
+
+func TestFetchTopicDeletedMidRead(t *testing.T) {
+	m, cli := newTestClient(t, "t", []string{"a"})
+
+	parts := []Partition{{Topic: "t", Partition: 0, End: 3}}
+	var n int
+	_, err := cli.FetchTopic(context.Background(), parts, 10, func(Message) bool {
+		n++
+		m.deleteTopic("t")
+		return false
+	})
+	if !errors.Is(err, ErrTopicDeleted) {
+		t.Errorf("got %v, want %v", err, ErrTopicDeleted)
+	}
+	if n != 1 {
+		t.Errorf("got %d messages, want 1", n)
+	}
+}
+
+func TestDeletedErr(t *testing.T) {
+	m, cli := newTestClient(t, "t", []string{"a"})
+
+	if err := cli.deletedErr("t", sarama.ErrUnknownTopicOrPartition); errors.Is(err, ErrTopicDeleted) {
+		t.Errorf("got %v for a topic that exists", err)
+	}
+
+	m.deleteTopic("t")
+	if err := cli.deletedErr("t", errBoom); err != errBoom {
+		t.Errorf("got %v, want %v", err, errBoom)
+	}
+	if err := cli.deletedErr("t", sarama.ErrUnknownTopicOrPartition); !errors.Is(err, ErrTopicDeleted) {
+		t.Errorf("got %v, want %v", err, ErrTopicDeleted)
+	}
+}
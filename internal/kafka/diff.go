@@ -0,0 +1,233 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// MessageRef identifies a single message
+type MessageRef struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Offset    int64  `json:"offset"`
+}
+
+// Change is a single difference between two JSON documents.  Kind is
+// one of added, removed or changed.  Old and New hold the values at Path
+// as JSON (Old is empty for added paths and New is empty for removed
+// ones).
+type Change struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// ByteDiff summarizes how two values that aren't both JSON differ.  The
+// values share Prefix bytes at the start and Suffix bytes at the end;
+// everything in between differs.
+type ByteDiff struct {
+	LenA   int `json:"len_a"`
+	LenB   int `json:"len_b"`
+	Prefix int `json:"prefix"`
+	Suffix int `json:"suffix"`
+}
+
+// Diff is the difference between two decoded messages.  When both values
+// are JSON, JSON is set and Changes lists the paths that differ (in path
+// order), otherwise Bytes summarizes the difference.
+type Diff struct {
+	A       Message   `json:"a"`
+	B       Message   `json:"b"`
+	JSON    bool      `json:"json"`
+	Changes []Change  `json:"changes,omitempty"`
+	Bytes   *ByteDiff `json:"bytes,omitempty"`
+}
+
+// Equal reports whether the two messages' values are the same
+func (d Diff) Equal() bool {
+	if d.JSON {
+		return len(d.Changes) == 0
+	}
+	return d.Bytes == nil
+}
+
+// String renders the diff as text, one line per change.
+func (d Diff) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s/%d@%d\n", d.A.Partition.Topic, d.A.Partition.Partition, d.A.Offset)
+	fmt.Fprintf(&buf, "+++ %s/%d@%d\n", d.B.Partition.Topic, d.B.Partition.Partition, d.B.Offset)
+
+	if d.Equal() {
+		buf.WriteString("no differences\n")
+		return buf.String()
+	}
+
+	if !d.JSON {
+		b := d.Bytes
+		fmt.Fprintf(&buf, "values differ: %d bytes vs %d bytes, first %d and last %d bytes are the same\n", b.LenA, b.LenB, b.Prefix, b.Suffix)
+		return buf.String()
+	}
+
+	for _, c := range d.Changes {
+		switch c.Kind {
+		case "added":
+			fmt.Fprintf(&buf, "+ %s: %s\n", c.Path, c.New)
+		case "removed":
+			fmt.Fprintf(&buf, "- %s: %s\n", c.Path, c.Old)
+		default:
+			fmt.Fprintf(&buf, "~ %s: %s -> %s\n", c.Path, c.Old, c.New)
+		}
+	}
+	return buf.String()
+}
+
+// DiffMessages fetches and decodes the messages at a and b and compares
+// their values.
+func (c *Client) DiffMessages(a, b MessageRef) (Diff, error) {
+	ma, err := c.fetchRef(context.Background(), a, callOpts{})
+	if err != nil {
+		return Diff{}, err
+	}
+
+	mb, err := c.fetchRef(context.Background(), b, callOpts{})
+	if err != nil {
+		return Diff{}, err
+	}
+
+	d := Diff{A: ma, B: mb}
+	va, aerr := parseJSON(string(ma.Value))
+	vb, berr := parseJSON(string(mb.Value))
+	if aerr == nil && berr == nil {
+		d.JSON = true
+		d.Changes = diffJSON("", va, vb, nil)
+		return d, nil
+	}
+
+	if !bytes.Equal(ma.Value, mb.Value) {
+		d.Bytes = diffBytes(ma.Value, mb.Value)
+	}
+	return d, nil
+}
+
+// fetchRef returns the message at ref, decoded according to o
+func (c *Client) fetchRef(ctx context.Context, ref MessageRef, o callOpts) (Message, error) {
+	start, end, err := c.watermarks(ref.Topic, ref.Partition)
+	if err != nil {
+		return Message{}, err
+	}
+
+	if ref.Offset < start || ref.Offset >= end {
+		return Message{}, wrapErr("fetch", ref.Topic, ref.Partition, ref.Offset, fmt.Errorf("%w (valid range is %d to %d)", ErrOffsetOutOfRange, start, end-1))
+	}
+
+	info := Partition{Topic: ref.Topic, Partition: ref.Partition, Start: start, End: ref.Offset + 1, Offset: ref.Offset}
+	var msg Message
+	var found bool
+	_, err = c.fetch(ctx, info, 1, o, func(m Message) bool {
+		msg, found = m, m.Offset == ref.Offset
+		return true
+	})
+	if err != nil {
+		return Message{}, err
+	}
+
+	if !found {
+		return Message{}, wrapErr("fetch", ref.Topic, ref.Partition, ref.Offset, fmt.Errorf("no message at offset %d", ref.Offset))
+	}
+	return msg, nil
+}
+
+// diffJSON appends the differences between a and b under path to out
+func diffJSON(path string, a, b interface{}, out []Change) []Change {
+	switch at := a.(type) {
+	case map[string]interface{}:
+		bt, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(at)+len(bt))
+		for k := range at {
+			keys = append(keys, k)
+		}
+		for k := range bt {
+			if _, ok := at[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+
+			av, aok := at[k]
+			bv, bok := bt[k]
+			switch {
+			case !aok:
+				out = append(out, Change{Path: p, Kind: "added", New: jsonValue(bv)})
+			case !bok:
+				out = append(out, Change{Path: p, Kind: "removed", Old: jsonValue(av)})
+			default:
+				out = diffJSON(p, av, bv, out)
+			}
+		}
+		return out
+	case []interface{}:
+		bt, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+
+		for i := 0; i < len(at) || i < len(bt); i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(at):
+				out = append(out, Change{Path: p, Kind: "added", New: jsonValue(bt[i])})
+			case i >= len(bt):
+				out = append(out, Change{Path: p, Kind: "removed", Old: jsonValue(at[i])})
+			default:
+				out = diffJSON(p, at[i], bt[i], out)
+			}
+		}
+		return out
+	}
+
+	av, bv := jsonValue(a), jsonValue(b)
+	if av != bv {
+		if path == "" {
+			path = "."
+		}
+		out = append(out, Change{Path: path, Kind: "changed", Old: av, New: bv})
+	}
+	return out
+}
+
+// jsonValue renders v as compact JSON
+func jsonValue(v interface{}) string {
+	d, _ := json.Marshal(v)
+	return string(d)
+}
+
+func diffBytes(a, b []byte) *ByteDiff {
+	d := &ByteDiff{LenA: len(a), LenB: len(b)}
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+
+	for d.Prefix < n && a[d.Prefix] == b[d.Prefix] {
+		d.Prefix++
+	}
+
+	for d.Suffix < n-d.Prefix && a[len(a)-1-d.Suffix] == b[len(b)-1-d.Suffix] {
+		d.Suffix++
+	}
+	return d
+}
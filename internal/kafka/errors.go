@@ -0,0 +1,212 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/IBM/sarama"
+)
+
+var (
+	// ErrOffsetOutOfRange means the requested offset has been removed by
+	// retention or is past the end of the partition.
+	ErrOffsetOutOfRange = errors.New("kafka: offset out of range")
+
+	// ErrTopicNotFound means the topic (or partition) does not exist.
+	ErrTopicNotFound = errors.New("kafka: topic or partition not found")
+
+	// ErrAuth means the broker rejected the credentials or the principal
+	// isn't allowed to do what was asked.
+	ErrAuth = errors.New("kafka: authentication or authorization failed")
+
+	// ErrUnauthorized is the narrower half of ErrAuth: the credentials
+	// were accepted but an ACL denies the principal access to the topic,
+	// group or cluster.  The KafkaError it comes wrapped in names the
+	// principal when SASL is configured.
+	ErrUnauthorized = errors.New("kafka: not authorized")
+
+	// ErrTopicDeleted means the topic was deleted while it was being
+	// read.
+	ErrTopicDeleted = errors.New("kafka: topic was deleted")
+
+	// ErrCorruptBatch means a fetched record batch failed its CRC check.
+	// The KafkaError it comes wrapped in has the partition and the offset
+	// the batch was fetched for.  See SkipCorruptBatches.
+	ErrCorruptBatch = errors.New("kafka: corrupt record batch")
+
+	// ErrWrongCluster means the brokers aren't (or can't be shown to be)
+	// the cluster given to ExpectClusterID.
+	ErrWrongCluster = errors.New("kafka: wrong cluster")
+
+	// ErrTimeout means WaitFor didn't see a matching message in time.
+	ErrTimeout = errors.New("kafka: timed out waiting for message")
+)
+
+// KafkaError is returned by the Client's methods when talking to kafka
+// fails.  Partition and Offset are -1 when they don't apply.  Use
+// errors.Is with ErrOffsetOutOfRange, ErrTopicNotFound, ErrUnauthorized
+// or ErrAuth to branch on the cause, or errors.As to get at the
+// underlying sarama.KError.  Principal is the SASL user of an
+// ErrUnauthorized error (empty without SASL).
+type KafkaError struct {
+	Op        string
+	Topic     string
+	Partition int32
+	Offset    int64
+	Principal string
+	Err       error
+}
+
+func (e *KafkaError) Error() string {
+	var s string
+	switch {
+	case e.Topic == "":
+		s = fmt.Sprintf("%s: %s", e.Op, e.Err)
+	case e.Partition < 0:
+		s = fmt.Sprintf("%s %s: %s", e.Op, e.Topic, e.Err)
+	case e.Offset < 0:
+		s = fmt.Sprintf("%s %s/%d: %s", e.Op, e.Topic, e.Partition, e.Err)
+	default:
+		s = fmt.Sprintf("%s %s/%d at offset %d: %s", e.Op, e.Topic, e.Partition, e.Offset, e.Err)
+	}
+
+	if e.Principal != "" {
+		s += fmt.Sprintf(" (principal %q)", e.Principal)
+	}
+	return s
+}
+
+// Unwrap returns the underlying error
+func (e *KafkaError) Unwrap() error { return e.Err }
+
+// Is maps the sarama errors onto the package's sentinel errors.
+func (e *KafkaError) Is(target error) bool {
+	var kerr sarama.KError
+	if !errors.As(e.Err, &kerr) {
+		return false
+	}
+
+	switch target {
+	case ErrOffsetOutOfRange:
+		return kerr == sarama.ErrOffsetOutOfRange
+	case ErrTopicNotFound:
+		return kerr == sarama.ErrUnknownTopicOrPartition
+	case ErrUnauthorized:
+		switch kerr {
+		case sarama.ErrTopicAuthorizationFailed,
+			sarama.ErrGroupAuthorizationFailed,
+			sarama.ErrClusterAuthorizationFailed,
+			sarama.ErrTransactionalIDAuthorizationFailed,
+			sarama.ErrDelegationTokenAuthorizationFailed:
+			return true
+		}
+	case ErrAuth:
+		switch kerr {
+		case sarama.ErrSASLAuthenticationFailed,
+			sarama.ErrIllegalSASLState,
+			sarama.ErrUnsupportedSASLMechanism,
+			sarama.ErrTopicAuthorizationFailed,
+			sarama.ErrGroupAuthorizationFailed,
+			sarama.ErrClusterAuthorizationFailed,
+			sarama.ErrTransactionalIDAuthorizationFailed,
+			sarama.ErrDelegationTokenAuthorizationFailed:
+			return true
+		}
+	}
+	return false
+}
+
+// wrapErr adds context to err.  nil, context errors and errors that
+// already carry context are returned as is.
+func wrapErr(op, topic string, partition int32, offset int64, err error) error {
+	if err == nil || err == context.Canceled || err == context.DeadlineExceeded {
+		return err
+	}
+
+	var kerr *KafkaError
+	if errors.As(err, &kerr) {
+		return err
+	}
+
+	return &KafkaError{Op: op, Topic: topic, Partition: partition, Offset: offset, Err: err}
+}
+
+// authErr names the SASL principal in err if it is an ErrUnauthorized
+// KafkaError.
+func (c *Client) authErr(err error) error {
+	if !c.cfg.Net.SASL.Enable || !errors.Is(err, ErrUnauthorized) {
+		return err
+	}
+
+	var kerr *KafkaError
+	if errors.As(err, &kerr) && kerr.Principal == "" {
+		kerr.Principal = c.cfg.Net.SASL.User
+	}
+	return err
+}
+
+// PartialError is returned by SearchTopic, FetchTopic and MergeTopics
+// when some partitions failed and the rest were read.  The results that
+// were gathered are returned alongside it, so callers that only want
+// complete results can check for it with errors.As.
+type PartialError struct {
+	failed map[TopicPartition]error
+}
+
+// add records err for p, the first error for a partition wins.
+func (p *PartialError) add(part Partition, err error) {
+	if p.failed == nil {
+		p.failed = map[TopicPartition]error{}
+	}
+	tp := TopicPartition{Topic: part.Topic, Partition: part.Partition}
+	if _, ok := p.failed[tp]; !ok {
+		p.failed[tp] = err
+	}
+}
+
+// orNil returns p if any partition failed
+func (p *PartialError) orNil() error {
+	if len(p.failed) == 0 {
+		return nil
+	}
+	return p
+}
+
+// Failed returns the error of each partition that failed.
+func (p *PartialError) Failed() map[TopicPartition]error {
+	return p.failed
+}
+
+func (p *PartialError) Error() string {
+	tps := p.partitions()
+	if len(tps) == 1 {
+		return fmt.Sprintf("%s/%d failed: %s", tps[0].Topic, tps[0].Partition, p.failed[tps[0]])
+	}
+	return fmt.Sprintf("%d partitions failed, the first (%s/%d): %s", len(tps), tps[0].Topic, tps[0].Partition, p.failed[tps[0]])
+}
+
+// Unwrap returns the error of the first partition (by topic, then
+// partition) that failed so errors.Is can find the cause.
+func (p *PartialError) Unwrap() error {
+	tps := p.partitions()
+	if len(tps) == 0 {
+		return nil
+	}
+	return p.failed[tps[0]]
+}
+
+func (p *PartialError) partitions() []TopicPartition {
+	out := make([]TopicPartition, 0, len(p.failed))
+	for tp := range p.failed {
+		out = append(out, tp)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Topic != out[j].Topic {
+			return out[i].Topic < out[j].Topic
+		}
+		return out[i].Partition < out[j].Partition
+	})
+	return out
+}
@@ -0,0 +1,156 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func TestPartialError(t *testing.T) {
+	var p PartialError
+	if p.orNil() != nil {
+		t.Fatal("a PartialError without failures isn't nil")
+	}
+
+	errA, errB := errors.New("a"), errors.New("b")
+	p.add(Partition{Topic: "b", Partition: 0}, errB)
+	p.add(Partition{Topic: "a", Partition: 3}, errA)
+	p.add(Partition{Topic: "a", Partition: 3}, errB)
+
+	err := p.orNil()
+	if err == nil {
+		t.Fatal("got nil, want the PartialError")
+	}
+
+	// the first error of a partition wins and Unwrap is the first
+	// partition by topic then partition
+	if got := p.Failed()[TopicPartition{Topic: "a", Partition: 3}]; got != errA {
+		t.Errorf("got %v for a/3, want %v", got, errA)
+	}
+	if !errors.Is(err, errA) || errors.Unwrap(err) != errA {
+		t.Errorf("got %v, want it to unwrap to %v", errors.Unwrap(err), errA)
+	}
+	if got, want := err.Error(), "2 partitions failed, the first (a/3): a"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	var one PartialError
+	one.add(Partition{Topic: "t", Partition: 1}, errB)
+	if got, want := one.Error(), "t/1 failed: b"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestFetchStatsJSON(t *testing.T) {
+	stats := FetchStats{
+		Delivered: 3,
+		Partitions: map[TopicPartition]PartitionFetchStats{
+			{Topic: "b", Partition: 0}: {Delivered: 1, Next: 1},
+			{Topic: "a", Partition: 1}: {Delivered: 2, Next: 5},
+		},
+	}
+
+	b, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"delivered":3,"skipped":0,"duplicates":0,"corrupt_batches":0,"partitions":[` +
+		`{"topic":"a","partition":1,"delivered":2,"skipped":0,"next":5},` +
+		`{"topic":"b","partition":0,"delivered":1,"skipped":0,"next":1}]}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
+
+func TestKafkaErrorIs(t *testing.T) {
+	tests := []struct {
+		err  sarama.KError
+		want []error
+	}{
+		{err: sarama.ErrOffsetOutOfRange, want: []error{ErrOffsetOutOfRange}},
+		{err: sarama.ErrUnknownTopicOrPartition, want: []error{ErrTopicNotFound}},
+		{err: sarama.ErrTopicAuthorizationFailed, want: []error{ErrUnauthorized, ErrAuth}},
+		{err: sarama.ErrGroupAuthorizationFailed, want: []error{ErrUnauthorized, ErrAuth}},
+		{err: sarama.ErrClusterAuthorizationFailed, want: []error{ErrUnauthorized, ErrAuth}},
+		{err: sarama.ErrSASLAuthenticationFailed, want: []error{ErrAuth}},
+		{err: sarama.ErrNotLeaderForPartition},
+	}
+
+	sentinels := []error{ErrOffsetOutOfRange, ErrTopicNotFound, ErrUnauthorized, ErrAuth}
+	for _, tt := range tests {
+		err := wrapErr("get partitions", "t", -1, -1, tt.err)
+		for _, s := range sentinels {
+			want := false
+			for _, w := range tt.want {
+				want = want || w == s
+			}
+			if got := errors.Is(err, s); got != want {
+				t.Errorf("errors.Is(%v, %v) = %v, want %v", tt.err, s, got, want)
+			}
+		}
+
+		var kerr sarama.KError
+		if !errors.As(err, &kerr) || kerr != tt.err {
+			t.Errorf("got %v, want it to wrap %v", kerr, tt.err)
+		}
+	}
+}
+
+func TestTopicErrors(t *testing.T) {
+	m, cli := newTestClient(t, "t", []string{"a"})
+	m.deny("t")
+
+	_, err := cli.GetTopic("nope")
+	if !errors.Is(err, ErrTopicNotFound) || errors.Is(err, ErrUnauthorized) {
+		t.Errorf("got %v for a topic that doesn't exist, want %v", err, ErrTopicNotFound)
+	}
+
+	_, err = cli.GetTopic("t")
+	if !errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrTopicNotFound) {
+		t.Errorf("got %v for a denied topic, want %v", err, ErrUnauthorized)
+	}
+
+	_, err = cli.GetPartition(Partition{Topic: "t", Partition: 0, End: 1}, 10, nil)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("got %v from GetPartition, want %v", err, ErrUnauthorized)
+	}
+
+	_, err = cli.Messages(context.Background(), Partition{Topic: "t", Partition: 0, End: 1}, 0)
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("got %v from Messages, want %v", err, ErrUnauthorized)
+	}
+
+	// without SASL there is no principal to name
+	var kerr *KafkaError
+	if !errors.As(err, &kerr) || kerr.Principal != "" {
+		t.Errorf("got %v, want a *KafkaError without a principal", err)
+	}
+}
+
+func TestUnauthorizedPrincipal(t *testing.T) {
+	t.Setenv("KCLI_USERNAME", "alice")
+	t.Setenv("KCLI_PASSWORD", "secret")
+
+	m, cli := newTestClient(t, "t", []string{"a"})
+	m.deny("t")
+
+	_, err := cli.GetTopic("t")
+	var kerr *KafkaError
+	if !errors.As(err, &kerr) || kerr.Principal != "alice" {
+		t.Fatalf("got %v, want a *KafkaError for alice", err)
+	}
+	if !strings.Contains(err.Error(), `principal "alice"`) {
+		t.Errorf("%q doesn't name the principal", err)
+	}
+
+	// topics that don't exist aren't the principal's fault
+	_, err = cli.GetTopic("nope")
+	if !errors.As(err, &kerr) || kerr.Principal != "" {
+		t.Errorf("got %v, want a *KafkaError without a principal", err)
+	}
+}
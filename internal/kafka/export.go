@@ -0,0 +1,365 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// exportBuffer is how many messages each chunk of an Export can read
+// ahead of the writer.
+const exportBuffer = 256
+
+// ExportOpts configures an Export
+type ExportOpts struct {
+	// IntraPartitionParallelism splits each partition's [Offset, End)
+	// into this many contiguous chunks that are read concurrently by
+	// separate partition consumers.  Defaults to 1.
+	IntraPartitionParallelism int
+
+	// Merge writes each partition's messages in offset order, buffering
+	// at most a few hundred messages per chunk.  Without it messages are
+	// written as soon as they are read, so chunks of a partition are
+	// interleaved in the output.
+	Merge bool
+
+	// ChunkDone, when set, is called after the last message of a chunk
+	// has been written.
+	ChunkDone func(ExportChunk)
+
+	// DedupByKey writes only the newest message of each key (see
+	// DedupByKey).  Keyed messages are held until every partition has
+	// been read and then written in partition and offset order.  The
+	// MaxKeys CallOpt bounds how many keys are held.
+	DedupByKey bool
+
+	// From and To, when set, limit the export to messages whose
+	// timestamp is between them (inclusive).  Each partition's Offset is
+	// moved up to the first offset at From and its End down to the first
+	// offset after To so partitions with nothing in the window aren't
+	// read at all.
+	From time.Time
+	To   time.Time
+
+	// Tolerance widens the offsets read for From and To to allow for
+	// producers whose clocks drift: messages within the window that were
+	// written up to Tolerance out of order are still exported.
+	Tolerance time.Duration
+}
+
+// inWindow is true if t is between From and To
+func (e ExportOpts) inWindow(t time.Time) bool {
+	return (e.From.IsZero() || !t.Before(e.From)) && (e.To.IsZero() || !t.After(e.To))
+}
+
+// ExportStats reports what an Export wrote.  Duplicates counts the
+// messages that were dropped by ExportOpts.DedupByKey.
+type ExportStats struct {
+	Exported   int64 `json:"exported"`
+	Duplicates int64 `json:"duplicates"`
+}
+
+// ExportChunk is a contiguous range of a partition that was exported.
+// Partition.Offset and Partition.End are the chunk's boundaries.
+type ExportChunk struct {
+	Partition Partition `json:"partition"`
+	Index     int       `json:"index"`
+	Exported  int64     `json:"exported"`
+}
+
+type exportItem struct {
+	chunk int
+	msg   Message
+	done  bool
+}
+
+// Export writes the decoded messages of parts, from each partition's
+// Offset to its End, to w as JSON lines.  Partitions are exported one
+// after another.
+func (c *Client) Export(ctx context.Context, parts []Partition, w io.Writer, opts ExportOpts, callOpts ...CallOpt) (ExportStats, error) {
+	return c.ExportTo(ctx, parts, writerSink{w}, opts, callOpts...)
+}
+
+// ExportTo is Export to a Sink, which gets a writer for each partition.
+// With DedupByKey every partition's writer stays open until the keyed
+// messages have been written at the end.
+func (c *Client) ExportTo(ctx context.Context, parts []Partition, sink Sink, opts ExportOpts, callOpts ...CallOpt) (stats ExportStats, err error) {
+	defer func() {
+		if cerr := sink.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	parts, err = c.exportWindow(parts, opts)
+	if err != nil {
+		return ExportStats{}, err
+	}
+
+	o, done := getCallOpts(callOpts).withProgress(totalMessages(parts))
+	defer done()
+
+	writers := map[TopicPartition]*exportWriter{}
+	defer func() {
+		for _, w := range writers {
+			w.Close()
+		}
+	}()
+
+	writer := func(p Partition) (*exportWriter, error) {
+		tp := TopicPartition{Topic: p.Topic, Partition: p.Partition}
+		if w, ok := writers[tp]; ok {
+			return w, nil
+		}
+
+		w, err := sink.Open(p)
+		if err != nil {
+			return nil, err
+		}
+		writers[tp] = &exportWriter{WriteCloser: w, enc: json.NewEncoder(w)}
+		return writers[tp], nil
+	}
+
+	closeWriter := func(p Partition) error {
+		tp := TopicPartition{Topic: p.Topic, Partition: p.Partition}
+		w, ok := writers[tp]
+		if !ok {
+			return nil
+		}
+		delete(writers, tp)
+		return w.Close()
+	}
+
+	write := func(m Message) error {
+		w, err := writer(m.Partition)
+		if err != nil {
+			return err
+		}
+		if err := w.enc.Encode(m); err != nil {
+			return err
+		}
+		stats.Exported++
+		return nil
+	}
+
+	emit := write
+	var dedup *deduper
+	if opts.DedupByKey {
+		dedup = newDeduper(o.maxKeys)
+		emit = func(m Message) error {
+			if m.Key == nil {
+				return write(m)
+			}
+			return dedup.add(m)
+		}
+	}
+
+	for _, p := range parts {
+		chunks := splitChunks(p, opts.IntraPartitionParallelism)
+		if _, err := c.exportChunks(ctx, chunks, emit, opts, o); err != nil {
+			return stats, err
+		}
+
+		if dedup == nil {
+			if err := closeWriter(p); err != nil {
+				return stats, err
+			}
+		}
+	}
+
+	if dedup == nil {
+		return stats, nil
+	}
+
+	stats.Duplicates = dedup.suppressed
+	for _, m := range dedup.messages() {
+		if err := write(m); err != nil {
+			return stats, err
+		}
+	}
+
+	for _, p := range parts {
+		if err := closeWriter(p); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// exportWriter is a Sink's writer for a partition
+type exportWriter struct {
+	io.WriteCloser
+	enc *json.Encoder
+}
+
+// exportWindow narrows each partition to the offsets that can hold
+// messages between opts.From and opts.To.
+func (c *Client) exportWindow(parts []Partition, opts ExportOpts) ([]Partition, error) {
+	if opts.From.IsZero() && opts.To.IsZero() {
+		return parts, nil
+	}
+
+	out := make([]Partition, len(parts))
+	for i, p := range parts {
+		if !opts.From.IsZero() {
+			o, err := c.offsetForTime(p, opts.From.Add(-opts.Tolerance))
+			if err != nil {
+				return nil, err
+			}
+			if o > p.Offset {
+				p.Offset = o
+			}
+		}
+
+		if !opts.To.IsZero() {
+			// the first offset after To, not at it
+			o, err := c.offsetForTime(p, opts.To.Add(opts.Tolerance+time.Millisecond))
+			if err != nil {
+				return nil, err
+			}
+			if o < p.End {
+				p.End = o
+			}
+		}
+
+		if p.Offset > p.End {
+			p.Offset = p.End
+		}
+		out[i] = p
+	}
+	return out, nil
+}
+
+// splitChunks splits p's [Offset, End) into up to n contiguous chunks.
+func splitChunks(p Partition, n int) []ExportChunk {
+	size := p.End - p.Offset
+	if n < 1 {
+		n = 1
+	}
+	if int64(n) > size {
+		n = int(size)
+	}
+	if n < 1 {
+		return nil
+	}
+
+	out := make([]ExportChunk, n)
+	start := p.Offset
+	for i := range out {
+		end := p.Offset + size*int64(i+1)/int64(n)
+		cp := p
+		cp.Offset = start
+		cp.End = end
+		out[i] = ExportChunk{Partition: cp, Index: i}
+		start = end
+	}
+	return out
+}
+
+func (c *Client) exportChunks(ctx context.Context, chunks []ExportChunk, emit func(Message) error, opts ExportOpts, o callOpts) (int64, error) {
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chans := make([]chan exportItem, len(chunks))
+	shared := make(chan exportItem, exportBuffer)
+	for i := range chans {
+		if opts.Merge {
+			chans[i] = make(chan exportItem, exportBuffer)
+		} else {
+			chans[i] = shared
+		}
+	}
+
+	errs := make([]error, len(chunks))
+	var wg sync.WaitGroup
+	for i, ch := range chunks {
+		wg.Add(1)
+		go func(i int, ch ExportChunk) {
+			defer wg.Done()
+			out := chans[i]
+			_, errs[i] = c.fetch(ctx, ch.Partition, ch.Partition.End-ch.Partition.Offset, o, func(m Message) bool {
+				if !opts.inWindow(m.Timestamp) {
+					return false
+				}
+
+				select {
+				case out <- exportItem{chunk: i, msg: m}:
+					return false
+				case <-ctx.Done():
+					return true
+				}
+			})
+			if errs[i] != nil {
+				cancel()
+			}
+
+			if opts.Merge {
+				close(out)
+				return
+			}
+
+			select {
+			case out <- exportItem{chunk: i, done: true}:
+			case <-ctx.Done():
+			}
+		}(i, ch)
+	}
+
+	var n int64
+	var werr error
+	write := func(it exportItem) {
+		if werr != nil {
+			return
+		}
+		if it.done {
+			if opts.ChunkDone != nil {
+				opts.ChunkDone(chunks[it.chunk])
+			}
+			return
+		}
+		if werr = emit(it.msg); werr != nil {
+			cancel()
+			return
+		}
+		chunks[it.chunk].Exported++
+		n++
+	}
+
+	if opts.Merge {
+		for i, ch := range chans {
+			for it := range ch {
+				write(it)
+			}
+			write(exportItem{chunk: i, done: true})
+		}
+	} else {
+		for remaining := len(chunks); remaining > 0; {
+			select {
+			case it := <-shared:
+				if it.done {
+					remaining--
+				}
+				write(it)
+			case <-ctx.Done():
+				remaining = 0
+			}
+		}
+	}
+
+	cancel()
+	wg.Wait()
+
+	if werr != nil {
+		return n, werr
+	}
+
+	for _, err := range errs {
+		if err != nil && err != context.Canceled {
+			return n, err
+		}
+	}
+
+	return n, parent.Err()
+}
@@ -0,0 +1,117 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExportParallel(t *testing.T) {
+	m := newMockCluster()
+	for i := 0; i < 10; i++ {
+		m.produce("t", 0, nil, []byte(fmt.Sprint(i)))
+	}
+	for i := 0; i < 4; i++ {
+		m.produce("t", 1, nil, []byte(fmt.Sprint(i)))
+	}
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	parts, err := cli.GetTopic("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lock sync.Mutex
+	var chunks []string
+	var buf bytes.Buffer
+	stats, err := cli.Export(context.Background(), parts, &buf, ExportOpts{
+		IntraPartitionParallelism: 3,
+		Merge:                     true,
+		ChunkDone: func(c ExportChunk) {
+			lock.Lock()
+			defer lock.Unlock()
+			chunks = append(chunks, fmt.Sprintf("%d:%d-%d:%d", c.Partition.Partition, c.Partition.Offset, c.Partition.End, c.Exported))
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Exported != 14 {
+		t.Errorf("exported %d, want 14", stats.Exported)
+	}
+
+	// merged chunks come out in partition and offset order
+	var got []string
+	s := bufio.NewScanner(&buf)
+	for s.Scan() {
+		var msg Message
+		if err := json.Unmarshal(s.Bytes(), &msg); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, fmt.Sprintf("%d/%d", msg.Partition.Partition, msg.Offset))
+	}
+
+	var want []string
+	for i := 0; i < 10; i++ {
+		want = append(want, fmt.Sprintf("0/%d", i))
+	}
+	for i := 0; i < 4; i++ {
+		want = append(want, fmt.Sprintf("1/%d", i))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// the chunks of a partition cover it without overlapping
+	sort.Strings(chunks)
+	if want := []string{"0:0-3:3", "0:3-6:3", "0:6-10:4", "1:0-1:1", "1:1-2:1", "1:2-4:2"}; !reflect.DeepEqual(chunks, want) {
+		t.Errorf("got chunks %v, want %v", chunks, want)
+	}
+}
+
+// BenchmarkExport exports a partition whose fetches each take a
+// millisecond, one per 100 messages, with more and more chunks.  Merged
+// exports gain less: a chunk can only read exportBuffer messages ahead
+// of the chunks before it.
+func BenchmarkExport(b *testing.B) {
+	m := newMockCluster()
+	for i := 0; i < 5000; i++ {
+		m.produce("t", 0, nil, []byte(fmt.Sprint(i)))
+		if i%100 == 0 {
+			m.stall("t", 0, int64(i), time.Millisecond)
+		}
+	}
+
+	cli, err := m.client()
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer cli.Close()
+
+	parts := []Partition{{Topic: "t", Partition: 0, End: 5000}}
+	for _, merge := range []bool{false, true} {
+		for _, n := range []int{1, 2, 4, 8} {
+			b.Run(fmt.Sprintf("merge=%t/parallelism=%d", merge, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if _, err := cli.Export(context.Background(), parts, io.Discard, ExportOpts{IntraPartitionParallelism: n, Merge: merge}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/antonmedv/expr"
+	"github.com/antonmedv/expr/vm"
+)
+
+type exprMatcher struct {
+	program *vm.Program
+}
+
+// NewExprMatcher returns a Matcher that evaluates expr, which must be a
+// boolean expression (see github.com/antonmedv/expr for the language),
+// against each message.  The variables are value (the decoded value
+// parsed as JSON, or the value as a string if it isn't JSON), key,
+// offset, partition, timestamp and headers (a map of header name to
+// value), ie:
+//
+//	value.amount > 100 && headers["region"] == "eu"
+//
+// The expression is compiled once.  Messages it fails to evaluate against
+// (ie a missing field) don't match.
+func NewExprMatcher(e string) (Matcher, error) {
+	program, err := expr.Compile(e, expr.Env(exprEnv{}), expr.AsBool())
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression: %s", err)
+	}
+	return &exprMatcher{program: program}, nil
+}
+
+// Match implements Matcher
+func (m *exprMatcher) Match(msg Message) bool {
+	out, err := expr.Run(m.program, newExprEnv(msg))
+	if err != nil {
+		return false
+	}
+	ok, _ := out.(bool)
+	return ok
+}
+
+// exprEnv holds the variables of an expression
+type exprEnv struct {
+	Value     interface{}       `expr:"value"`
+	Key       string            `expr:"key"`
+	Offset    int64             `expr:"offset"`
+	Partition int32             `expr:"partition"`
+	Timestamp time.Time         `expr:"timestamp"`
+	Headers   map[string]string `expr:"headers"`
+}
+
+func newExprEnv(msg Message) exprEnv {
+	var value interface{}
+	if err := json.Unmarshal(msg.Value, &value); err != nil {
+		value = string(msg.Value)
+	}
+
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+
+	return exprEnv{
+		Value:     value,
+		Key:       string(msg.Key),
+		Offset:    msg.Offset,
+		Partition: msg.Partition.Partition,
+		Timestamp: msg.Timestamp,
+		Headers:   headers,
+	}
+}
@@ -0,0 +1,293 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// FetchStats reports what a FetchTopic did.  Duplicates counts the
+// messages that were dropped by DedupByKey and CorruptBatches the batches
+// passed over by SkipCorruptBatches.
+type FetchStats struct {
+	Delivered      int64                                  `json:"delivered"`
+	Skipped        int64                                  `json:"skipped"`
+	Duplicates     int64                                  `json:"duplicates"`
+	CorruptBatches int64                                  `json:"corrupt_batches"`
+	Partitions     map[TopicPartition]PartitionFetchStats `json:"-"`
+}
+
+// MarshalJSON writes Partitions as a list, in topic and partition order,
+// because json can't have a TopicPartition as a key.
+func (s FetchStats) MarshalJSON() ([]byte, error) {
+	type partition struct {
+		TopicPartition
+		PartitionFetchStats
+	}
+
+	parts := make([]partition, 0, len(s.Partitions))
+	for tp, st := range s.Partitions {
+		parts = append(parts, partition{TopicPartition: tp, PartitionFetchStats: st})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		if parts[i].Topic != parts[j].Topic {
+			return parts[i].Topic < parts[j].Topic
+		}
+		return parts[i].Partition < parts[j].Partition
+	})
+
+	type stats FetchStats
+	return json.Marshal(struct {
+		stats
+		Partitions []partition `json:"partitions"`
+	}{stats: stats(s), Partitions: parts})
+}
+
+// PartitionFetchStats reports what a fetch did for a single partition.
+// Skipped counts the messages that were passed over by sampling and Next
+// is the offset to continue from.  CorruptBatches and CorruptOffsets are
+// the corrupt batches, and the offsets, that SkipCorruptBatches skipped.
+type PartitionFetchStats struct {
+	Delivered      int64 `json:"delivered"`
+	Skipped        int64 `json:"skipped"`
+	Next           int64 `json:"next"`
+	CorruptBatches int64 `json:"corrupt_batches,omitempty"`
+	CorruptOffsets int64 `json:"corrupt_offsets,omitempty"`
+}
+
+// SkipCorruptBatches makes fetches step over record batches that fail
+// their checksum (see ErrCorruptBatch) instead of failing.  kafka doesn't
+// say where a batch ends so the offsets after it are probed with a
+// doubling stride, which can also skip a few good messages that follow
+// it.  FetchStats counts what was skipped.
+func SkipCorruptBatches() CallOpt {
+	return func(o *callOpts) {
+		o.skipCorrupt = true
+	}
+}
+
+// SampleEvery only delivers every nth message.  The skipped messages are
+// never decoded.
+func SampleEvery(n int) CallOpt {
+	return func(o *callOpts) {
+		o.sampleEvery = n
+	}
+}
+
+// SampleRate delivers a random sample of roughly rate (0-1) of the
+// messages.  The same seed always selects the same messages, which keeps
+// the sample from being biased by how the producer batched its writes.
+func SampleRate(rate float64, seed int64) CallOpt {
+	return func(o *callOpts) {
+		o.sampleRate = rate
+		o.sampleSeed = seed
+	}
+}
+
+// sampler returns a func that reports whether the next message of a
+// partition should be delivered.
+func (o callOpts) sampler(partition int32) func() bool {
+	switch {
+	case o.sampleEvery > 1:
+		var i int
+		return func() bool {
+			i++
+			return (i-1)%o.sampleEvery == 0
+		}
+	case o.sampleRate > 0 && o.sampleRate < 1:
+		r := rand.New(rand.NewSource(o.sampleSeed + int64(partition)))
+		return func() bool { return r.Float64() < o.sampleRate }
+	default:
+		return func() bool { return true }
+	}
+}
+
+// FetchTopic fetches up to end messages from each of parts concurrently.
+// Calls to cb are serialized, and if it returns true every partition
+// stops consuming.  Partitions that are added to the topics of parts
+// while it runs are fetched too, from their oldest message (see
+// PartitionRefresh and OnNewPartitions).  A partition that fails doesn't
+// stop the others: the error is a *PartialError once they are done.
+func (c *Client) FetchTopic(ctx context.Context, parts []Partition, end int64, cb func(Message) bool, opts ...CallOpt) (FetchStats, error) {
+	o, done := getCallOpts(opts).withProgress(totalMessages(parts))
+	defer done()
+	stats := FetchStats{Partitions: map[TopicPartition]PartitionFetchStats{}}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var firstErr error
+	var partial PartialError
+	var dedup *deduper
+	if o.dedup {
+		dedup = newDeduper(o.maxKeys)
+	}
+
+	var lock sync.Mutex
+	f := func(m Message) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		if ctx.Err() != nil {
+			return true
+		}
+
+		if dedup != nil && m.Key != nil {
+			if err := dedup.add(m); err != nil {
+				firstErr = err
+				cancel()
+				return true
+			}
+			return false
+		}
+
+		if cb(m) {
+			cancel()
+			return true
+		}
+		return false
+	}
+
+	// running counts the partitions being fetched, allDone is closed
+	// when it drops to 0 and no more are started after that.  It starts
+	// at len(parts) so that a partition that is done before the others
+	// have been launched can't close allDone early.
+	sem := make(chan struct{}, c.concurrency)
+	running := len(parts)
+	allDone := make(chan struct{})
+	if running == 0 {
+		running = -1
+		close(allDone)
+	}
+
+	launch := func(p Partition) {
+		go func() {
+			sem <- struct{}{}
+			st, err := c.fetch(ctx, p, end, o, f)
+			<-sem
+
+			lock.Lock()
+			defer lock.Unlock()
+			stats.Partitions[TopicPartition{Topic: p.Topic, Partition: p.Partition}] = st
+			stats.Delivered += st.Delivered
+			stats.Skipped += st.Skipped
+			stats.CorruptBatches += st.CorruptBatches
+			if err != nil && err != context.Canceled {
+				partial.add(p, err)
+			}
+
+			if running--; running == 0 {
+				running = -1
+				close(allDone)
+			}
+		}()
+	}
+
+	// start launches a partition that was added while fetching
+	start := func(p Partition) {
+		lock.Lock()
+		if running < 0 {
+			lock.Unlock()
+			return
+		}
+		running++
+		lock.Unlock()
+		launch(p)
+	}
+
+	for _, p := range parts {
+		launch(p)
+	}
+
+	refreshCtx, stopRefresh := context.WithCancel(ctx)
+	refreshed := make(chan struct{})
+	go func() {
+		defer close(refreshed)
+		c.newPartitions(refreshCtx, parts, o, start)
+	}()
+
+	<-allDone
+	stopRefresh()
+	<-refreshed
+
+	if firstErr == nil {
+		firstErr = parent.Err()
+	}
+
+	if dedup != nil {
+		stats.Duplicates = dedup.suppressed
+		stats.Delivered -= dedup.suppressed
+		if firstErr == nil && ctx.Err() == nil {
+			for _, m := range dedup.messages() {
+				if cb(m) {
+					break
+				}
+			}
+		}
+	}
+
+	if firstErr == nil {
+		firstErr = partial.orNil()
+	}
+	return stats, firstErr
+}
+
+// FetchReverse pages backwards through a partition, from info.End towards
+// info.Start, sending pages of up to pageSize messages to cb with the
+// newest message first.  It stops when the start of the partition is
+// reached or cb returns true.  On compacted topics a window of pageSize
+// offsets can hold fewer messages, so windows are widened until a full
+// page is found and no message is ever delivered twice.
+func (c *Client) FetchReverse(ctx context.Context, info Partition, pageSize int, cb func([]Message) bool, opts ...CallOpt) error {
+	if pageSize <= 0 {
+		return nil
+	}
+
+	o := getCallOpts(opts)
+	hi := info.End
+	for hi > info.Start {
+		var page []Message
+		lo := hi
+		for len(page) < pageSize && lo > info.Start {
+			top := lo
+			lo = top - int64(pageSize)
+			if lo < info.Start {
+				lo = info.Start
+			}
+
+			var window []Message
+			w := info
+			w.Offset = lo
+			w.End = top
+			_, err := c.fetch(ctx, w, top-lo, o, func(m Message) bool {
+				window = append(window, m)
+				return false
+			})
+			if err != nil {
+				return err
+			}
+			page = append(window, page...)
+		}
+
+		if len(page) > pageSize {
+			page = page[len(page)-pageSize:]
+		}
+
+		if len(page) == 0 {
+			return nil
+		}
+
+		hi = page[0].Offset
+		for i, j := 0, len(page)-1; i < j; i, j = i+1, j-1 {
+			page[i], page[j] = page[j], page[i]
+		}
+
+		if cb(page) {
+			return nil
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+)
+
+// Formatter turns a message into the text that is written for it
+type Formatter interface {
+	Format(w io.Writer, msg Message) error
+}
+
+// templateMessage is what a template formatter's template is executed
+// with.  Key and Value are strings so they print as text.
+type templateMessage struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Timestamp time.Time
+	Key       string
+	Value     string
+	Headers   map[string]string
+	Message   Message
+}
+
+type templateFormatter struct {
+	tmpl *template.Template
+}
+
+// NewTemplateFormatter returns a Formatter that executes tmpl, a
+// text/template, for each message.  The template can use .Topic,
+// .Partition, .Offset, .Timestamp, .Key, .Value (decoded), .Headers (a map
+// of header name to value) and .Message (the Message itself).  The json
+// func parses a string (ie .Value) as JSON so fields can be picked out:
+//
+//	{{.Offset}} {{(json .Value).user.id}}
+//
+// hex renders a string as a hex dump and encoding returns the
+// DetectEncoding of a string.  A newline is written after each message.
+func NewTemplateFormatter(tmpl string) (Formatter, error) {
+	t, err := template.New("message").Funcs(template.FuncMap{
+		"json":     parseJSON,
+		"hex":      func(s string) string { return RenderHexDump([]byte(s), 16) },
+		"encoding": func(s string) string { return DetectEncoding([]byte(s)).String() },
+	}).Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message template: %s", err)
+	}
+	return &templateFormatter{tmpl: t}, nil
+}
+
+// Format implements Formatter
+func (t *templateFormatter) Format(w io.Writer, msg Message) error {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+
+	var buf bytes.Buffer
+	err := t.tmpl.Execute(&buf, templateMessage{
+		Topic:     msg.Partition.Topic,
+		Partition: msg.Partition.Partition,
+		Offset:    msg.Offset,
+		Timestamp: msg.Timestamp,
+		Key:       string(msg.Key),
+		Value:     string(msg.Value),
+		Headers:   headers,
+		Message:   msg,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to format message at offset %d: %s", msg.Offset, err)
+	}
+
+	buf.WriteByte('\n')
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func parseJSON(s string) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		return nil, fmt.Errorf("value is not json: %s", err)
+	}
+	return v, nil
+}
+
+// FetchFormatted fetches up to end messages, starting at part.Offset, and
+// writes each one to w using f.
+func (c *Client) FetchFormatted(ctx context.Context, part Partition, end int64, f Formatter, w io.Writer, opts ...CallOpt) error {
+	var ferr error
+	_, _, err := c.FetchN(ctx, part, end, func(msg Message) bool {
+		ferr = f.Format(w, msg)
+		return ferr != nil
+	}, opts...)
+
+	if ferr != nil {
+		return ferr
+	}
+	return err
+}
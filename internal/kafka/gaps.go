@@ -0,0 +1,69 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/IBM/sarama"
+)
+
+// Gap is a range of offsets, From to To inclusive, that held no record.
+// Marker is set for single offset gaps, which is what a transaction's
+// commit or abort marker leaves behind.
+type Gap struct {
+	From   int64 `json:"from"`
+	To     int64 `json:"to"`
+	Count  int64 `json:"count"`
+	Marker bool  `json:"marker,omitempty"`
+}
+
+// GapReport is what ScanGaps found.  Expected is End - Offset of the
+// partition that was scanned and Missing is Expected - Records, split
+// into the offsets in Markers and the ones in Holes.
+type GapReport struct {
+	Partition Partition `json:"partition"`
+	Records   int64     `json:"records"`
+	Expected  int64     `json:"expected"`
+	Missing   int64     `json:"missing"`
+	Markers   int64     `json:"markers"`
+	Holes     int64     `json:"holes"`
+	Gaps      []Gap     `json:"gaps"`
+}
+
+// ScanGaps reads part from its Offset to its End and reports the ranges
+// of offsets without a record.  Compaction and transactions leave gaps
+// too, so a hole isn't necessarily lost data, but on a topic that is
+// neither compacted nor transactional any hole is suspicious.  Messages
+// aren't decoded or kept.  If ctx is cancelled the report so far is
+// returned with the error.
+func (c *Client) ScanGaps(ctx context.Context, part Partition) (GapReport, error) {
+	r := GapReport{Partition: part, Expected: part.End - part.Offset}
+	next := part.Offset
+	err := c.consume(ctx, part, part.End-part.Offset, func(msg *sarama.ConsumerMessage) bool {
+		r.add(next, msg.Offset-1)
+		r.Records++
+		next = msg.Offset + 1
+		return false
+	})
+
+	if err == nil {
+		// markers are often the last offsets of a partition
+		r.add(next, part.End-1)
+	}
+	r.Missing = r.Markers + r.Holes
+	return r, err
+}
+
+// add records the gap from to to, if there is one
+func (r *GapReport) add(from, to int64) {
+	if to < from {
+		return
+	}
+
+	g := Gap{From: from, To: to, Count: to - from + 1, Marker: from == to}
+	if g.Marker {
+		r.Markers++
+	} else {
+		r.Holes += g.Count
+	}
+	r.Gaps = append(r.Gaps, g)
+}
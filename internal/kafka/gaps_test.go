@@ -0,0 +1,60 @@
+package kafka
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScanGaps(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, nil, []byte("a"))
+	m.hole("t", 0, 2)
+	m.produce("t", 0, nil, []byte("b"))
+	m.hole("t", 0, 1)
+	m.produce("t", 0, nil, []byte("c"))
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	r, err := cli.ScanGaps(context.Background(), Partition{Topic: "t", Partition: 0, End: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Gap{{From: 1, To: 2, Count: 2}, {From: 4, To: 4, Count: 1, Marker: true}}
+	if !reflect.DeepEqual(r.Gaps, want) {
+		t.Errorf("got gaps %+v, want %+v", r.Gaps, want)
+	}
+	if r.Records != 3 || r.Expected != 6 || r.Missing != 3 || r.Markers != 1 || r.Holes != 2 {
+		t.Errorf("got %+v, want 3 records, 1 marker and 2 holes out of 6", r)
+	}
+}
+
+func TestScanGapsStall(t *testing.T) {
+	m := newMockCluster()
+	for i := 0; i < 4; i++ {
+		m.produce("t", 0, nil, []byte("x"))
+	}
+	m.stall("t", 0, 2, 1500*time.Millisecond)
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	// a consumer that is waiting on the broker mid-partition isn't at the
+	// end, the offsets after the stall mustn't be reported as a hole
+	r, err := cli.ScanGaps(context.Background(), Partition{Topic: "t", Partition: 0, End: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Records != 4 || len(r.Gaps) != 0 {
+		t.Errorf("got %d records and gaps %+v, want 4 records and no gaps", r.Records, r.Gaps)
+	}
+}
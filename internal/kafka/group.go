@@ -0,0 +1,189 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// FromOldest makes a consumer group that has no committed offsets start
+// at the oldest message instead of the newest.
+func FromOldest() CallOpt {
+	return func(o *callOpts) {
+		o.fromOldest = true
+	}
+}
+
+// OnRebalance is called with the topics and partitions that are assigned
+// to this member each time the group rebalances.
+func OnRebalance(f func(claims map[string][]int32)) CallOpt {
+	return func(o *callOpts) {
+		o.onRebalance = f
+	}
+}
+
+// CommitMetadata sets the metadata string that ConsumeGroup commits with
+// each offset (ie the version of the consumer).
+func CommitMetadata(m string) CallOpt {
+	return func(o *callOpts) {
+		o.commitMetadata = m
+	}
+}
+
+// ConsumeGroup joins group as a real member and sends the decoded messages
+// of the partitions it is assigned to cb.  A message's offset is committed
+// once cb returns nil; if cb returns an error consuming stops and that
+// error is returned.  Cancelling ctx commits what has been consumed and
+// leaves the group so that the remaining members rebalance right away.
+func (c *Client) ConsumeGroup(ctx context.Context, group string, topics []string, cb func(Message) error, opts ...CallOpt) error {
+	o := getCallOpts(opts)
+	cfg := *c.cfg
+	cfg.Consumer.Offsets.Initial = sarama.OffsetNewest
+	if o.fromOldest {
+		cfg.Consumer.Offsets.Initial = sarama.OffsetOldest
+	}
+
+	cg, err := sarama.NewConsumerGroup(c.learnAddrs(), group, &cfg)
+	if err != nil {
+		return err
+	}
+	defer cg.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	h := &groupHandler{cli: c, o: o, cb: cb, cancel: cancel, topics: topics}
+	go h.watchErrors(cg.Errors())
+	for {
+		if err := cg.Consume(ctx, topics, h); err != nil {
+			return err
+		}
+
+		if err := h.error(); err != nil {
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+type groupHandler struct {
+	cli    *Client
+	o      callOpts
+	cb     func(Message) error
+	cancel func()
+	topics []string
+
+	// known are the partitions of topics as of the last rebalance
+	known map[string]map[int32]bool
+
+	lock sync.Mutex
+	err  error
+}
+
+func (g *groupHandler) Setup(s sarama.ConsumerGroupSession) error {
+	if g.o.onRebalance != nil {
+		g.o.onRebalance(s.Claims())
+	}
+	g.checkPartitions()
+	return nil
+}
+
+// checkPartitions reports the partitions that have been added to the
+// group's topics since the previous rebalance (sarama rebalances when
+// the partition count changes).
+func (g *groupHandler) checkPartitions() {
+	first := g.known == nil
+	if first {
+		g.known = map[string]map[int32]bool{}
+	}
+
+	for _, topic := range g.topics {
+		if err := g.cli.sarama.RefreshMetadata(topic); err != nil {
+			continue
+		}
+
+		partitions, err := g.cli.sarama.Partitions(topic)
+		if err != nil {
+			continue
+		}
+
+		if g.known[topic] == nil {
+			g.known[topic] = map[int32]bool{}
+		}
+
+		var added []int32
+		for _, p := range partitions {
+			if !g.known[topic][p] {
+				g.known[topic][p] = true
+				added = append(added, p)
+			}
+		}
+
+		if !first && len(added) > 0 && g.o.onNewPartitions != nil {
+			g.o.onNewPartitions(topic, added)
+		}
+	}
+}
+
+func (g *groupHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (g *groupHandler) ConsumeClaim(s sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	part := Partition{Topic: claim.Topic(), Partition: claim.Partition()}
+	for msg := range claim.Messages() {
+		val, ok, err := g.cli.decode(s.Context(), g.o, msg.Topic, msg.Offset, msg.Value)
+		if err != nil {
+			return g.fail(err)
+		}
+
+		if ok {
+			m := newMessage(part, msg)
+			m.Value = val
+			if err := g.cb(m); err != nil {
+				return g.fail(err)
+			}
+		}
+
+		s.MarkMessage(msg, g.o.commitMetadata)
+	}
+	return nil
+}
+
+func (g *groupHandler) fail(err error) error {
+	g.lock.Lock()
+	if g.err == nil {
+		g.err = err
+	}
+	g.lock.Unlock()
+	g.cancel()
+	return err
+}
+
+// watchErrors reads the group's errors until it is closed.  Retriable
+// fetch errors are logged (sarama retries them), anything else stops
+// consuming.
+func (g *groupHandler) watchErrors(errs <-chan error) {
+	for err := range errs {
+		var cerr *sarama.ConsumerError
+		if !errors.As(err, &cerr) {
+			g.fail(err)
+			continue
+		}
+
+		if retriable(cerr.Err) {
+			g.cli.logf("group consume %s/%d: %s", cerr.Topic, cerr.Partition, cerr.Err)
+			continue
+		}
+		g.fail(wrapErr("consume group", cerr.Topic, cerr.Partition, -1, g.cli.fetchError(cerr.Err)))
+	}
+}
+
+func (g *groupHandler) error() error {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.err
+}
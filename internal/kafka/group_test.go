@@ -0,0 +1,151 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+// testSession is the sarama.ConsumerGroupSession a groupHandler sees
+type testSession struct {
+	ctx    context.Context
+	claims map[string][]int32
+	marked []string
+}
+
+func (s *testSession) Claims() map[string][]int32 { return s.claims }
+
+func (s *testSession) MemberID() string { return "member" }
+
+func (s *testSession) GenerationID() int32 { return 1 }
+
+func (s *testSession) MarkOffset(topic string, partition int32, offset int64, metadata string) {}
+
+func (s *testSession) Commit() {}
+
+func (s *testSession) ResetOffset(topic string, partition int32, offset int64, metadata string) {}
+
+func (s *testSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.marked = append(s.marked, string(msg.Value)+"@"+metadata)
+}
+
+func (s *testSession) Context() context.Context { return s.ctx }
+
+// testClaim is a claim on a partition whose messages are msgs
+type testClaim struct {
+	topic     string
+	partition int32
+	msgs      chan *sarama.ConsumerMessage
+}
+
+func newTestClaim(topic string, partition int32, vals ...string) *testClaim {
+	c := &testClaim{topic: topic, partition: partition, msgs: make(chan *sarama.ConsumerMessage, len(vals))}
+	for i, v := range vals {
+		c.msgs <- &sarama.ConsumerMessage{Topic: topic, Partition: partition, Offset: int64(i), Value: []byte(v)}
+	}
+	close(c.msgs)
+	return c
+}
+
+func (c *testClaim) Topic() string { return c.topic }
+
+func (c *testClaim) Partition() int32 { return c.partition }
+
+func (c *testClaim) InitialOffset() int64 { return 0 }
+
+func (c *testClaim) HighWaterMarkOffset() int64 { return int64(cap(c.msgs)) }
+
+func (c *testClaim) Messages() <-chan *sarama.ConsumerMessage { return c.msgs }
+
+func newTestHandler(t *testing.T, cb func(Message) error, opts ...CallOpt) (*groupHandler, *bool) {
+	t.Helper()
+	cli, err := newMockCluster().client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cli.Close)
+
+	cancelled := new(bool)
+	return &groupHandler{cli: cli, o: getCallOpts(opts), cb: cb, cancel: func() { *cancelled = true }}, cancelled
+}
+
+func TestGroupConsumeClaim(t *testing.T) {
+	var got []string
+	h, cancelled := newTestHandler(t, func(m Message) error {
+		got = append(got, string(m.Value))
+		return nil
+	}, CommitMetadata("v1"))
+
+	s := &testSession{ctx: context.Background()}
+	if err := h.ConsumeClaim(s, newTestClaim("t", 2, "a", "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if want := []string{"a@v1", "b@v1"}; !reflect.DeepEqual(s.marked, want) {
+		t.Errorf("marked %v, want %v", s.marked, want)
+	}
+	if *cancelled {
+		t.Error("the group was cancelled")
+	}
+}
+
+func TestGroupConsumeClaimError(t *testing.T) {
+	h, cancelled := newTestHandler(t, func(m Message) error {
+		if string(m.Value) == "b" {
+			return errBoom
+		}
+		return nil
+	})
+
+	s := &testSession{ctx: context.Background()}
+	if err := h.ConsumeClaim(s, newTestClaim("t", 0, "a", "b", "c")); err != errBoom {
+		t.Fatalf("got %v, want %v", err, errBoom)
+	}
+
+	// the failed message isn't committed so it is read again
+	if want := []string{"a@"}; !reflect.DeepEqual(s.marked, want) {
+		t.Errorf("marked %v, want %v", s.marked, want)
+	}
+	if !*cancelled || h.error() != errBoom {
+		t.Errorf("got cancelled %v and error %v, want the group stopped with %v", *cancelled, h.error(), errBoom)
+	}
+}
+
+func TestGroupSetup(t *testing.T) {
+	var got map[string][]int32
+	h, _ := newTestHandler(t, nil, OnRebalance(func(claims map[string][]int32) { got = claims }))
+
+	claims := map[string][]int32{"t": {0, 2}}
+	if err := h.Setup(&testSession{ctx: context.Background(), claims: claims}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, claims) {
+		t.Errorf("got %v, want %v", got, claims)
+	}
+}
+
+func TestGroupWatchErrors(t *testing.T) {
+	h, cancelled := newTestHandler(t, nil)
+
+	errs := make(chan error, 1)
+	errs <- &sarama.ConsumerError{Topic: "t", Partition: 0, Err: sarama.ErrNotLeaderForPartition}
+	close(errs)
+	h.watchErrors(errs)
+	if *cancelled || h.error() != nil {
+		t.Fatalf("a retriable error stopped the group: %v", h.error())
+	}
+
+	errs = make(chan error, 1)
+	errs <- &sarama.ConsumerError{Topic: "t", Partition: 0, Err: errBoom}
+	close(errs)
+	h.watchErrors(errs)
+	if !*cancelled || !errors.Is(h.error(), errBoom) {
+		t.Errorf("got cancelled %v and error %v, want the group stopped with %v", *cancelled, h.error(), errBoom)
+	}
+}
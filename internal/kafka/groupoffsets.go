@@ -0,0 +1,184 @@
+package kafka
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+// GroupOffsets holds the committed offsets of a consumer group by topic
+// and partition.  It is JSON serializable so that it can be saved and
+// applied to another group (or cluster) later.
+type GroupOffsets map[string]map[int32]GroupOffset
+
+// ApplyReport describes what ApplyGroupOffsets changed (or would change,
+// for a dry run).
+type ApplyReport struct {
+	DryRun  bool             `json:"dry_run"`
+	Changes []OffsetChange   `json:"changes"`
+	Missing []TopicPartition `json:"missing,omitempty"`
+}
+
+// OffsetChange is the old and new offset of one partition.  Old is -1 if
+// the group had no committed offset.
+type OffsetChange struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Old       int64  `json:"old"`
+	New       int64  `json:"new"`
+	Metadata  string `json:"metadata,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// TopicPartition identifies a single partition
+type TopicPartition struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+}
+
+// DumpGroupOffsets returns all of the committed offsets of group.
+func (c *Client) DumpGroupOffsets(group string) (GroupOffsets, error) {
+	admin, err := c.newAdmin()
+	if err != nil {
+		return nil, err
+	}
+	defer admin.Close()
+
+	resp, err := admin.ListConsumerGroupOffsets(group, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Err != sarama.ErrNoError {
+		return nil, resp.Err
+	}
+
+	out := GroupOffsets{}
+	for topic, parts := range resp.Blocks {
+		for p, b := range parts {
+			if b.Err != sarama.ErrNoError {
+				return nil, b.Err
+			}
+			if b.Offset < 0 {
+				continue
+			}
+			if out[topic] == nil {
+				out[topic] = map[int32]GroupOffset{}
+			}
+			out[topic][p] = GroupOffset{Offset: b.Offset, Metadata: b.Metadata}
+		}
+	}
+
+	return out, nil
+}
+
+// SearchGroupMetadata returns the committed offsets of group whose
+// metadata contains s.
+func (c *Client) SearchGroupMetadata(group, s string) (GroupOffsets, error) {
+	offsets, err := c.DumpGroupOffsets(group)
+	if err != nil {
+		return nil, err
+	}
+
+	out := GroupOffsets{}
+	for topic, parts := range offsets {
+		for p, o := range parts {
+			if !strings.Contains(o.Metadata, s) {
+				continue
+			}
+			if out[topic] == nil {
+				out[topic] = map[int32]GroupOffset{}
+			}
+			out[topic][p] = o
+		}
+	}
+
+	return out, nil
+}
+
+// ApplyGroupOffsets commits o for group.  With dryRun nothing is
+// committed and the report shows what would have changed.  Partitions in
+// o that don't exist on this cluster are reported as missing rather than
+// committed.  It requires AllowDestructive, even for a dry run.
+func (c *Client) ApplyGroupOffsets(group string, o GroupOffsets, dryRun bool) (ApplyReport, error) {
+	report := ApplyReport{DryRun: dryRun}
+	if err := c.checkDestructive(); err != nil {
+		return report, err
+	}
+
+	current, err := c.DumpGroupOffsets(group)
+	if err != nil {
+		return report, err
+	}
+
+	apply := map[string]map[int32]GroupOffset{}
+	for _, topic := range sortedTopics(o) {
+		existing := map[int32]bool{}
+		parts, err := c.sarama.Partitions(topic)
+		if err != nil && err != sarama.ErrUnknownTopicOrPartition {
+			return report, err
+		}
+		for _, p := range parts {
+			existing[p] = true
+		}
+
+		for _, p := range sortedPartitions(o[topic]) {
+			if !existing[p] {
+				report.Missing = append(report.Missing, TopicPartition{Topic: topic, Partition: p})
+				continue
+			}
+
+			old := int64(-1)
+			if cur, ok := current[topic][p]; ok {
+				old = cur.Offset
+			}
+
+			off := o[topic][p]
+			report.Changes = append(report.Changes, OffsetChange{Topic: topic, Partition: p, Old: old, New: off.Offset, Metadata: off.Metadata})
+			if apply[topic] == nil {
+				apply[topic] = map[int32]GroupOffset{}
+			}
+			apply[topic][p] = off
+		}
+	}
+
+	if dryRun || len(apply) == 0 {
+		return report, nil
+	}
+
+	if err := c.checkGroupInactive(group); err != nil {
+		return report, err
+	}
+
+	errs, err := c.commitOffsets(group, apply)
+	if err != nil {
+		return report, err
+	}
+
+	for i, ch := range report.Changes {
+		if err := errs[ch.Topic][ch.Partition]; err != nil {
+			report.Changes[i].Error = err.Error()
+		}
+	}
+
+	return report, nil
+}
+
+func sortedTopics(o GroupOffsets) []string {
+	out := make([]string, 0, len(o))
+	for t := range o {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func sortedPartitions(m map[int32]GroupOffset) []int32 {
+	out := make([]int32, 0, len(m))
+	for p := range m {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
@@ -0,0 +1,91 @@
+package kafka
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+// newBrokerTestClient returns a Client that reads partitions from a
+// mockCluster and sends admin requests to a sarama MockBroker that
+// answers them with handlers (plus metadata and the group coordinator).
+func newBrokerTestClient(t *testing.T, handlers map[string]sarama.MockResponse, opts ...Opt) (*mockCluster, *Client) {
+	t.Helper()
+	mb := sarama.NewMockBroker(t, 1)
+	t.Cleanup(mb.Close)
+
+	all := map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(mb.Addr(), mb.BrokerID()).
+			SetController(mb.BrokerID()),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, "g", mb),
+	}
+	for k, v := range handlers {
+		all[k] = v
+	}
+	mb.SetHandlerByMap(all)
+
+	m := newMockCluster()
+	cli, err := New([]string{mb.Addr()}, append(opts, withSaramaClient(m, func(*sarama.Config) (sarama.Consumer, error) { return m, nil }))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cli.Close)
+	return m, cli
+}
+
+func TestSearchGroupMetadata(t *testing.T) {
+	_, cli := newBrokerTestClient(t, map[string]sarama.MockResponse{
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("g", "t", 0, 10, "consumer v1.2", sarama.ErrNoError).
+			SetOffset("g", "t", 1, 20, "consumer v1.3", sarama.ErrNoError).
+			SetOffset("g", "u", 0, 5, "consumer v1.2", sarama.ErrNoError).
+			SetOffset("g", "u", 1, -1, "", sarama.ErrNoError),
+	})
+
+	all, err := cli.DumpGroupOffsets("g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := GroupOffsets{
+		"t": {0: {Offset: 10, Metadata: "consumer v1.2"}, 1: {Offset: 20, Metadata: "consumer v1.3"}},
+		"u": {0: {Offset: 5, Metadata: "consumer v1.2"}},
+	}
+	if !reflect.DeepEqual(all, want) {
+		t.Errorf("got %+v, want %+v", all, want)
+	}
+
+	got, err := cli.SearchGroupMetadata("g", "v1.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = GroupOffsets{
+		"t": {0: {Offset: 10, Metadata: "consumer v1.2"}},
+		"u": {0: {Offset: 5, Metadata: "consumer v1.2"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestGroupLagMetadata(t *testing.T) {
+	m, cli := newBrokerTestClient(t, map[string]sarama.MockResponse{
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("g", "t", 0, 1, "v1", sarama.ErrNoError),
+	})
+	for i := 0; i < 4; i++ {
+		m.produce("t", 0, nil, []byte("x"))
+	}
+
+	lag, err := cli.GetGroupLag("g")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := GroupLag{Group: "g", Lag: 3, Partitions: []PartitionLag{{Topic: "t", Partition: 0, End: 4, Offset: 1, Lag: 3, Metadata: "v1"}}}
+	if !reflect.DeepEqual(lag, want) {
+		t.Errorf("got %+v, want %+v", lag, want)
+	}
+}
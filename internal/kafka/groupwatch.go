@@ -0,0 +1,165 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// GroupSnapshot is the state of a consumer group at Time.  Moved lists
+// the partitions whose owner changed since the previous snapshot (From
+// or To is empty when the partition was unassigned).  When polling fails
+// Err is set and the rest of the snapshot is empty.
+type GroupSnapshot struct {
+	Time     time.Time       `json:"time"`
+	Group    string          `json:"group"`
+	State    string          `json:"state"`
+	Protocol string          `json:"protocol"`
+	Members  []GroupMember   `json:"members"`
+	Moved    []PartitionMove `json:"moved,omitempty"`
+	Err      error           `json:"-"`
+}
+
+// GroupMember is a member of a consumer group and the partitions it is
+// assigned
+type GroupMember struct {
+	ID         string             `json:"id"`
+	ClientID   string             `json:"client_id"`
+	Host       string             `json:"host"`
+	Assignment map[string][]int32 `json:"assignment"`
+}
+
+// PartitionMove is a partition that changed owner
+type PartitionMove struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+}
+
+// WatchGroup describes group every interval and calls cb with a snapshot
+// when its state, members or assignments change (and once with the
+// initial snapshot).  Errors are passed to cb as a snapshot with Err set,
+// once until polling succeeds again, and don't stop the watch.  It
+// returns when ctx is cancelled.
+func (c *Client) WatchGroup(ctx context.Context, group string, interval time.Duration, cb func(GroupSnapshot)) error {
+	var admin sarama.ClusterAdmin
+	defer func() {
+		if admin != nil {
+			admin.Close()
+		}
+	}()
+
+	var prev *GroupSnapshot
+	var prevErr string
+	for {
+		snap, err := c.describeGroup(&admin, group)
+		switch {
+		case err != nil:
+			if err.Error() != prevErr {
+				cb(GroupSnapshot{Time: time.Now(), Group: group, Err: err})
+			}
+			prevErr = err.Error()
+		case prev == nil || prevErr != "" || !sameGroup(*prev, snap):
+			if prev != nil {
+				snap.Moved = movedPartitions(*prev, snap)
+			}
+			cb(snap)
+			prev, prevErr = &snap, ""
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// describeGroup reuses *admin, or connects a new one, and drops it if the
+// request fails so the next poll reconnects.
+func (c *Client) describeGroup(admin *sarama.ClusterAdmin, group string) (GroupSnapshot, error) {
+	snap := GroupSnapshot{Time: time.Now(), Group: group}
+	if *admin == nil {
+		a, err := c.newAdmin()
+		if err != nil {
+			return snap, err
+		}
+		*admin = a
+	}
+
+	groups, err := (*admin).DescribeConsumerGroups([]string{group})
+	if err != nil {
+		(*admin).Close()
+		*admin = nil
+		return snap, err
+	}
+
+	if len(groups) != 1 {
+		return snap, fmt.Errorf("describe group %s: got %d groups", group, len(groups))
+	}
+
+	g := groups[0]
+	if g.Err != sarama.ErrNoError {
+		return snap, g.Err
+	}
+
+	snap.State = g.State
+	snap.Protocol = g.Protocol
+	for id, m := range g.Members {
+		member := GroupMember{ID: id, ClientID: m.ClientId, Host: m.ClientHost}
+		if a, err := m.GetMemberAssignment(); err == nil && a != nil {
+			member.Assignment = a.Topics
+			for _, parts := range member.Assignment {
+				sort.Slice(parts, func(i, j int) bool { return parts[i] < parts[j] })
+			}
+		}
+		snap.Members = append(snap.Members, member)
+	}
+	sort.Slice(snap.Members, func(i, j int) bool { return snap.Members[i].ID < snap.Members[j].ID })
+	return snap, nil
+}
+
+func sameGroup(a, b GroupSnapshot) bool {
+	return a.State == b.State && a.Protocol == b.Protocol && reflect.DeepEqual(a.Members, b.Members)
+}
+
+// movedPartitions compares the owners of every partition in a and b
+func movedPartitions(a, b GroupSnapshot) []PartitionMove {
+	before, after := owners(a), owners(b)
+	var out []PartitionMove
+	for tp, to := range after {
+		if from := before[tp]; from != to {
+			out = append(out, PartitionMove{Topic: tp.Topic, Partition: tp.Partition, From: from, To: to})
+		}
+	}
+	for tp, from := range before {
+		if _, ok := after[tp]; !ok {
+			out = append(out, PartitionMove{Topic: tp.Topic, Partition: tp.Partition, From: from})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Topic != out[j].Topic {
+			return out[i].Topic < out[j].Topic
+		}
+		return out[i].Partition < out[j].Partition
+	})
+	return out
+}
+
+func owners(s GroupSnapshot) map[TopicPartition]string {
+	out := map[TopicPartition]string{}
+	for _, m := range s.Members {
+		for topic, parts := range m.Assignment {
+			for _, p := range parts {
+				out[TopicPartition{Topic: topic, Partition: p}] = m.ID
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// defaultPartitionRefresh is how often long running calls re-read the
+// partitions of the topics they are reading
+const defaultPartitionRefresh = 10 * time.Second
+
+// PartitionRefresh sets how often FetchTopic, Watch, WaitFor and
+// ConsumeGroup check for partitions that have been added to the topics
+// they are reading.  0 turns the check off for FetchTopic, Watch and
+// WaitFor (sarama's consumer group always checks).
+func PartitionRefresh(d time.Duration) Opt {
+	return func(c *Client) {
+		c.partitionRefresh = d
+	}
+}
+
+// OnNewPartitions is called with the partitions that were added to topic
+// while a FetchTopic, Watch, WaitFor or ConsumeGroup was running.
+// Added partitions are read from their oldest message (by ConsumeGroup,
+// according to the group's committed offsets).
+func OnNewPartitions(f func(topic string, partitions []int32)) CallOpt {
+	return func(o *callOpts) {
+		o.onNewPartitions = f
+	}
+}
+
+// newPartitions polls the partitions of the topics of parts every
+// c.partitionRefresh until ctx is done and calls found with each one
+// that isn't in parts once it has messages.  Its Offset is the
+// partition's Start and End the high water mark at the time it was found.
+func (c *Client) newPartitions(ctx context.Context, parts []Partition, o callOpts, found func(Partition)) {
+	if c.partitionRefresh <= 0 {
+		return
+	}
+
+	known := map[string]map[int32]bool{}
+	for _, p := range parts {
+		if known[p.Topic] == nil {
+			known[p.Topic] = map[int32]bool{}
+		}
+		known[p.Topic][p.Partition] = true
+	}
+
+	topics := make([]string, 0, len(known))
+	for t := range known {
+		topics = append(topics, t)
+	}
+	sort.Strings(topics)
+
+	t := time.NewTicker(c.partitionRefresh)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return
+		}
+
+		for _, topic := range topics {
+			if err := c.sarama.RefreshMetadata(topic); err != nil {
+				c.logf("refreshing partitions of %s: %s", topic, err)
+				continue
+			}
+
+			partitions, err := c.sarama.Partitions(topic)
+			if err != nil {
+				c.logf("refreshing partitions of %s: %s", topic, err)
+				continue
+			}
+
+			var added []int32
+			for _, p := range partitions {
+				if known[topic][p] {
+					continue
+				}
+
+				start, end, err := c.watermarks(topic, p)
+				if err != nil || end <= start {
+					// try again once it has something to read
+					continue
+				}
+
+				known[topic][p] = true
+				added = append(added, p)
+				found(Partition{Topic: topic, Partition: p, Start: start, End: end, Offset: start})
+			}
+
+			if len(added) > 0 {
+				c.logf("new partitions of %s: %v", topic, added)
+				if o.onNewPartitions != nil {
+					o.onNewPartitions(topic, added)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package kafka
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestFetchTopicNewPartitions(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, nil, []byte("a"))
+
+	cli, err := m.client(PartitionRefresh(10 * time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	// partition 0 waits for a second message, which is produced once
+	// the partition added after the fetch started has been found
+	parts := []Partition{{Topic: "t", Partition: 0, End: 2}}
+	var added []int32
+	var got []string
+	_, err = cli.FetchTopic(context.Background(), parts, 10, func(msg Message) bool {
+		got = append(got, string(msg.Value))
+		if string(msg.Value) == "a" {
+			m.produce("t", 1, nil, []byte("b"))
+		}
+		return false
+	}, OnNewPartitions(func(topic string, partitions []int32) {
+		added = append(added, partitions...)
+		m.produce("t", 0, nil, []byte("c"))
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(got)
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if want := []int32{1}; !reflect.DeepEqual(added, want) {
+		t.Errorf("got new partitions %v, want %v", added, want)
+	}
+}
+
+func TestFetchTopicNoRefresh(t *testing.T) {
+	m := newMockCluster()
+	for p := int32(0); p < 8; p++ {
+		m.produce("t", p, nil, []byte("x"))
+	}
+
+	cli, err := m.client(PartitionRefresh(0), Concurrency(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	parts, err := cli.GetTopic("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// with one worker, partitions finish while others are still waiting
+	// to start, which mustn't end the fetch early
+	for i := 0; i < 10; i++ {
+		stats, err := cli.FetchTopic(context.Background(), parts, 10, func(Message) bool { return false })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Delivered != 8 || len(stats.Partitions) != 8 {
+			t.Fatalf("got %d delivered from %d partitions, want 8 from 8", stats.Delivered, len(stats.Partitions))
+		}
+	}
+}
+
+func TestWatchNewPartitions(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, nil, []byte("old"))
+
+	cli, err := m.client(PartitionRefresh(10 * time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		m.produce("t", 1, nil, []byte("new"))
+	}()
+
+	// partition 0 is tailed from its newest message and the added
+	// partition from its oldest
+	var added []int32
+	var got []string
+	err = cli.Watch(ctx, "t", MatcherFunc(func(Message) bool { return true }), func(msg Message) {
+		got = append(got, string(msg.Value))
+		cancel()
+	}, OnNewPartitions(func(topic string, partitions []int32) {
+		added = append(added, partitions...)
+	}))
+	if err != context.Canceled {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+
+	if want := []string{"new"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if want := []int32{1}; !reflect.DeepEqual(added, want) {
+		t.Errorf("got new partitions %v, want %v", added, want)
+	}
+}
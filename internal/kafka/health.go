@@ -0,0 +1,115 @@
+package kafka
+
+import (
+	"sort"
+
+	"github.com/IBM/sarama"
+)
+
+// HealthReport is a snapshot of the cluster's replication health.  Only
+// topics with problems are included.  MissingBrokers are brokers that
+// are in some partition's replica set but aren't in the cluster metadata
+// (ie they are down).
+type HealthReport struct {
+	Controller     int32         `json:"controller"`
+	Brokers        []int32       `json:"brokers"`
+	MissingBrokers []int32       `json:"missing_brokers,omitempty"`
+	Topics         []TopicHealth `json:"topics,omitempty"`
+}
+
+// OK reports whether the cluster has no problems
+func (h HealthReport) OK() bool {
+	return len(h.MissingBrokers) == 0 && len(h.Topics) == 0
+}
+
+// TopicHealth lists the partitions of a topic that have fewer in sync
+// replicas than replicas and the ones that have no leader.  Error is set
+// if the metadata for the topic itself was an error.
+type TopicHealth struct {
+	Topic           string            `json:"topic"`
+	Error           string            `json:"error,omitempty"`
+	UnderReplicated []PartitionHealth `json:"under_replicated,omitempty"`
+	Offline         []PartitionHealth `json:"offline,omitempty"`
+}
+
+// PartitionHealth is the replica state of a single partition.  Leader is
+// -1 when the partition has no leader.
+type PartitionHealth struct {
+	Partition       int32   `json:"partition"`
+	Leader          int32   `json:"leader"`
+	Replicas        []int32 `json:"replicas"`
+	ISR             []int32 `json:"isr"`
+	OfflineReplicas []int32 `json:"offline_replicas,omitempty"`
+}
+
+// ClusterHealth fetches the metadata of every topic with a single
+// request and reports under replicated partitions, partitions without a
+// leader and brokers that have dropped out of the cluster.
+func (c *Client) ClusterHealth() (HealthReport, error) {
+	b, err := c.sarama.Controller()
+	if err != nil {
+		return HealthReport{}, wrapErr("cluster health", "", -1, -1, err)
+	}
+
+	resp, err := b.GetMetadata(&sarama.MetadataRequest{Version: 5})
+	if err != nil {
+		return HealthReport{}, wrapErr("cluster health", "", -1, -1, err)
+	}
+
+	return healthReport(resp), nil
+}
+
+func healthReport(resp *sarama.MetadataResponse) HealthReport {
+	h := HealthReport{Controller: resp.ControllerID}
+	live := map[int32]bool{}
+	for _, b := range resp.Brokers {
+		live[b.ID()] = true
+		h.Brokers = append(h.Brokers, b.ID())
+	}
+	sort.Slice(h.Brokers, func(i, j int) bool { return h.Brokers[i] < h.Brokers[j] })
+
+	missing := map[int32]bool{}
+	for _, t := range resp.Topics {
+		th := TopicHealth{Topic: t.Name}
+		if t.Err != sarama.ErrNoError {
+			th.Error = t.Err.Error()
+		}
+
+		for _, p := range t.Partitions {
+			for _, r := range p.Replicas {
+				if !live[r] {
+					missing[r] = true
+				}
+			}
+
+			ph := PartitionHealth{
+				Partition:       p.ID,
+				Leader:          p.Leader,
+				Replicas:        p.Replicas,
+				ISR:             p.Isr,
+				OfflineReplicas: p.OfflineReplicas,
+			}
+
+			if p.Leader < 0 || p.Err == sarama.ErrLeaderNotAvailable {
+				th.Offline = append(th.Offline, ph)
+			} else if len(p.Isr) < len(p.Replicas) {
+				th.UnderReplicated = append(th.UnderReplicated, ph)
+			}
+		}
+
+		if th.Error != "" || len(th.Offline) > 0 || len(th.UnderReplicated) > 0 {
+			sort.Slice(th.Offline, func(i, j int) bool { return th.Offline[i].Partition < th.Offline[j].Partition })
+			sort.Slice(th.UnderReplicated, func(i, j int) bool {
+				return th.UnderReplicated[i].Partition < th.UnderReplicated[j].Partition
+			})
+			h.Topics = append(h.Topics, th)
+		}
+	}
+
+	for id := range missing {
+		h.MissingBrokers = append(h.MissingBrokers, id)
+	}
+	sort.Slice(h.MissingBrokers, func(i, j int) bool { return h.MissingBrokers[i] < h.MissingBrokers[j] })
+	sort.Slice(h.Topics, func(i, j int) bool { return h.Topics[i].Topic < h.Topics[j].Topic })
+	return h
+}
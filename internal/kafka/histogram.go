@@ -0,0 +1,105 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Histogram counts messages by timestamp.
+type Histogram struct {
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Buckets []Bucket  `json:"buckets"`
+}
+
+// Bucket is a single time range of a Histogram, Start is inclusive and
+// End is exclusive.
+type Bucket struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Count int64     `json:"count"`
+}
+
+// Counts returns the count of each bucket.
+func (h Histogram) Counts() []int64 {
+	out := make([]int64, len(h.Buckets))
+	for i, b := range h.Buckets {
+		out[i] = b.Count
+	}
+	return out
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the bucket counts as a single line of block characters.
+func (h Histogram) Sparkline() string {
+	var max int64
+	for _, b := range h.Buckets {
+		if b.Count > max {
+			max = b.Count
+		}
+	}
+
+	var sb strings.Builder
+	for _, b := range h.Buckets {
+		i := 0
+		if max > 0 {
+			i = int(b.Count * int64(len(sparks)-1) / max)
+		}
+		sb.WriteRune(sparks[i])
+	}
+	return sb.String()
+}
+
+// TimeHistogram counts the messages of parts whose timestamps fall
+// between from and to in the given number of equally sized buckets.  The
+// offsets-for-times API is used to only read the messages in that range
+// and the messages are never decoded.  The timestamp is whatever the
+// broker returns (CreateTime or LogAppendTime depending on the topic).
+func (c *Client) TimeHistogram(ctx context.Context, parts []Partition, buckets int, from, to time.Time) (Histogram, error) {
+	h := Histogram{From: from, To: to}
+	if buckets <= 0 || !to.After(from) {
+		return h, fmt.Errorf("invalid histogram: need at least one bucket and from before to")
+	}
+
+	width := to.Sub(from) / time.Duration(buckets)
+	if width <= 0 {
+		width = 1
+	}
+
+	h.Buckets = make([]Bucket, buckets)
+	for i := range h.Buckets {
+		h.Buckets[i].Start = from.Add(time.Duration(i) * width)
+		h.Buckets[i].End = from.Add(time.Duration(i+1) * width)
+	}
+	h.Buckets[buckets-1].End = to
+
+	for _, part := range parts {
+		part, err := c.applyRange(part, SearchRange{FromTime: from, ToTime: to})
+		if err != nil {
+			return h, err
+		}
+
+		err = c.consume(ctx, part, part.End-part.Offset, func(msg *sarama.ConsumerMessage) bool {
+			if msg.Timestamp.Before(from) || !msg.Timestamp.Before(to) {
+				return false
+			}
+			i := int(msg.Timestamp.Sub(from) / width)
+			if i >= buckets {
+				i = buckets - 1
+			}
+			h.Buckets[i].Count++
+			return false
+		})
+
+		if err != nil {
+			return h, err
+		}
+	}
+
+	return h, nil
+}
@@ -0,0 +1,53 @@
+package kafka
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+const hllPrecision = 10
+
+// hll is a small HyperLogLog used to estimate the number of distinct keys.
+type hll struct {
+	registers [1 << hllPrecision]uint8
+}
+
+func (h *hll) add(b []byte) {
+	f := fnv.New64a()
+	f.Write(b)
+	x := f.Sum64()
+
+	idx := x >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1))) + 1
+	if rank > h.registers[idx] {
+		h.registers[idx] = rank
+	}
+}
+
+func (h *hll) merge(o *hll) {
+	for i, r := range o.registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+}
+
+func (h *hll) estimate() uint64 {
+	m := float64(len(h.registers))
+	var sum float64
+	var zeros int
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	e := alpha * m * m / sum
+	if e <= 2.5*m && zeros > 0 {
+		e = m * math.Log(m/float64(zeros))
+	}
+	return uint64(e + 0.5)
+}
@@ -0,0 +1,67 @@
+package kafka
+
+import (
+	"context"
+	"sync/atomic"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// MaxInFlightBytes caps the bytes (keys plus values) of the messages
+// that the search and fetch workers are decoding and matching at once,
+// with 0 meaning unlimited.  Workers wait for room instead of holding
+// more, so topics with large messages are read with less parallelism
+// rather than running out of memory.  A message bigger than n takes the
+// whole budget.  The current usage is reported as Stats.InFlightBytes.
+func MaxInFlightBytes(n int64) Opt {
+	return func(c *Client) {
+		if n <= 0 {
+			c.inFlight = nil
+			return
+		}
+		c.inFlight = &inFlight{max: n, sem: semaphore.NewWeighted(n)}
+	}
+}
+
+// inFlight is the Client's budget of message bytes being worked on.  A
+// nil inFlight is unlimited.
+type inFlight struct {
+	max  int64
+	used int64
+	sem  *semaphore.Weighted
+}
+
+// acquire waits until n bytes fit in the budget and returns the amount
+// taken, which must be given back with release.
+func (f *inFlight) acquire(ctx context.Context, n int) (int64, error) {
+	if f == nil {
+		return 0, nil
+	}
+
+	w := int64(n)
+	if w > f.max {
+		w = f.max
+	}
+
+	if err := f.sem.Acquire(ctx, w); err != nil {
+		return 0, err
+	}
+	atomic.AddInt64(&f.used, w)
+	return w, nil
+}
+
+func (f *inFlight) release(w int64) {
+	if f == nil || w == 0 {
+		return
+	}
+	atomic.AddInt64(&f.used, -w)
+	f.sem.Release(w)
+}
+
+// bytes is how much of the budget is taken
+func (f *inFlight) bytes() int64 {
+	if f == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&f.used)
+}
@@ -0,0 +1,98 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInFlight(t *testing.T) {
+	cli := &Client{}
+	MaxInFlightBytes(10)(cli)
+	f := cli.inFlight
+
+	w, err := f.acquire(context.Background(), 6)
+	if err != nil || w != 6 {
+		t.Fatalf("got %d %v, want 6", w, err)
+	}
+	if f.bytes() != 6 {
+		t.Errorf("got %d bytes, want 6", f.bytes())
+	}
+
+	// 6 more don't fit until the first 6 are released
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := f.acquire(ctx, 6); err != context.DeadlineExceeded {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	f.release(w)
+	if f.bytes() != 0 {
+		t.Errorf("got %d bytes, want 0", f.bytes())
+	}
+
+	// a message bigger than the budget takes all of it
+	if w, err := f.acquire(context.Background(), 100); err != nil || w != 10 {
+		t.Errorf("got %d %v, want 10", w, err)
+	}
+}
+
+func TestInFlightUnlimited(t *testing.T) {
+	cli := &Client{}
+	MaxInFlightBytes(10)(cli)
+	MaxInFlightBytes(0)(cli)
+	if cli.inFlight != nil {
+		t.Fatal("MaxInFlightBytes(0) didn't remove the limit")
+	}
+
+	var f *inFlight
+	if w, err := f.acquire(context.Background(), 100); err != nil || w != 0 {
+		t.Errorf("got %d %v, want 0", w, err)
+	}
+	f.release(0)
+	if f.bytes() != 0 {
+		t.Errorf("got %d bytes, want 0", f.bytes())
+	}
+}
+
+func TestFetchTopicInFlight(t *testing.T) {
+	m := newMockCluster()
+	m.createTopic("t", 4)
+	for p := int32(0); p < 4; p++ {
+		m.produce("t", p, nil, []byte("abc"))
+		m.produce("t", p, nil, []byte("a message that is bigger than the budget"))
+	}
+
+	cli, err := m.client(MaxInFlightBytes(8), Concurrency(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	parts, err := cli.GetTopic("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lock sync.Mutex
+	var n int
+	_, err = cli.FetchTopic(context.Background(), parts, 10, func(Message) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		n++
+		if b := cli.inFlight.bytes(); b <= 0 || b > 8 {
+			t.Errorf("got %d bytes in flight, want 1 to 8", b)
+		}
+		return false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 8 {
+		t.Errorf("got %d messages, want 8", n)
+	}
+	if b := cli.inFlight.bytes(); b != 0 {
+		t.Errorf("got %d bytes in flight after the fetch, want 0", b)
+	}
+}
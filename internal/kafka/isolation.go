@@ -0,0 +1,94 @@
+package kafka
+
+import (
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Isolation controls whether records from aborted transactions are
+// returned.
+type Isolation int
+
+const (
+	// ReadUncommitted returns every record, including those written by
+	// transactions that were later aborted.  This is the default.
+	ReadUncommitted Isolation = iota
+
+	// ReadCommitted only returns records from committed transactions
+	// (and non-transactional producers).
+	ReadCommitted
+)
+
+// IsolationLevel sets the isolation level used by all consumers.
+func IsolationLevel(level Isolation) Opt {
+	return func(c *Client) {
+		switch level {
+		case ReadCommitted:
+			c.cfg.Consumer.IsolationLevel = sarama.ReadCommitted
+		default:
+			c.cfg.Consumer.IsolationLevel = sarama.ReadUncommitted
+		}
+	}
+}
+
+// undeliverable reports whether the offsets from from up to to hold
+// nothing but transaction markers and, when reading committed, records of
+// aborted transactions.  Anything it can't fetch counts as deliverable.
+func (c *Client) undeliverable(topic string, partition int32, from, to int64) bool {
+	b, err := c.sarama.Leader(topic, partition)
+	if err != nil {
+		return false
+	}
+
+	for from < to {
+		req := c.replicaFetch()
+		req.Isolation = c.cfg.Consumer.IsolationLevel
+		req.AddBlock(topic, partition, from, c.cfg.Consumer.Fetch.Default, -1)
+
+		start := time.Now()
+		resp, err := b.Fetch(req)
+		c.traced(TraceFetchBatch, b, topic, partition, start, err)
+		if err != nil {
+			return false
+		}
+
+		block := resp.GetBlock(topic, partition)
+		if block == nil || block.Err != sarama.ErrNoError {
+			return false
+		}
+
+		aborted := map[int64]int64{}
+		for _, t := range block.AbortedTransactions {
+			aborted[t.ProducerID] = t.FirstOffset
+		}
+
+		next := from
+		for _, records := range block.RecordsSet {
+			batch := records.RecordBatch
+			if batch == nil {
+				// the old message format has no transactions
+				return false
+			}
+
+			last := batch.FirstOffset + int64(batch.LastOffsetDelta)
+			if last < from || batch.PartialTrailingRecord {
+				continue
+			}
+
+			first, ok := aborted[batch.ProducerID]
+			skipped := batch.Control || (batch.IsTransactional && ok && first <= batch.FirstOffset && req.Isolation == sarama.ReadCommitted)
+			if !skipped {
+				return false
+			}
+			next = last + 1
+		}
+
+		if next == from {
+			// nothing complete came back
+			return false
+		}
+		from = next
+	}
+	return true
+}
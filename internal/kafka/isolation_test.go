@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+func TestIsolationLevel(t *testing.T) {
+	tests := []struct {
+		level Isolation
+		want  sarama.IsolationLevel
+	}{
+		{level: ReadUncommitted, want: sarama.ReadUncommitted},
+		{level: ReadCommitted, want: sarama.ReadCommitted},
+	}
+
+	for _, tt := range tests {
+		cli, err := newMockCluster().client(IsolationLevel(tt.level))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cli.cfg.Consumer.IsolationLevel; got != tt.want {
+			t.Errorf("IsolationLevel(%d) set %d, want %d", tt.level, got, tt.want)
+		}
+		cli.Close()
+	}
+}
+
+func TestFetchStopsAtEndOffset(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, nil, []byte("a"))
+	m.hole("t", 0, 2)
+	m.produce("t", 0, nil, []byte("b"))
+	m.hole("t", 0, 1)
+	m.produce("t", 0, nil, []byte("c"))
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	// the holes leave 3 records in 6 offsets, waiting for 6 would stall
+	start := time.Now()
+	var got []string
+	_, _, err = cli.FetchN(context.Background(), Partition{Topic: "t", Partition: 0, End: 6}, 6, func(m Message) bool {
+		got = append(got, string(m.Value))
+		return false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("the fetch took %s, it should stop at the last offset", d)
+	}
+}
+
+func TestCaughtUp(t *testing.T) {
+	m := newMockCluster()
+	m.createTopic("t", 1)
+
+	pc, err := m.ConsumePartition("t", 0, sarama.OffsetOldest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	if caughtUp(pc, 0) {
+		t.Error("an empty partition is caught up before it has a high water mark")
+	}
+
+	m.produce("t", 0, nil, []byte("a"))
+	m.produce("t", 0, nil, []byte("b"))
+	for next, want := range []bool{false, false, true, true} {
+		if got := caughtUp(pc, int64(next)); got != want {
+			t.Errorf("caughtUp at %d = %v, want %v", next, got, want)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// iterBufferSize bounds how many messages a MessageIter's consumer reads
+// ahead of the caller.
+const iterBufferSize = 8
+
+// MessageIter reads a partition one message at a time.  Values are
+// decoded and Partition.Filter is applied, like GetPartition, but only a
+// small window of messages is held in memory.  Call Close if the iterator
+// is abandoned before Next returns false.  Pause, Resume and Close may be
+// called from another goroutine than Next.
+type MessageIter struct {
+	ctx    context.Context
+	part   Partition
+	limit  int
+	f      func([]byte) bool
+	o      callOpts
+	filter Matcher
+	c      *Client
+
+	consumer sarama.Consumer
+	ap       *activePartition
+	n        int
+	next     int64
+	scanned  int64
+	done     bool
+	err      error
+	deleted  deletionCheck
+
+	// mu guards pc and the state below it.  pc is nil while paused and
+	// changed is closed (and replaced) whenever the state changes.
+	mu      sync.Mutex
+	pc      sarama.PartitionConsumer
+	paused  bool
+	closed  bool
+	changed chan struct{}
+}
+
+// Messages returns an iterator over at most limit messages of part,
+// starting at part.Offset.  limit <= 0 reads up to part.End.  If part.End
+// is 0 then the partition's watermarks are refreshed first.
+func (c *Client) Messages(ctx context.Context, part Partition, limit int, opts ...CallOpt) (*MessageIter, error) {
+	return c.messages(ctx, part, limit, nil, getCallOpts(opts))
+}
+
+// messages is Messages with a predicate on the raw value (see
+// GetPartition).
+func (c *Client) messages(ctx context.Context, part Partition, limit int, f func([]byte) bool, o callOpts) (*MessageIter, error) {
+	filter, err := filterMatcher(part.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if part.End == 0 {
+		if part, err = c.RefreshPartition(part); err != nil {
+			return nil, err
+		}
+	}
+
+	consumer, err := c.newConsumer(func(cfg *sarama.Config) {
+		cfg.ChannelBufferSize = iterBufferSize
+	})
+	if err != nil {
+		return nil, wrapErr("consume", part.Topic, part.Partition, part.Offset, err)
+	}
+
+	pc, part, err := c.consumePartition(consumer, part)
+	if err != nil {
+		consumer.Close()
+		return nil, c.authErr(wrapErr("consume", part.Topic, part.Partition, part.Offset, c.deletedErr(part.Topic, err)))
+	}
+
+	return &MessageIter{
+		ctx:      ctx,
+		part:     part,
+		limit:    limit,
+		f:        f,
+		o:        o,
+		filter:   filter,
+		c:        c,
+		consumer: consumer,
+		pc:       pc,
+		ap:       c.meter.start(part.Topic, part.Partition, part.Offset),
+		next:     part.Offset,
+		done:     part.Offset >= part.End,
+		deleted:  deletionCheck{c: c, topic: part.Topic},
+		changed:  make(chan struct{}),
+	}, nil
+}
+
+// Next returns the next message.  It returns false when the limit or the
+// end of the partition has been reached, or on error (see Err).  While
+// the iterator is paused Next waits for Resume.
+func (it *MessageIter) Next() (Message, bool) {
+	for !it.done && (it.limit <= 0 || it.n < it.limit) {
+		pc, changed, err := it.current()
+		if err != nil {
+			it.fail(err)
+			return Message{}, false
+		}
+
+		if it.done {
+			break
+		}
+
+		if pc == nil {
+			select {
+			case <-changed:
+			case <-it.ctx.Done():
+				it.fail(it.ctx.Err())
+			}
+			continue
+		}
+
+		select {
+		case msg, ok := <-pc.Messages():
+			// a message read ahead before a Pause is read again after the
+			// Resume
+			if !it.live(pc) {
+				continue
+			}
+			if !ok {
+				it.done = true
+				break
+			}
+
+			it.c.meter.record(it.ap, msg)
+			it.next = msg.Offset + 1
+			it.scanned++
+
+			// transaction markers take up offsets without being
+			// delivered so End-1 itself may never show up
+			it.done = msg.Offset >= it.part.End-1
+			if it.f != nil && !it.f(msg.Value) {
+				continue
+			}
+
+			val, ok, err := it.c.decode(it.ctx, it.o, it.part.Topic, msg.Offset, msg.Value)
+			if err != nil {
+				it.fail(wrapErr("decode", it.part.Topic, it.part.Partition, msg.Offset, err))
+				return Message{}, false
+			}
+
+			m := newMessage(it.part, msg)
+			m.Value = val
+			if ok && it.filter.Match(m) {
+				it.n++
+				return m, true
+			}
+		case cerr, ok := <-pc.Errors():
+			if !ok || !it.live(pc) {
+				continue
+			}
+			// sarama recovers from leadership moves on its own
+			if !retriable(cerr.Err) {
+				it.fail(wrapErr("consume", it.part.Topic, it.part.Partition, it.part.Offset, it.c.fetchError(cerr.Err)))
+			}
+		case <-changed:
+		case <-it.ctx.Done():
+			it.fail(it.ctx.Err())
+		case <-time.After(time.Second):
+			it.done = it.c.settled(pc, it.part.Topic, it.part.Partition, it.next)
+			if !it.done {
+				if err := it.deleted.idle(); err != nil {
+					it.fail(wrapErr("consume", it.part.Topic, it.part.Partition, it.next, err))
+				}
+			}
+		}
+	}
+
+	it.Close()
+	return Message{}, false
+}
+
+// Offset returns the offset after the last message that was read from
+// kafka, delivered or not, which is where a following read should start.
+func (it *MessageIter) Offset() int64 {
+	return it.next
+}
+
+// Scanned returns the number of messages that were read from kafka,
+// including the ones that were filtered out.
+func (it *MessageIter) Scanned() int64 {
+	return it.scanned
+}
+
+// Err returns the error, if any, that stopped the iterator.
+func (it *MessageIter) Err() error {
+	return it.err
+}
+
+// Pause stops reading from kafka until Resume is called.  The messages
+// that were read ahead of Next are dropped and read again once the
+// iterator resumes at Offset, so none are skipped or repeated.
+func (it *MessageIter) Pause() {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	if it.paused || it.closed {
+		return
+	}
+
+	it.paused = true
+	if it.pc != nil {
+		it.pc.Close()
+		it.pc = nil
+	}
+	it.signal()
+}
+
+// Resume lets a paused iterator read from kafka again.  The consumer is
+// reopened by the next call to Next.
+func (it *MessageIter) Resume() {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	if !it.paused || it.closed {
+		return
+	}
+
+	it.paused = false
+	it.signal()
+}
+
+// Paused reports whether the iterator is paused.
+func (it *MessageIter) Paused() bool {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	return it.paused
+}
+
+// Close stops the underlying consumer.  It is safe to call more than once.
+func (it *MessageIter) Close() error {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	if it.closed {
+		return nil
+	}
+
+	it.closed = true
+	it.c.meter.stop(it.ap)
+	if it.pc != nil {
+		it.pc.Close()
+		it.pc = nil
+	}
+	it.signal()
+	return it.consumer.Close()
+}
+
+// current returns the partition consumer Next reads from and the channel
+// that is closed when the iterator's state changes.  After a Resume the
+// consumer is reopened at it.next.  It sets it.done once the iterator is
+// closed and returns a nil consumer while it is paused.
+func (it *MessageIter) current() (sarama.PartitionConsumer, <-chan struct{}, error) {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	switch {
+	case it.closed:
+		it.done = true
+	case it.pc == nil && !it.paused:
+		part := it.part
+		part.Offset = it.next
+		pc, part, err := it.c.consumePartition(it.consumer, part)
+		if err != nil {
+			return nil, it.changed, wrapErr("consume", part.Topic, part.Partition, part.Offset, it.c.deletedErr(part.Topic, err))
+		}
+		// part.Offset moves if retention removed it.next while paused
+		it.pc, it.next = pc, part.Offset
+	}
+	return it.pc, it.changed, nil
+}
+
+// live reports whether pc is still the iterator's consumer, ie Pause
+// hasn't closed it.
+func (it *MessageIter) live(pc sarama.PartitionConsumer) bool {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	return it.pc == pc
+}
+
+// signal wakes a Next that is waiting on the old state.  mu must be held.
+func (it *MessageIter) signal() {
+	close(it.changed)
+	it.changed = make(chan struct{})
+}
+
+func (it *MessageIter) fail(err error) {
+	it.err = it.c.authErr(err)
+	it.Close()
+}
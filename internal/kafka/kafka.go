@@ -1,20 +1,25 @@
 package kafka
 
 import (
+	"context"
 	"crypto/sha256"
 	"crypto/sha512"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"hash"
 	"io/ioutil"
+	"log"
 	"os"
 	"sort"
-	"strings"
+	"sync"
 	"time"
 
-	"github.com/Shopify/sarama"
+	"github.com/IBM/sarama"
 	"github.com/xdg/scram"
+	"golang.org/x/net/proxy"
 )
 
 // Decoder is the interface that is required of plugins
@@ -22,6 +27,14 @@ type Decoder interface {
 	Decode(topic string, data []byte) ([]byte, error)
 }
 
+// ContextDecoder is a Decoder that may block (ie to fetch a schema).  It
+// is given the context of the call that is decoding so that cancelling
+// the call doesn't leave it waiting.
+type ContextDecoder interface {
+	Decoder
+	DecodeContext(ctx context.Context, topic string, data []byte) ([]byte, error)
+}
+
 // plainDecoder is the default Decoder
 type plainDecoder struct{}
 
@@ -29,13 +42,44 @@ func (p plainDecoder) Decode(topic string, data []byte) ([]byte, error) { return
 
 // Client fetches from kafka
 type Client struct {
-	addrs       []string
-	sarama      sarama.Client
-	decoder     Decoder
-	concurrency int
+	addrsMu      sync.Mutex
+	addrs        []string
+	cfg          *sarama.Config
+	sarama       saramaClient
+	newConsumerF func(*sarama.Config) (sarama.Consumer, error)
+	decoder      Decoder
+	decodePolicy DecodePolicy
+	concurrency  int
+	keyHash      HashStrategy
+	destructive  bool
+	clampOffsets bool
+	retries      int
+	retryBackoff time.Duration
+	onRetry      func(Partition, int, error)
+	logger       *log.Logger
+	versionErr   error
+	proxy        proxy.ContextDialer
+	proxyErr     error
+	limiter      *rateLimiter
+	inFlight     *inFlight
+	rendering    Rendering
+	meter        *meter
+	trace        func(TraceEvent)
+	annotators   []TopicAnnotator
+	avroEncoder  AvroEncoder
+	prefs        preferences
+
+	expectClusterID string
+	startFrom       *OffsetSpec
+
+	partitionRefresh time.Duration
 }
 
-// Partition holds information about a kafka partition
+// Partition holds information about a kafka partition.  When Filter is
+// not empty, GetPartition and Fetch only deliver messages whose decoded
+// value contains it (or, if it is of the form /expr/, matches the regular
+// expression expr).  Offsets still advance past the messages that are
+// filtered out and page sizes count only delivered messages.
 type Partition struct {
 	Topic     string `json:"topic"`
 	Partition int32  `json:"partition"`
@@ -43,6 +87,9 @@ type Partition struct {
 	End       int64  `json:"end"`
 	Offset    int64  `json:"offset"`
 	Filter    string `json:"filter"`
+
+	// Clamped is set when Offset was moved to keep it between Start and End
+	Clamped bool `json:"clamped,omitempty"`
 }
 
 // String turns a partition into a string
@@ -54,39 +101,164 @@ func (p *Partition) String() string {
 // Message holds information about a single kafka message
 type Message struct {
 	Partition Partition `json:"partition"`
+	Key       []byte    `json:"key,omitempty"`
 	Value     []byte    `json:"msg"`
 	Offset    int64     `json:"offset"`
+	Headers   []Header  `json:"headers,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+
+	// Size is the length of the key plus the value as stored in kafka
+	// (before decoding).
+	Size int `json:"size"`
+
+	// Target marks the message FetchAround was asked for.
+	Target bool `json:"target,omitempty"`
+}
+
+// Header is a single kafka record header
+type Header struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+func newMessage(part Partition, msg *sarama.ConsumerMessage) Message {
+	var headers []Header
+	for _, h := range msg.Headers {
+		headers = append(headers, Header{Key: string(h.Key), Value: h.Value})
+	}
+
+	return Message{
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Offset:    msg.Offset,
+		Headers:   headers,
+		Timestamp: msg.Timestamp,
+		Size:      len(msg.Key) + len(msg.Value),
+		Partition: Partition{
+			Offset:    msg.Offset,
+			Partition: msg.Partition,
+			Topic:     msg.Topic,
+			End:       part.End,
+			Clamped:   part.Clamped,
+		},
+	}
 }
 
 // Opt is a func that sets an  attribute on Client
 type Opt func(*Client)
 
-// New returns a kafka Client.
+// New returns a kafka Client.  An address may start with tls:// or
+// plaintext:// to connect to that broker with or without TLS regardless
+// of the TLS config (for clusters part way through moving to TLS).
 func New(addrs []string, opts ...Opt) (*Client, error) {
 	cfg, err := getConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	s, err := sarama.NewClient(addrs, cfg)
+	addrs, hints, err := parseAddrs(addrs)
 	if err != nil {
 		return nil, err
 	}
 
 	cli := &Client{
-		sarama:      s,
-		addrs:       addrs,
-		decoder:     &plainDecoder{},
-		concurrency: 20,
+		cfg:          cfg,
+		addrs:        addrs,
+		decoder:      &plainDecoder{},
+		concurrency:  20,
+		retries:      defaultRetries,
+		retryBackoff: defaultRetryBackoff,
+
+		partitionRefresh: defaultPartitionRefresh,
 	}
 
 	for _, opt := range opts {
 		opt(cli)
 	}
 
+	if err := cli.checkConfig(); err != nil {
+		return nil, err
+	}
+
+	useDialer(cfg, hints, cli.proxy)
+
+	if err := cli.prefs.load(); err != nil {
+		return nil, fmt.Errorf("load preferences: %w", err)
+	}
+
+	if cli.sarama == nil {
+		cli.sarama, err = sarama.NewClient(addrs, cfg)
+		if err != nil {
+			return nil, wrapErr("connect", "", -1, -1, err)
+		}
+	}
+
+	if cli.trace != nil {
+		cli.sarama = &tracingClient{saramaClient: cli.sarama, hook: cli.trace}
+	}
+
+	if err := cli.checkClusterID(); err != nil {
+		cli.sarama.Close()
+		return nil, err
+	}
+
+	cli.learnAddrs()
+
+	if cli.meter != nil {
+		cli.meter.inFlight = cli.inFlight
+	}
+	cli.meter.run()
 	return cli, nil
 }
 
+// CallOpt is a func that sets an attribute on a single call to the Client
+type CallOpt func(*callOpts)
+
+type callOpts struct {
+	rng     SearchRange
+	target  SearchTarget
+	header  string
+	matcher Matcher
+	raw     bool
+	decoder Decoder
+
+	sampleEvery int
+	sampleRate  float64
+	sampleSeed  int64
+
+	keyPrefix []byte
+	maxKeys   int
+
+	fromOldest     bool
+	onRebalance    func(map[string][]int32)
+	commitMetadata string
+
+	// scanned is called by searchAll with the offset of each message it
+	// has looked at
+	scanned func(int64)
+
+	dedup         bool
+	skipCorrupt   bool
+	maxPositions  int
+	indexMaxBytes int64
+
+	// startOffsets are where WaitFor starts reading each partition
+	startOffsets map[int32]int64
+
+	progress func(Progress)
+	prog     *progress
+
+	onNewPartitions func(topic string, partitions []int32)
+}
+
+func getCallOpts(opts []CallOpt) callOpts {
+	var o callOpts
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
 // Concurrency is used to set the size of the search worker pool
 func Concurrency(j int) func(*Client) {
 	return func(c *Client) {
@@ -131,6 +303,13 @@ func (x *XDGSCRAMClient) Done() bool {
 func getConfig() (*sarama.Config, error) {
 	cfg := sarama.NewConfig()
 
+	// headers and timestamps are only returned by brokers that speak 0.11+
+	cfg.Version = sarama.V1_0_0_0
+	cfg.Producer.Return.Successes = true
+	// every consume loop reads Errors, without it they are only logged
+	cfg.Consumer.Return.Errors = true
+	cfg.ClientID = defaultClientID()
+
 	cfg.Net.SASL.User = os.Getenv("KCLI_USERNAME")
 	if cfg.Net.SASL.User != "" {
 		cfg.Net.SASL.Enable = true
@@ -182,28 +361,61 @@ func getTLSConfig() (*tls.Config, error) {
 
 // GetTopics gets topics (duh)
 func (c *Client) GetTopics() ([]string, error) {
-	return c.sarama.Topics()
+	topics, err := c.sarama.Topics()
+	return topics, wrapErr("get topics", "", -1, -1, err)
 }
 
-// GetTopic gets a single kafka topic
+// GetTopic gets a single kafka topic.  Each partition's Offset is its
+// Start unless the topic's TopicDefaults or StartFrom say otherwise, and
+// its Filter is the one in the TopicDefaults.
 func (c *Client) GetTopic(topic string) ([]Partition, error) {
-	partitions, err := c.sarama.Partitions(topic)
+	out, err := c.topicPartitions(topic)
 	if err != nil {
 		return nil, err
 	}
 
-	out := make([]Partition, len(partitions))
+	d, _ := c.prefs.get(topic)
+	if d.Start == nil {
+		d.Start = c.startFrom
+	}
 
-	for i, p := range partitions {
-		n, err := c.sarama.GetOffset(topic, p, sarama.OffsetNewest)
-		if err != nil {
+	for i := range out {
+		out[i].Filter = d.Filter
+		if d.Start == nil {
+			continue
+		}
+
+		// a remembered offset that retention has removed starts at the
+		// oldest message
+		off, err := c.resolveSpec(out[i], *d.Start)
+		switch {
+		case errors.Is(err, ErrOffsetOutOfRange):
+			out[i].Clamped = true
+		case err != nil:
 			return nil, err
+		default:
+			out[i].Offset = off
 		}
+	}
+	return out, nil
+}
 
-		o, err := c.sarama.GetOffset(topic, p, sarama.OffsetOldest)
+// topicPartitions gets the partitions of topic with their Offset at
+// Start.  Calls that read or count whole partitions use it rather than
+// GetTopic so that the TopicDefaults, which are for browsing, don't apply.
+func (c *Client) topicPartitions(topic string) ([]Partition, error) {
+	partitions, err := c.sarama.Partitions(topic)
+	if err != nil {
+		return nil, c.authErr(wrapErr("get partitions", topic, -1, -1, err))
+	}
+
+	out := make([]Partition, len(partitions))
+	for i, p := range partitions {
+		o, n, err := c.watermarks(topic, p)
 		if err != nil {
 			return nil, err
 		}
+
 		out[i] = Partition{
 			Topic:     topic,
 			Partition: p,
@@ -215,174 +427,508 @@ func (c *Client) GetTopic(topic string) ([]Partition, error) {
 	return out, nil
 }
 
-// GetPartition fetches a kafka partition.  It includes a callback func
-// so that the caller can tell it when to stop consuming.
-func (c *Client) GetPartition(part Partition, end int, f func([]byte) bool) ([]Message, error) {
-	consumer, err := sarama.NewConsumer(c.addrs, nil)
+// watermarks returns the oldest and newest offsets of a partition
+func (c *Client) watermarks(topic string, partition int32) (int64, int64, error) {
+	o, err := c.sarama.GetOffset(topic, partition, sarama.OffsetOldest)
 	if err != nil {
-		return nil, err
+		return 0, 0, c.authErr(wrapErr("get offsets", topic, partition, -1, c.deletedErr(topic, err)))
 	}
 
-	pc, err := consumer.ConsumePartition(part.Topic, part.Partition, part.Offset)
+	n, err := c.sarama.GetOffset(topic, partition, sarama.OffsetNewest)
 	if err != nil {
-		return nil, err
+		return 0, 0, c.authErr(wrapErr("get offsets", topic, partition, -1, err))
 	}
 
-	defer func() {
-		consumer.Close()
-		pc.Close()
-	}()
+	return o, n, nil
+}
+
+// GetPartition fetches a kafka partition.  It includes a callback func
+// so that the caller can tell it when to stop consuming.  If part.End is
+// 0 then the partition's watermarks are refreshed first.
+func (c *Client) GetPartition(part Partition, end int, f func([]byte) bool, opts ...CallOpt) ([]Message, error) {
+	out, _, err := c.GetPage(part, end, f, opts...)
+	return out, err
+}
+
+// PageInfo describes where a page of GetPage left off.  NextOffset is the
+// offset after the last message read from kafka (which may have been
+// filtered out), Scanned is the number of messages read, and HasMore is
+// whether the partition's current high water mark is past NextOffset.
+type PageInfo struct {
+	NextOffset int64 `json:"next_offset"`
+	HasMore    bool  `json:"has_more"`
+	Scanned    int64 `json:"scanned"`
+}
+
+// GetPage is GetPartition plus where the page ended.  Start the next page
+// at NextOffset.
+func (c *Client) GetPage(part Partition, end int, f func([]byte) bool, opts ...CallOpt) ([]Message, PageInfo, error) {
+	it, err := c.messages(context.Background(), part, end, f, getCallOpts(opts))
+	if err != nil {
+		return nil, PageInfo{}, err
+	}
 
 	var out []Message
+	for msg, ok := it.Next(); ok; msg, ok = it.Next() {
+		out = append(out, msg)
+	}
 
-	var msg *sarama.ConsumerMessage
-	var i int
-	var last bool
-	for i < end && !last {
-		select {
-		case msg = <-pc.Messages():
-			if f(msg.Value) {
-				val, err := c.decoder.Decode(part.Topic, msg.Value)
-				if err != nil {
-					return nil, err
-				}
+	if err := it.Err(); err != nil {
+		return nil, PageInfo{}, err
+	}
 
-				out = append(out, Message{
-					Value:  val,
-					Offset: msg.Offset,
-					Partition: Partition{
-						Offset:    msg.Offset,
-						Partition: msg.Partition,
-						Topic:     msg.Topic,
-						End:       part.End,
-					},
-				})
-				i++
-			}
-			last = msg.Offset == part.End-1
-		case <-time.After(time.Second):
-			break
-		}
+	info := PageInfo{NextOffset: it.Offset(), Scanned: it.Scanned()}
+	hwm, err := c.sarama.GetOffset(part.Topic, part.Partition, sarama.OffsetNewest)
+	if err != nil {
+		return out, info, wrapErr("get offsets", part.Topic, part.Partition, -1, c.deletedErr(part.Topic, err))
 	}
+	info.HasMore = info.NextOffset < hwm
 
-	return out, nil
+	return out, info, nil
+}
+
+// caughtUp reports whether a partition consumer that has gone quiet has
+// been handed everything up to the high water mark of its last fetch.
+// next is the offset after the last message it delivered.
+func caughtUp(pc sarama.PartitionConsumer, next int64) bool {
+	hwm := pc.HighWaterMarkOffset()
+	return hwm > 0 && next >= hwm
+}
+
+// settled is caughtUp for partitions that end in transaction markers,
+// which take up offsets without being delivered: a consumer stuck short
+// of the high water mark is done if the leader holds nothing it would
+// deliver between next and the mark.
+func (c *Client) settled(pc sarama.PartitionConsumer, topic string, partition int32, next int64) bool {
+	if caughtUp(pc, next) {
+		return true
+	}
+	hwm := pc.HighWaterMarkOffset()
+	return hwm > 0 && c.undeliverable(topic, partition, next, hwm)
 }
 
 // Close disconnects from kafka
 func (c *Client) Close() {
+	c.meter.close()
 	c.sarama.Close()
 }
 
-type searchResult struct {
-	partition Partition
-	offset    int64
-	error     error
+// SearchFailure records a partition that could not be searched.
+type SearchFailure struct {
+	Partition Partition
+	Err       error
+}
+
+func (s SearchFailure) Error() string {
+	return fmt.Sprintf("search of %s partition %d failed: %s", s.Partition.Topic, s.Partition.Partition, s.Err)
 }
 
+// Unwrap returns the underlying error
+func (s SearchFailure) Unwrap() error { return s.Err }
+
 // SearchTopic allows the caller to search across all partitions in a topic.
-func (c *Client) SearchTopic(partitions []Partition, s string, firstResult bool, cb func(int64, int64)) ([]Partition, error) {
-	ch := make(chan searchResult)
-	in := make(chan Partition)
-	n := int64(len(partitions))
-	var stop bool
-	f := func() bool {
-		return stop
+// cb, which may be nil, is called periodically with the number of
+// messages scanned so far across all partitions and the total number of
+// messages to scan (it is a shorthand for WithProgress).  The results are
+// sorted by topic, partition and offset.  Partitions that could not be
+// searched don't stop the search: the results of the rest are returned
+// with a *PartialError that has the error of each one that failed.
+func (c *Client) SearchTopic(partitions []Partition, s string, firstResult bool, cb func(int64, int64), opts ...CallOpt) ([]Partition, error) {
+	o, done := getCallOpts(append(opts, progressFunc(cb))).withProgress(totalMessages(partitions))
+	defer done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, errs := c.searchTopicStream(ctx, partitions, []byte(s), o)
+
+	var results []Partition
+	var partial PartialError
+	for ch != nil {
+		select {
+		case p, ok := <-ch:
+			if !ok {
+				ch = nil
+				break
+			}
+			results = append(results, p)
+			if firstResult {
+				ch = nil
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				break
+			}
+			f := err.(SearchFailure)
+			partial.add(f.Partition, f.Err)
+		}
+	}
+
+	if !firstResult && errs != nil {
+		for err := range errs {
+			f := err.(SearchFailure)
+			partial.add(f.Partition, f.Err)
+		}
+	}
+
+	sortPartitions(results)
+	return results, partial.orNil()
+}
+
+// sortPartitions sorts by topic, then partition, then offset
+func sortPartitions(parts []Partition) {
+	sort.Slice(parts, func(i, j int) bool {
+		a, b := parts[i], parts[j]
+		if a.Topic != b.Topic {
+			return a.Topic < b.Topic
+		}
+		if a.Partition != b.Partition {
+			return a.Partition < b.Partition
+		}
+		return a.Offset < b.Offset
+	})
+}
+
+// SearchTopicStream searches all of parts concurrently and sends each
+// partition that contains s, with its Offset set to the first match, on
+// the returned channel as soon as it is found.  The channel is closed
+// once every partition has been searched or ctx is cancelled.  Errors for
+// individual partitions are sent, as a SearchFailure, on the error channel,
+// which is buffered so that it never blocks the search.
+func (c *Client) SearchTopicStream(ctx context.Context, parts []Partition, s string, opts ...CallOpt) (<-chan Partition, <-chan error) {
+	return c.searchTopicStream(ctx, parts, []byte(s), getCallOpts(opts))
+}
+
+// totalMessages returns the number of messages from each partition's
+// Offset to its End
+func totalMessages(parts []Partition) int64 {
+	var total int64
+	for _, p := range parts {
+		total += p.End - p.Offset
 	}
+	return total
+}
+
+func (c *Client) searchTopicStream(ctx context.Context, parts []Partition, needle []byte, o callOpts) (<-chan Partition, <-chan error) {
+	out := make(chan Partition)
+	errs := make(chan error, len(parts))
+	in := make(chan Partition)
+
+	go func() {
+		defer close(in)
+		for _, p := range parts {
+			select {
+			case in <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
 
+	// gone holds the topics that were deleted during the search, their
+	// remaining partitions fail without being read
+	var gone sync.Map
+	var wg sync.WaitGroup
 	for i := 0; i < c.concurrency; i++ {
-		go func(in chan Partition, out chan searchResult) {
+		wg.Add(1)
+		go func(worker int) {
+			c.logf("search worker %d started", worker)
+			defer func() {
+				c.logf("search worker %d stopped", worker)
+				wg.Done()
+			}()
 			for partition := range in {
-				i, err := c.search(partition, s, f, func(_, _ int64) {})
-				ch <- searchResult{partition: partition, offset: i, error: err}
+				if _, ok := gone.Load(partition.Topic); ok {
+					errs <- SearchFailure{Partition: partition, Err: fmt.Errorf("%w: %s", ErrTopicDeleted, partition.Topic)}
+					continue
+				}
+
+				c.logf("search worker %d searching %s/%d", worker, partition.Topic, partition.Partition)
+				offsets, err := c.searchAll(ctx, partition, needle, 1, o, func(int64) {})
+				if errors.Is(err, ErrTopicDeleted) {
+					gone.Store(partition.Topic, true)
+				}
+				if err != nil {
+					c.logf("search worker %d: %s/%d failed: %s", worker, partition.Topic, partition.Partition, err)
+					if ctx.Err() == nil {
+						errs <- SearchFailure{Partition: partition, Err: err}
+					}
+					continue
+				}
+
+				if len(offsets) == 0 {
+					continue
+				}
+
+				partition.Offset = offsets[0]
+				select {
+				case out <- partition:
+				case <-ctx.Done():
+				}
 			}
-		}(in, ch)
+		}(i)
 	}
 
 	go func() {
-		for _, p := range partitions {
-			in <- p
-		}
+		wg.Wait()
+		close(out)
+		close(errs)
 	}()
 
-	var results []Partition
+	return out, errs
+}
 
-	nResults := len(partitions)
-	if firstResult {
-		nResults = 1
-	}
+func (c *Client) search(info Partition, s string, o callOpts) (int64, error) {
+	o, done := o.withProgress(info.End - info.Offset)
+	defer done()
 
-	var i int64
-	for i = 0; i < int64(len(partitions)); i++ {
-		r := <-ch
-		cb(i, n)
-		if r.error != nil {
-			return nil, r.error
-		}
-		if r.offset > -1 {
-			r.partition.Offset = r.offset
-			results = append(results, r.partition)
-		}
-		if len(results) == nResults {
-			stop = true
-			break
-		}
+	offsets, err := c.searchAll(context.Background(), info, []byte(s), 1, o, func(int64) {})
+	if err != nil || len(offsets) == 0 {
+		return -1, err
 	}
+	return offsets[0], nil
+}
 
-	close(in)
+func (c *Client) searchAll(ctx context.Context, info Partition, needle []byte, max int, o callOpts, found func(int64)) ([]int64, error) {
+	info, err := c.applyRange(info, o.rng)
+	if err != nil {
+		return nil, err
+	}
 
-	sort.Slice(results, func(i, j int) bool {
-		return results[j].Partition >= results[i].Partition
-	})
+	match := o.target.matcher(needle, o.header)
+	decode := o.matcher != nil || o.target == TargetValue || o.target == TargetAny
 
-	return results, nil
-}
+	var out []int64
+	var derr error
+	err = c.consume(ctx, info, info.End, func(msg *sarama.ConsumerMessage) bool {
+		o.prog.scan(msg)
+		if o.scanned != nil {
+			defer o.scanned(msg.Offset)
+		}
 
-func (c *Client) search(info Partition, s string, stop func() bool, cb func(int64, int64)) (int64, error) {
-	n := int64(-1)
-	var i int64
-	err := c.consume(info, info.End, func(d []byte) bool {
-		cb(i, info.End)
-		if strings.Contains(string(d), s) {
-			n = i + info.Offset
+		w, err := c.inFlight.acquire(ctx, len(msg.Key)+len(msg.Value))
+		if err != nil {
+			derr = err
 			return true
 		}
-		i++
-		return stop()
+		defer c.inFlight.release(w)
+
+		if decode {
+			var ok bool
+			msg, ok, derr = c.decodeMessage(ctx, o, msg)
+			if derr != nil || !ok {
+				return derr != nil
+			}
+		}
+
+		if match(msg) && o.matches(info, msg) {
+			out = append(out, msg.Offset)
+			o.prog.match()
+			found(msg.Offset)
+			if max > 0 && len(out) >= max {
+				return true
+			}
+		}
+		return false
 	})
 
-	return n, err
+	if derr != nil {
+		return out, derr
+	}
+
+	return out, err
 }
 
 // Search is for searching for a string in a single kafka partition.
-// It stops at the first match.
-func (c *Client) Search(info Partition, s string, cb func(i, j int64)) (int64, error) {
-	return c.search(info, s, func() bool { return false }, cb)
+// It stops at the first match.  cb, which may be nil, is called
+// periodically with the number of messages scanned so far and the number
+// from info.Offset to info.End (it is a shorthand for WithProgress).
+func (c *Client) Search(info Partition, s string, cb func(i, j int64), opts ...CallOpt) (int64, error) {
+	return c.search(info, s, getCallOpts(append(opts, progressFunc(cb))))
+}
+
+// SearchAll searches a single kafka partition from info.Offset to info.End
+// and returns the offset of every message that contains s.  It stops
+// early once max matches have been found (max <= 0 means no limit).  cb
+// is called with each matching offset as it is found.
+func (c *Client) SearchAll(ctx context.Context, info Partition, s string, max int, cb func(offset int64), opts ...CallOpt) ([]int64, error) {
+	return c.searchAll(ctx, info, []byte(s), max, getCallOpts(opts), cb)
 }
 
 // Fetch gets all messages in a partition up intil the 'end' offset.
-func (c *Client) Fetch(info Partition, end int64, cb func(string)) error {
-	return c.consume(info, end, func(msg []byte) bool {
-		val, err := c.decoder.Decode(info.Topic, msg)
+// When a Matcher is passed, or the partition has a Filter, only the
+// matching messages are sent to cb and end counts the delivered messages.
+func (c *Client) Fetch(info Partition, end int64, cb func(string), opts ...CallOpt) error {
+	_, _, err := c.FetchN(context.Background(), info, end, func(m Message) bool {
+		cb(c.rendering.render(m.Value))
+		return false
+	}, opts...)
+	return err
+}
+
+// FetchN sends up to end decoded messages, starting at info.Offset, to cb.
+// Consuming stops when cb returns true or ctx is cancelled.  It returns
+// the number of messages that were delivered and the offset that a
+// subsequent fetch should start from to continue where this one stopped.
+func (c *Client) FetchN(ctx context.Context, info Partition, end int64, cb func(Message) bool, opts ...CallOpt) (int64, int64, error) {
+	o, done := getCallOpts(opts).withProgress(info.End - info.Offset)
+	defer done()
+
+	st, err := c.fetch(ctx, info, end, o, cb)
+	return st.Delivered, st.Next, err
+}
+
+func (c *Client) fetch(ctx context.Context, info Partition, end int64, o callOpts, cb func(Message) bool) (PartitionFetchStats, error) {
+	st := PartitionFetchStats{Next: info.Offset}
+	filter, err := filterMatcher(info.Filter)
+	if err != nil {
+		return st, err
+	}
+
+	sample := o.sampler(info.Partition)
+
+	var derr error
+	var step int64
+	consumed := func(msg *sarama.ConsumerMessage) bool {
+		o.prog.scan(msg)
+		step = 0
+		st.Next = msg.Offset + 1
+		if !sample() {
+			st.Skipped++
+			return false
+		}
+
+		w, err := c.inFlight.acquire(ctx, len(msg.Key)+len(msg.Value))
 		if err != nil {
+			derr = err
+			st.Next = msg.Offset
 			return true
 		}
-		cb(string(val))
-		return false
-	})
+		defer c.inFlight.release(w)
+
+		var val []byte
+		var ok bool
+		val, ok, derr = c.decode(ctx, o, info.Topic, msg.Offset, msg.Value)
+		if derr != nil {
+			st.Next = msg.Offset
+			return true
+		}
+
+		if !ok {
+			return false
+		}
+
+		m := newMessage(info, msg)
+		m.Value = val
+		if !filter.Match(m) || (o.matcher != nil && !o.matcher.Match(m)) {
+			return false
+		}
+
+		st.Delivered++
+		o.prog.match()
+		return cb(m) || st.Delivered >= end
+	}
+
+	for {
+		err = c.consume(ctx, info, info.End-info.Offset, consumed)
+		if derr != nil {
+			return st, derr
+		}
+
+		if !o.skipCorrupt || !errors.Is(err, ErrCorruptBatch) {
+			return st, err
+		}
+
+		// step is reset by every message that is read, so a batch that
+		// is still corrupt after a skip doubles the stride
+		if step == 0 {
+			st.CorruptBatches++
+			step = 1
+		} else {
+			step *= 2
+		}
+
+		c.logf("skipping corrupt batch of %s/%d at %d: %s", info.Topic, info.Partition, st.Next, err)
+		next := st.Next + step
+		if next > info.End {
+			next = info.End
+		}
+		st.CorruptOffsets += next - st.Next
+		st.Next = next
+		if next >= info.End {
+			return st, nil
+		}
+		info.Offset = next
+	}
+}
+
+// newConsumer returns a consumer with the Client's config, adjusted by f.
+// Its partition consumers report errors on their Errors channel, which
+// must be read.
+func (c *Client) newConsumer(f func(*sarama.Config)) (sarama.Consumer, error) {
+	cfg := *c.cfg
+	f(&cfg)
+	addrs := c.learnAddrs()
+	c.logf("creating consumer for %v", addrs)
+	if c.newConsumerF != nil {
+		return c.newConsumerF(&cfg)
+	}
+	return sarama.NewConsumer(addrs, &cfg)
+}
+
+func (c *Client) newAdmin() (sarama.ClusterAdmin, error) {
+	return sarama.NewClusterAdmin(c.learnAddrs(), c.cfg)
+}
+
+func (c *Client) newProducer(f func(*sarama.Config)) (sarama.SyncProducer, error) {
+	cfg := *c.cfg
+	f(&cfg)
+	return sarama.NewSyncProducer(c.learnAddrs(), &cfg)
+}
+
+func (c *Client) consume(ctx context.Context, info Partition, end int64, cb func(*sarama.ConsumerMessage) bool) error {
+	for attempt := 1; ; attempt++ {
+		n, next, err := c.consumeFrom(ctx, info, end, cb)
+		if err == nil || !retriable(err) || attempt > c.retries {
+			return c.authErr(wrapErr("consume", info.Topic, info.Partition, next, c.deletedErr(info.Topic, err)))
+		}
+
+		end -= n
+		info.Offset = next
+		c.logf("retrying %s/%d from offset %d (attempt %d): %s", info.Topic, info.Partition, info.Offset, attempt, err)
+		if c.onRetry != nil {
+			c.onRetry(info, attempt, err)
+		}
+
+		if err := c.backoff(ctx, info.Topic, attempt); err != nil {
+			return err
+		}
+	}
 }
 
-func (c *Client) consume(info Partition, end int64, cb func([]byte) bool) error {
-	consumer, err := sarama.NewConsumer(c.addrs, nil)
+// consumeFrom does the work of consume with a single partition consumer.
+// It returns the number of messages that were delivered to cb and the
+// offset to resume from.
+func (c *Client) consumeFrom(ctx context.Context, info Partition, end int64, cb func(*sarama.ConsumerMessage) bool) (int64, int64, error) {
+	consumer, err := c.newConsumer(func(*sarama.Config) {})
 	if err != nil {
-		return err
+		c.logf("creating consumer: %s", err)
+		return 0, info.Offset, err
 	}
 
-	pc, err := consumer.ConsumePartition(info.Topic, info.Partition, info.Offset)
+	pc, info, err := c.consumePartition(consumer, info)
 	if err != nil {
-		return err
+		consumer.Close()
+		return 0, info.Offset, err
 	}
 
+	next := info.Offset
+	c.logf("consuming %s/%d from offset %d to %d", info.Topic, info.Partition, info.Offset, info.End)
+	ap := c.meter.start(info.Topic, info.Partition, info.Offset)
 	defer func() {
+		c.meter.stop(ap)
+		c.logf("stopped consuming %s/%d at offset %d", info.Topic, info.Partition, next)
 		consumer.Close()
 		pc.Close()
 	}()
@@ -392,16 +938,56 @@ func (c *Client) consume(info Partition, end int64, cb func([]byte) bool) error
 		end = l
 	}
 
-	for i := int64(0); i < end; i++ {
+	// end counts delivered messages only: compacted topics and
+	// transaction markers leave holes so waiting for a fixed number of
+	// offsets would stall on messages that will never arrive.
+	deleted := deletionCheck{c: c, topic: info.Topic}
+	errs := pc.Errors()
+	var i int64
+	for i < end {
+		// an empty channel means waiting on the next batch from the broker
+		var wait time.Time
+		if c.trace != nil && len(pc.Messages()) == 0 {
+			wait = time.Now()
+		}
+
 		select {
-		case msg := <-pc.Messages():
-			if stop := cb(msg.Value); stop {
-				return nil
+		case msg, ok := <-pc.Messages():
+			if !ok {
+				return i, next, nil
+			}
+			if !wait.IsZero() {
+				c.traced(TraceFetchBatch, nil, info.Topic, info.Partition, wait, nil)
 			}
+			if err := c.limiter.wait(ctx, len(msg.Key)+len(msg.Value)); err != nil {
+				return i, next, err
+			}
+			c.meter.record(ap, msg)
+			i++
+			next = msg.Offset + 1
+			if stop := cb(msg); stop || msg.Offset >= info.End-1 {
+				return i, next, nil
+			}
+		case cerr, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			if !wait.IsZero() {
+				c.traced(TraceFetchBatch, nil, info.Topic, info.Partition, wait, cerr.Err)
+			}
+			return i, next, c.fetchError(cerr.Err)
+		case <-ctx.Done():
+			return i, next, ctx.Err()
 		case <-time.After(time.Second):
-			break
+			if c.settled(pc, info.Topic, info.Partition, next) {
+				return i, next, nil
+			}
+			if err := deleted.idle(); err != nil {
+				return i, next, err
+			}
 		}
 	}
 
-	return nil
+	return i, next, nil
 }
@@ -0,0 +1,417 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+var errBoom = errors.New("boom")
+
+// newTestClient returns a Client backed by a mockCluster that has topic
+// with a partition for each of msgs, partition p holding msgs[p].
+func newTestClient(t *testing.T, topic string, msgs ...[]string) (*mockCluster, *Client) {
+	t.Helper()
+	m := newMockCluster()
+	m.createTopic(topic, len(msgs))
+	for p, vals := range msgs {
+		for _, v := range vals {
+			m.produce(topic, int32(p), nil, []byte(v))
+		}
+	}
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cli.Close)
+	return m, cli
+}
+
+func values(msgs []Message) []string {
+	out := make([]string, len(msgs))
+	for i, m := range msgs {
+		out[i] = string(m.Value)
+	}
+	return out
+}
+
+func TestGetTopic(t *testing.T) {
+	_, cli := newTestClient(t, "t", []string{"a", "b"}, nil, []string{"c"})
+
+	parts, err := cli.GetTopic("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Partition{
+		{Topic: "t", Partition: 0, Start: 0, End: 2, Offset: 0},
+		{Topic: "t", Partition: 1, Start: 0, End: 0, Offset: 0},
+		{Topic: "t", Partition: 2, Start: 0, End: 1, Offset: 0},
+	}
+	if !reflect.DeepEqual(parts, want) {
+		t.Errorf("got %+v, want %+v", parts, want)
+	}
+
+	if _, err := cli.GetTopic("nope"); err == nil {
+		t.Error("expected an error for a topic that doesn't exist")
+	}
+}
+
+func TestGetPartition(t *testing.T) {
+	_, cli := newTestClient(t, "t", []string{"a", "b", "c", "d"})
+
+	tests := []struct {
+		name   string
+		offset int64
+		end    int
+		f      func([]byte) bool
+		want   []string
+	}{
+		{name: "all", end: 10, want: []string{"a", "b", "c", "d"}},
+		{name: "from offset", offset: 2, end: 10, want: []string{"c", "d"}},
+		{name: "limit", end: 2, want: []string{"a", "b"}},
+		{name: "predicate", end: 10, f: func(b []byte) bool { return string(b) != "b" }, want: []string{"a", "c", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			part := Partition{Topic: "t", Partition: 0, Start: 0, End: 4, Offset: tt.offset}
+			msgs, err := cli.GetPartition(part, tt.end, tt.f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := values(msgs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetPartitionDeleted(t *testing.T) {
+	m, cli := newTestClient(t, "t", []string{"a"})
+	m.deleteTopic("t")
+
+	_, err := cli.GetPartition(Partition{Topic: "t", Partition: 0, End: 1}, 10, nil)
+	if err == nil {
+		t.Fatal("expected an error for a deleted topic")
+	}
+}
+
+func TestSearchTopic(t *testing.T) {
+	_, cli := newTestClient(t, "t", []string{"x", "needle"}, []string{"x"}, []string{"needle", "needle"})
+
+	parts, err := cli.GetTopic("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := cli.SearchTopic(parts, "needle", false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, p := range results {
+		got = append(got, fmt.Sprintf("%d/%d", p.Partition, p.Offset))
+	}
+	if want := []string{"0/1", "2/0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSearchTopicSorted(t *testing.T) {
+	m := newMockCluster()
+	var parts []Partition
+	for _, topic := range []string{"b", "a"} {
+		for p := int32(7); p >= 0; p-- {
+			m.produce(topic, p, nil, []byte("x"))
+			m.produce(topic, p, nil, []byte("needle"))
+			parts = append(parts, Partition{Topic: topic, Partition: p, End: 2})
+		}
+	}
+
+	cli, err := m.client(Concurrency(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	for i := 0; i < 5; i++ {
+		results, err := cli.SearchTopic(parts, "needle", false, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(results) != len(parts) {
+			t.Fatalf("got %d results, want %d", len(results), len(parts))
+		}
+		for j, p := range results {
+			topic, partition := "a", int32(j)
+			if j >= 8 {
+				topic, partition = "b", int32(j-8)
+			}
+			if p.Topic != topic || p.Partition != partition || p.Offset != 1 {
+				t.Fatalf("result %d is %s/%d@%d, want %s/%d@1", j, p.Topic, p.Partition, p.Offset, topic, partition)
+			}
+		}
+	}
+}
+
+func TestSearchTopicPartialError(t *testing.T) {
+	m, cli := newTestClient(t, "t", []string{"x"}, []string{"needle"})
+
+	parts, err := cli.GetTopic("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts[0].End = 2
+	m.injectError("t", 0, errBoom)
+
+	results, err := cli.SearchTopic(parts, "needle", false, nil)
+	var partial *PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("got %v, want a *PartialError", err)
+	}
+	if !errors.Is(err, errBoom) {
+		t.Errorf("got %v, want it to wrap %v", err, errBoom)
+	}
+	if _, ok := partial.Failed()[TopicPartition{Topic: "t", Partition: 0}]; !ok || len(partial.Failed()) != 1 {
+		t.Errorf("got failures %v, want only t/0", partial.Failed())
+	}
+	if len(results) != 1 || results[0].Partition != 1 {
+		t.Errorf("got %+v, want the match in partition 1", results)
+	}
+}
+
+func TestMessages(t *testing.T) {
+	_, cli := newTestClient(t, "t", []string{"a", "b", "c"})
+
+	it, err := cli.Messages(context.Background(), Partition{Topic: "t", Partition: 0, Offset: 1}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	var offsets []int64
+	for m, ok := it.Next(); ok; m, ok = it.Next() {
+		got = append(got, string(m.Value))
+		offsets = append(offsets, m.Offset)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if want := []int64{1, 2}; !reflect.DeepEqual(offsets, want) {
+		t.Errorf("got offsets %v, want %v", offsets, want)
+	}
+	if it.Offset() != 3 {
+		t.Errorf("got Offset %d, want 3", it.Offset())
+	}
+}
+
+func TestMessagesError(t *testing.T) {
+	m, cli := newTestClient(t, "t", []string{"a"})
+
+	it, err := cli.Messages(context.Background(), Partition{Topic: "t", Partition: 0, End: 2}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.injectError("t", 0, errBoom)
+
+	for _, ok := it.Next(); ok; _, ok = it.Next() {
+	}
+	if !errors.Is(it.Err(), errBoom) {
+		t.Errorf("got %v, want %v", it.Err(), errBoom)
+	}
+}
+
+func TestMessagesRetriableError(t *testing.T) {
+	m, cli := newTestClient(t, "t", []string{"a"})
+	m.injectError("t", 0, sarama.ErrNotLeaderForPartition)
+
+	it, err := cli.Messages(context.Background(), Partition{Topic: "t", Partition: 0, End: 2}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		m.produce("t", 0, nil, []byte("b"))
+	}()
+
+	var got []string
+	for msg, ok := it.Next(); ok; msg, ok = it.Next() {
+		got = append(got, string(msg.Value))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestMessagesPause pauses part way through, with messages read ahead
+// of Next, produces more while paused and checks that every offset is
+// delivered exactly once after the Resume.
+func TestMessagesPause(t *testing.T) {
+	m, cli := newTestClient(t, "t", []string{"0", "1", "2", "3", "4"})
+
+	it, err := cli.Messages(context.Background(), Partition{Topic: "t", Partition: 0, End: 10}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+
+	var offsets []int64
+	for len(offsets) < 3 {
+		msg, ok := it.Next()
+		if !ok {
+			t.Fatalf("stopped after %v: %v", offsets, it.Err())
+		}
+		offsets = append(offsets, msg.Offset)
+	}
+
+	if it.Paused() {
+		t.Fatal("Paused is true before Pause")
+	}
+	it.Pause()
+	it.Pause()
+	if !it.Paused() {
+		t.Fatal("Paused is false after Pause")
+	}
+	if it.Offset() != 3 {
+		t.Errorf("got Offset %d while paused, want 3", it.Offset())
+	}
+
+	for i := 5; i < 10; i++ {
+		m.produce("t", 0, nil, []byte(fmt.Sprint(i)))
+	}
+
+	// Next waits for the Resume from another goroutine
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		it.Resume()
+	}()
+
+	for msg, ok := it.Next(); ok; msg, ok = it.Next() {
+		offsets = append(offsets, msg.Offset)
+		if string(msg.Value) != fmt.Sprint(msg.Offset) {
+			t.Errorf("got %q at offset %d", msg.Value, msg.Offset)
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if it.Paused() {
+		t.Error("Paused is true after Resume")
+	}
+
+	if want := []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}; !reflect.DeepEqual(offsets, want) {
+		t.Errorf("got offsets %v, want %v", offsets, want)
+	}
+}
+
+// TestMessagesPauseClose closes a paused iterator, which ends a Next
+// that is waiting for a Resume.
+func TestMessagesPauseClose(t *testing.T) {
+	_, cli := newTestClient(t, "t", []string{"a", "b"})
+
+	it, err := cli.Messages(context.Background(), Partition{Topic: "t", Partition: 0, End: 2}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	it.Pause()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		it.Close()
+	}()
+
+	if msg, ok := it.Next(); ok {
+		t.Errorf("got %q from a closed iterator", msg.Value)
+	}
+	it.Resume()
+	if !it.Paused() {
+		t.Error("Resume changed a closed iterator")
+	}
+}
+
+func TestFetchTopic(t *testing.T) {
+	_, cli := newTestClient(t, "t", []string{"a", "b"}, []string{"c"}, nil)
+
+	parts, err := cli.GetTopic("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lock sync.Mutex
+	got := map[string]bool{}
+	stats, err := cli.FetchTopic(context.Background(), parts, 10, func(m Message) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		got[string(m.Value)] = true
+		return false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]bool{"a": true, "b": true, "c": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if stats.Delivered != 3 {
+		t.Errorf("got %d delivered, want 3", stats.Delivered)
+	}
+	if st := stats.Partitions[TopicPartition{Topic: "t", Partition: 0}]; st.Delivered != 2 || st.Next != 2 {
+		t.Errorf("got %+v for t/0, want 2 delivered and Next 2", st)
+	}
+}
+
+func TestFetchTopicPartialError(t *testing.T) {
+	m, cli := newTestClient(t, "t", []string{"a"}, []string{"b"})
+
+	parts, err := cli.GetTopic("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts[1].End = 2
+	m.injectError("t", 1, errBoom)
+
+	stats, err := cli.FetchTopic(context.Background(), parts, 10, func(Message) bool { return false })
+	var partial *PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("got %v, want a *PartialError", err)
+	}
+	if _, ok := partial.Failed()[TopicPartition{Topic: "t", Partition: 1}]; !ok {
+		t.Errorf("got failures %v, want t/1", partial.Failed())
+	}
+	if st := stats.Partitions[TopicPartition{Topic: "t", Partition: 0}]; st.Delivered != 1 {
+		t.Errorf("got %+v for t/0, want it fetched", st)
+	}
+}
+
+func TestFetchTopicDeleted(t *testing.T) {
+	m, cli := newTestClient(t, "t", []string{"a"})
+
+	parts, err := cli.GetTopic("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.deleteTopic("t")
+
+	_, err = cli.FetchTopic(context.Background(), parts, 10, func(Message) bool { return false })
+	if !errors.Is(err, ErrTopicDeleted) {
+		t.Errorf("got %v, want %v", err, ErrTopicDeleted)
+	}
+}
@@ -0,0 +1,192 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// failDecoder fails every decode, so a call that succeeds with it never
+// decoded anything
+type failDecoder struct{}
+
+func (failDecoder) Decode(string, []byte) ([]byte, error) { return nil, errBoom }
+
+func TestFetchKeys(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, []byte("user-1"), []byte("a"))
+	m.produce("t", 0, []byte("order-1"), []byte("b"))
+	m.produce("t", 0, nil, []byte("c"))
+	m.produce("t", 0, []byte("user-2"), []byte("d"))
+
+	cli, err := m.client(WithDecoder(failDecoder{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	part := Partition{Topic: "t", Partition: 0, End: 4}
+	tests := []struct {
+		name string
+		opts []CallOpt
+		want []string
+	}{
+		{name: "all", want: []string{"user-1@0", "order-1@1", "@2", "user-2@3"}},
+		{name: "prefix", opts: []CallOpt{KeyPrefix([]byte("user-"))}, want: []string{"user-1@0", "user-2@3"}},
+		{name: "matcher", opts: []CallOpt{WithMatcher(MatcherFunc(func(m Message) bool {
+			return m.Value == nil && m.Offset%2 == 1
+		}))}, want: []string{"order-1@1", "user-2@3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := cli.FetchKeys(context.Background(), part, 10, func(key []byte, offset int64) bool {
+				got = append(got, fmt.Sprintf("%s@%d", key, offset))
+				return false
+			}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchKeysStop(t *testing.T) {
+	m := newMockCluster()
+	for i := 0; i < 5; i++ {
+		m.produce("t", 0, []byte(fmt.Sprint(i)), nil)
+	}
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	var n int
+	err = cli.FetchKeys(context.Background(), Partition{Topic: "t", Partition: 0, End: 5}, 10, func([]byte, int64) bool {
+		n++
+		return n == 2
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("cb was called %d times after asking to stop at 2", n)
+	}
+}
+
+func TestFetchHeaders(t *testing.T) {
+	m := newMockCluster()
+	base := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	for i, svc := range []string{"orders", "billing", "", "orders"} {
+		var headers []*sarama.RecordHeader
+		if svc != "" {
+			headers = append(headers, &sarama.RecordHeader{Key: []byte("service"), Value: []byte(svc)})
+		}
+		off := m.produce("t", 0, nil, []byte("a large value"), headers...)
+		m.topics["t"][0][off].Timestamp = base.Add(time.Duration(i) * 30 * time.Minute)
+	}
+
+	cli, err := m.client(WithDecoder(failDecoder{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	part := Partition{Topic: "t", Partition: 0, End: 4}
+	tests := []struct {
+		name string
+		opts []CallOpt
+		want []string
+	}{
+		{name: "all", want: []string{"0 13:00 orders", "1 13:30 billing", "2 14:00 ", "3 14:30 orders"}},
+		{name: "time window", opts: []CallOpt{InRange(SearchRange{
+			FromTime: base.Add(30 * time.Minute),
+			ToTime:   base.Add(90 * time.Minute),
+		})}, want: []string{"1 13:30 billing", "2 14:00 "}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := cli.FetchHeaders(context.Background(), part, 10, func(offset int64, ts time.Time, headers map[string][]byte) bool {
+				if headers == nil {
+					t.Errorf("got nil headers at offset %d", offset)
+				}
+				got = append(got, fmt.Sprintf("%d %s %s", offset, ts.UTC().Format("15:04"), headers["service"]))
+				return false
+			}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// copyDecoder returns a copy of each value, like any decoder that builds
+// a new value does
+type copyDecoder struct{}
+
+func (copyDecoder) Decode(_ string, data []byte) ([]byte, error) {
+	return append([]byte(nil), data...), nil
+}
+
+// benchmarkFetch reads 1000 messages with 16KB values and a couple of
+// audit headers with fetch
+func benchmarkFetch(b *testing.B, fetch func(*Client, Partition) error) {
+	m := newMockCluster()
+	val := bytes.Repeat([]byte("x"), 16<<10)
+	for i := 0; i < 1000; i++ {
+		m.produce("t", 0, []byte(fmt.Sprintf("key-%d", i%100)), val,
+			&sarama.RecordHeader{Key: []byte("service"), Value: []byte("orders")},
+			&sarama.RecordHeader{Key: []byte("trace-id"), Value: []byte(fmt.Sprintf("%016x", i))},
+		)
+	}
+
+	cli, err := m.client(WithDecoder(copyDecoder{}))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer cli.Close()
+
+	part := Partition{Topic: "t", Partition: 0, End: 1000}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := fetch(cli, part); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFetchN(b *testing.B) {
+	benchmarkFetch(b, func(cli *Client, part Partition) error {
+		_, _, err := cli.FetchN(context.Background(), part, 1000, func(Message) bool { return false })
+		return err
+	})
+}
+
+func BenchmarkFetchKeys(b *testing.B) {
+	benchmarkFetch(b, func(cli *Client, part Partition) error {
+		return cli.FetchKeys(context.Background(), part, 1000, func([]byte, int64) bool { return false })
+	})
+}
+
+func BenchmarkFetchHeaders(b *testing.B) {
+	benchmarkFetch(b, func(cli *Client, part Partition) error {
+		return cli.FetchHeaders(context.Background(), part, 1000, func(int64, time.Time, map[string][]byte) bool { return false })
+	})
+}
@@ -0,0 +1,59 @@
+package kafka
+
+// GroupLag is how far behind a consumer group is on each partition it has
+// committed offsets for.
+type GroupLag struct {
+	Group      string         `json:"group"`
+	Lag        int64          `json:"lag"`
+	Partitions []PartitionLag `json:"partitions"`
+}
+
+// PartitionLag is the lag of a consumer group on a single partition.
+// Metadata is the string that was committed with Offset.
+type PartitionLag struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Start     int64  `json:"start"`
+	End       int64  `json:"end"`
+	Offset    int64  `json:"offset"`
+	Lag       int64  `json:"lag"`
+	Metadata  string `json:"metadata,omitempty"`
+}
+
+// GetGroupLag returns the lag (End - committed offset) of group on every
+// partition it has committed offsets for.
+func (c *Client) GetGroupLag(group string) (GroupLag, error) {
+	gl := GroupLag{Group: group}
+	offsets, err := c.DumpGroupOffsets(group)
+	if err != nil {
+		return gl, err
+	}
+
+	for _, topic := range sortedTopics(offsets) {
+		for _, p := range sortedPartitions(offsets[topic]) {
+			start, end, err := c.watermarks(topic, p)
+			if err != nil {
+				return gl, err
+			}
+
+			o := offsets[topic][p]
+			lag := end - o.Offset
+			if lag < 0 {
+				lag = 0
+			}
+
+			gl.Lag += lag
+			gl.Partitions = append(gl.Partitions, PartitionLag{
+				Topic:     topic,
+				Partition: p,
+				Start:     start,
+				End:       end,
+				Offset:    o.Offset,
+				Lag:       lag,
+				Metadata:  o.Metadata,
+			})
+		}
+	}
+
+	return gl, nil
+}
@@ -0,0 +1,364 @@
+package kafka
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// defaultIndexMaxBytes caps the values an index holds unless
+	// IndexMaxBytes says otherwise
+	defaultIndexMaxBytes = 1 << 30
+
+	indexFile = "index.gob"
+)
+
+// ErrStaleIndex means an index built with BuildIndex no longer covers its
+// partitions: messages were produced since, or removed by retention, or
+// the index was cut short by IndexMaxBytes.
+var ErrStaleIndex = errors.New("kafka: index is stale")
+
+// IndexMaxBytes caps the decoded values BuildIndex stores (1GB by
+// default).  Partitions that don't fit are indexed up to where the limit
+// was reached.
+func IndexMaxBytes(n int64) CallOpt {
+	return func(o *callOpts) {
+		o.indexMaxBytes = n
+	}
+}
+
+// indexedPartition is a partition covered by an index.  Offset to End is
+// what was indexed and Start and HighWater are the partition's
+// watermarks when it was.
+type indexedPartition struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Start     int64  `json:"start"`
+	HighWater int64  `json:"high_water"`
+	Offset    int64  `json:"offset"`
+	End       int64  `json:"end"`
+}
+
+// localIndex is what BuildIndex writes to index.gob.  Record i's value is
+// at Pos[i] (Len[i] bytes) in the Values file, which is Size bytes long,
+// and Trigrams maps each three byte sequence to the records whose value
+// contains it.  Every build writes a Values file of its own, so renaming
+// index.gob into place is all it takes to swap one index for another.
+type localIndex struct {
+	Built      time.Time
+	Values     string
+	Size       int64
+	Partitions []indexedPartition
+	Truncated  bool
+
+	Refs     []MessageRef
+	Pos      []int64
+	Len      []int32
+	Trigrams map[uint32][]uint32
+}
+
+// BuildIndex reads the decoded messages of parts, from each partition's
+// Offset to its End, into an index in dir that SearchIndexed answers
+// substring searches from without going to kafka.  An index already in
+// dir is replaced once the new one is complete.  See IndexMaxBytes and
+// RemoveIndex.
+func (c *Client) BuildIndex(ctx context.Context, parts []Partition, dir string, opts ...CallOpt) error {
+	o := getCallOpts(opts)
+	limit := o.indexMaxBytes
+	if limit <= 0 {
+		limit = defaultIndexMaxBytes
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	built := time.Now()
+	idx := localIndex{Built: built, Values: fmt.Sprintf("values-%x.dat", built.UnixNano()), Trigrams: map[uint32][]uint32{}}
+	tmp, err := os.OpenFile(filepath.Join(dir, idx.Values), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	swapped := false
+	defer func() {
+		tmp.Close()
+		if !swapped {
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	w := bufio.NewWriter(tmp)
+	var pos int64
+	for _, p := range parts {
+		start, hw, err := c.watermarks(p.Topic, p.Partition)
+		if err != nil {
+			return err
+		}
+
+		ip := indexedPartition{Topic: p.Topic, Partition: p.Partition, Start: start, HighWater: hw, Offset: p.Offset, End: p.Offset}
+		var werr error
+		_, err = c.fetch(ctx, p, p.End-p.Offset, o, func(m Message) bool {
+			if pos+int64(len(m.Value)) > limit {
+				idx.Truncated = true
+				return true
+			}
+
+			if _, werr = w.Write(m.Value); werr != nil {
+				return true
+			}
+
+			idx.add(MessageRef{Topic: p.Topic, Partition: p.Partition, Offset: m.Offset}, pos, m.Value)
+			pos += int64(len(m.Value))
+			ip.End = m.Offset + 1
+			return false
+		})
+
+		if werr != nil {
+			return werr
+		}
+		if err != nil {
+			return err
+		}
+
+		if !idx.Truncated {
+			ip.End = p.End
+		}
+		idx.Partitions = append(idx.Partitions, ip)
+		if idx.Truncated {
+			c.logf("index %s reached %d bytes at %s/%d offset %d", dir, limit, p.Topic, p.Partition, ip.End)
+			break
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	idx.Size = pos
+
+	old, oldErr := readIndex(dir)
+	if err := writeIndex(filepath.Join(dir, indexFile), idx); err != nil {
+		return err
+	}
+	swapped = true
+
+	if oldErr == nil && old.Values != "" && old.Values != idx.Values {
+		os.Remove(filepath.Join(dir, old.Values))
+	}
+	return nil
+}
+
+func (idx *localIndex) add(ref MessageRef, pos int64, val []byte) {
+	id := uint32(len(idx.Refs))
+	idx.Refs = append(idx.Refs, ref)
+	idx.Pos = append(idx.Pos, pos)
+	idx.Len = append(idx.Len, int32(len(val)))
+
+	for i := 0; i+3 <= len(val); i++ {
+		t := trigram(val[i:])
+		ids := idx.Trigrams[t]
+		if n := len(ids); n == 0 || ids[n-1] != id {
+			idx.Trigrams[t] = append(ids, id)
+		}
+	}
+}
+
+func trigram(b []byte) uint32 {
+	return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+}
+
+func writeIndex(path string, idx localIndex) error {
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := gob.NewEncoder(f).Encode(idx); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func readIndex(dir string) (localIndex, error) {
+	var idx localIndex
+	f, err := os.Open(filepath.Join(dir, indexFile))
+	if err != nil {
+		return idx, err
+	}
+	defer f.Close()
+	return idx, gob.NewDecoder(bufio.NewReader(f)).Decode(&idx)
+}
+
+// openValues opens the Values file of idx, making sure it is the one
+// index.gob was written with.
+func openValues(dir string, idx localIndex) (*os.File, error) {
+	if idx.Values == "" || filepath.Base(idx.Values) != idx.Values {
+		return nil, fmt.Errorf("kafka: index in %s names no values file", dir)
+	}
+
+	f, err := os.Open(filepath.Join(dir, idx.Values))
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if fi.Size() != idx.Size {
+		f.Close()
+		return nil, fmt.Errorf("kafka: index in %s expects %d bytes of values, %s has %d", dir, idx.Size, idx.Values, fi.Size())
+	}
+	return f, nil
+}
+
+// SearchIndexed returns the messages in the index in dir whose value
+// contains query, in topic, partition and offset order.  If the index no
+// longer covers its partitions the matches are returned with an error
+// wrapping ErrStaleIndex.
+func (c *Client) SearchIndexed(dir, query string) ([]MessageRef, error) {
+	idx, err := readIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	vals, err := openValues(dir, idx)
+	if err != nil {
+		return nil, err
+	}
+	defer vals.Close()
+
+	needle := []byte(query)
+	var out []MessageRef
+	var buf []byte
+	for _, id := range idx.candidates(needle) {
+		if int(idx.Len[id]) > cap(buf) {
+			buf = make([]byte, idx.Len[id])
+		}
+		buf = buf[:idx.Len[id]]
+		if _, err := vals.ReadAt(buf, idx.Pos[id]); err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		if bytes.Contains(buf, needle) {
+			out = append(out, idx.Refs[id])
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Topic != b.Topic {
+			return a.Topic < b.Topic
+		}
+		if a.Partition != b.Partition {
+			return a.Partition < b.Partition
+		}
+		return a.Offset < b.Offset
+	})
+
+	return out, c.checkIndex(idx)
+}
+
+// candidates returns the records that have every trigram of needle (all
+// of them for needles shorter than three bytes).
+func (idx *localIndex) candidates(needle []byte) []uint32 {
+	if len(needle) < 3 {
+		out := make([]uint32, len(idx.Refs))
+		for i := range out {
+			out[i] = uint32(i)
+		}
+		return out
+	}
+
+	var out []uint32
+	for i := 0; i+3 <= len(needle); i++ {
+		ids := idx.Trigrams[trigram(needle[i:])]
+		if i == 0 {
+			out = ids
+		} else {
+			out = intersect(out, ids)
+		}
+		if len(out) == 0 {
+			return nil
+		}
+	}
+	return out
+}
+
+// intersect returns the ids in both of the sorted lists a and b
+func intersect(a, b []uint32) []uint32 {
+	var out []uint32
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// checkIndex compares the partitions of idx with their watermarks now
+func (c *Client) checkIndex(idx localIndex) error {
+	if idx.Truncated {
+		return fmt.Errorf("%w: it was cut short by IndexMaxBytes", ErrStaleIndex)
+	}
+
+	for _, p := range idx.Partitions {
+		start, end, err := c.watermarks(p.Topic, p.Partition)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case start > p.Offset:
+			return fmt.Errorf("%w: retention removed %s/%d offsets %d to %d", ErrStaleIndex, p.Topic, p.Partition, p.Offset, start-1)
+		case end > p.HighWater:
+			return fmt.Errorf("%w: %s/%d has %d messages since it was built", ErrStaleIndex, p.Topic, p.Partition, end-p.HighWater)
+		}
+	}
+	return nil
+}
+
+// RemoveIndex deletes the index in dir, and dir if nothing else is in it.
+func RemoveIndex(dir string) error {
+	names, err := filepath.Glob(filepath.Join(dir, "values-*.dat"))
+	if err != nil {
+		return err
+	}
+	for _, name := range append([]string{filepath.Join(dir, indexFile)}, names...) {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if rest, _ := filepath.Glob(filepath.Join(dir, "*")); len(rest) > 0 {
+		return nil
+	}
+	if err := os.Remove(dir); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package kafka
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+// LogDirInfo describes a log directory on a broker.  When the broker
+// couldn't be asked (or the directory is offline) Error is set.
+type LogDirInfo struct {
+	Broker   int32         `json:"broker"`
+	Path     string        `json:"path"`
+	Error    string        `json:"error,omitempty"`
+	Replicas []ReplicaSize `json:"replicas"`
+}
+
+// ReplicaSize is the size on disk of one replica of a partition.  Future
+// is set for replicas that are being moved into the directory.
+type ReplicaSize struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Size      int64  `json:"size"`
+	OffsetLag int64  `json:"offset_lag"`
+	Future    bool   `json:"future,omitempty"`
+}
+
+// LogDirs returns the log directories of every broker, keyed by broker
+// id.  Brokers that can't be reached are included with Error set rather
+// than failing the call.
+func (c *Client) LogDirs() (map[int32][]LogDirInfo, error) {
+	return c.logDirs(nil)
+}
+
+// TopicSize returns the number of bytes the replicas of topic take up on
+// disk across the cluster.  If some brokers couldn't be reached the size
+// of the others is returned along with an error naming them.
+func (c *Client) TopicSize(topic string) (int64, error) {
+	partitions, err := c.sarama.Partitions(topic)
+	if err != nil {
+		return 0, wrapErr("get partitions", topic, -1, -1, err)
+	}
+
+	dirs, err := c.logDirs([]sarama.DescribeLogDirsRequestTopic{{Topic: topic, PartitionIDs: partitions}})
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	var failed []string
+	for id, ds := range dirs {
+		for _, d := range ds {
+			if d.Error != "" {
+				failed = append(failed, fmt.Sprintf("broker %d (%s): %s", id, d.Path, d.Error))
+				continue
+			}
+			for _, r := range d.Replicas {
+				if r.Topic == topic && !r.Future {
+					size += r.Size
+				}
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return size, fmt.Errorf("size of %s is incomplete: %s", topic, strings.Join(failed, ", "))
+	}
+
+	return size, nil
+}
+
+func (c *Client) logDirs(topics []sarama.DescribeLogDirsRequestTopic) (map[int32][]LogDirInfo, error) {
+	brokers := c.sarama.Brokers()
+	if len(brokers) == 0 {
+		return nil, wrapErr("describe log dirs", "", -1, -1, sarama.ErrOutOfBrokers)
+	}
+
+	out := map[int32][]LogDirInfo{}
+	for _, b := range brokers {
+		out[b.ID()] = c.brokerLogDirs(b, topics)
+	}
+	return out, nil
+}
+
+func (c *Client) brokerLogDirs(b *sarama.Broker, topics []sarama.DescribeLogDirsRequestTopic) []LogDirInfo {
+	if err := b.Open(c.cfg); err != nil && err != sarama.ErrAlreadyConnected {
+		return []LogDirInfo{{Broker: b.ID(), Error: err.Error()}}
+	}
+
+	resp, err := b.DescribeLogDirs(&sarama.DescribeLogDirsRequest{DescribeTopics: topics})
+	if err != nil {
+		return []LogDirInfo{{Broker: b.ID(), Error: err.Error()}}
+	}
+
+	out := make([]LogDirInfo, 0, len(resp.LogDirs))
+	for _, d := range resp.LogDirs {
+		info := LogDirInfo{Broker: b.ID(), Path: d.Path}
+		if d.ErrorCode != sarama.ErrNoError {
+			info.Error = d.ErrorCode.Error()
+		}
+
+		for _, t := range d.Topics {
+			for _, p := range t.Partitions {
+				info.Replicas = append(info.Replicas, ReplicaSize{
+					Topic:     t.Topic,
+					Partition: p.PartitionID,
+					Size:      p.Size,
+					OffsetLag: p.OffsetLag,
+					Future:    p.IsTemporary,
+				})
+			}
+		}
+		out = append(out, info)
+	}
+	return out
+}
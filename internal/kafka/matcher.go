@@ -0,0 +1,268 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+// Matcher decides if a message should be included in the results of a
+// search, count or filtered fetch.  The Message's Value has already been
+// run through the Client's Decoder.
+type Matcher interface {
+	Match(msg Message) bool
+}
+
+// MatcherFunc turns a func into a Matcher
+type MatcherFunc func(Message) bool
+
+// Match calls f(msg)
+func (f MatcherFunc) Match(msg Message) bool { return f(msg) }
+
+// WithMatcher only includes messages that satisfy m.  When used with a
+// search, a message must contain the search string and satisfy m.
+func WithMatcher(m Matcher) CallOpt {
+	return func(o *callOpts) {
+		o.matcher = m
+	}
+}
+
+// Count returns the number of messages from info.Offset to info.End that
+// satisfy m.
+func (c *Client) Count(ctx context.Context, info Partition, m Matcher, opts ...CallOpt) (int64, error) {
+	o := getCallOpts(append(opts, WithMatcher(m)))
+	info, err := c.applyRange(info, o.rng)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	var derr error
+	err = c.consume(ctx, info, info.End, func(msg *sarama.ConsumerMessage) bool {
+		var ok bool
+		if msg, ok, derr = c.decodeMessage(ctx, o, msg); derr != nil {
+			return true
+		}
+		if ok && o.matches(info, msg) {
+			n++
+		}
+		return false
+	})
+
+	if derr != nil {
+		return n, derr
+	}
+
+	return n, err
+}
+
+// matches checks an already decoded msg against the call's Matcher.
+func (o callOpts) matches(info Partition, msg *sarama.ConsumerMessage) bool {
+	if o.matcher == nil {
+		return true
+	}
+
+	return o.matcher.Match(newMessage(info, msg))
+}
+
+// Contains returns a Matcher for messages whose value contains s.
+func Contains(s string) Matcher {
+	b := []byte(s)
+	return MatcherFunc(func(msg Message) bool { return bytes.Contains(msg.Value, b) })
+}
+
+// NewRegexMatcher returns a Matcher for messages whose value matches the
+// regular expression expr.
+func NewRegexMatcher(expr string) (Matcher, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return MatcherFunc(func(msg Message) bool { return re.Match(msg.Value) }), nil
+}
+
+// filterMatcher returns the Matcher for a Partition.Filter.  An empty
+// filter matches everything.
+func filterMatcher(f string) (Matcher, error) {
+	if f == "" {
+		return MatcherFunc(func(Message) bool { return true }), nil
+	}
+
+	if len(f) > 1 && strings.HasPrefix(f, "/") && strings.HasSuffix(f, "/") {
+		m, err := NewRegexMatcher(f[1 : len(f)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid partition filter %q: %s", f, err)
+		}
+		return m, nil
+	}
+
+	return Contains(f), nil
+}
+
+type jsonMatcher struct {
+	path  []interface{}
+	op    string
+	value string
+}
+
+// NewJSONMatcher returns a Matcher that parses each message as JSON, looks
+// up the value at path and compares it to value using op.  Paths are
+// dotted and may contain array indexes (ie "order.items[0].sku").
+// Supported ops are ==, !=, contains, > and <.  Comparison is numeric when
+// both sides parse as numbers.  Messages that are not valid JSON or do not
+// contain the path never match.
+func NewJSONMatcher(path string, op string, value string) (Matcher, error) {
+	switch op {
+	case "==", "!=", "contains", ">", "<":
+	default:
+		return nil, fmt.Errorf("unsupported json matcher operator %q", op)
+	}
+
+	p, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonMatcher{path: p, op: op, value: value}, nil
+}
+
+// parseJSONPath splits a path into string (object key) and int (array
+// index) segments.
+func parseJSONPath(path string) ([]interface{}, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty json path")
+	}
+
+	var out []interface{}
+	for _, part := range strings.Split(path, ".") {
+		key := part
+		var idx []int
+		if i := strings.Index(part, "["); i > -1 {
+			key = part[:i]
+			rest := part[i:]
+			for len(rest) > 0 {
+				j := strings.Index(rest, "]")
+				if rest[0] != '[' || j < 0 {
+					return nil, fmt.Errorf("invalid json path %q", path)
+				}
+				n, err := strconv.Atoi(rest[1:j])
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid array index in json path %q", path)
+				}
+				idx = append(idx, n)
+				rest = rest[j+1:]
+			}
+		}
+
+		if key == "" && len(idx) == 0 {
+			return nil, fmt.Errorf("invalid json path %q", path)
+		}
+
+		if key != "" {
+			out = append(out, key)
+		}
+
+		for _, n := range idx {
+			out = append(out, n)
+		}
+	}
+
+	return out, nil
+}
+
+// Match implements Matcher
+func (j *jsonMatcher) Match(msg Message) bool {
+	dec := json.NewDecoder(bytes.NewReader(msg.Value))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return false
+	}
+
+	v, ok := lookupJSONPath(v, j.path)
+	if !ok {
+		return false
+	}
+
+	return compareJSON(v, j.op, j.value)
+}
+
+func lookupJSONPath(v interface{}, path []interface{}) (interface{}, bool) {
+	for _, seg := range path {
+		switch s := seg.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if v, ok = m[s]; !ok {
+				return nil, false
+			}
+		case int:
+			a, ok := v.([]interface{})
+			if !ok || s >= len(a) {
+				return nil, false
+			}
+			v = a[s]
+		}
+	}
+	return v, true
+}
+
+func compareJSON(v interface{}, op, value string) bool {
+	if op == "contains" {
+		if a, ok := v.([]interface{}); ok {
+			for _, e := range a {
+				if compareJSON(e, "==", value) {
+					return true
+				}
+			}
+			return false
+		}
+		return strings.Contains(jsonString(v), value)
+	}
+
+	s := jsonString(v)
+	if n, ok := v.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			if g, err := strconv.ParseFloat(value, 64); err == nil {
+				return compareOrdered(f < g, f == g, op)
+			}
+		}
+	}
+
+	return compareOrdered(s < value, s == value, op)
+}
+
+func compareOrdered(less, equal bool, op string) bool {
+	switch op {
+	case "==":
+		return equal
+	case "!=":
+		return !equal
+	case "<":
+		return less
+	case ">":
+		return !less && !equal
+	}
+	return false
+}
+
+// jsonString returns strings as is and everything else as JSON.
+func jsonString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	}
+
+	d, _ := json.Marshal(v)
+	return string(d)
+}
@@ -0,0 +1,155 @@
+package kafka
+
+import (
+	"context"
+	"regexp"
+	"sort"
+
+	"github.com/IBM/sarama"
+)
+
+// defaultMaxPositions is how many match positions are kept per message
+// unless MaxPositions says otherwise.
+const defaultMaxPositions = 100
+
+// SearchTerm is one of the patterns of SearchMatches.  Text is matched
+// literally unless Regex is set.
+type SearchTerm struct {
+	Text       string `json:"text"`
+	Regex      bool   `json:"regex,omitempty"`
+	IgnoreCase bool   `json:"ignore_case,omitempty"`
+}
+
+// SearchMatch is a message that matched at least one term.  Ranges are
+// the [start, end) byte ranges within the decoded value where a term
+// matched, in order of start, and Terms[i] is the index of the term that
+// matched Ranges[i].
+type SearchMatch struct {
+	Offset int64    `json:"offset"`
+	Ranges [][2]int `json:"ranges"`
+	Terms  []int    `json:"terms"`
+}
+
+// MaxPositions caps the number of ranges SearchMatches returns for each
+// message (100 by default).
+func MaxPositions(n int) CallOpt {
+	return func(o *callOpts) {
+		o.maxPositions = n
+	}
+}
+
+// Positions compiles terms for finding where they match.  It lets a
+// caller highlight a message found some other way with the same rules
+// SearchMatches uses.
+type Positions struct {
+	res []*regexp.Regexp
+}
+
+// NewPositions compiles terms.  It fails if a Regex term doesn't compile.
+func NewPositions(terms []SearchTerm) (*Positions, error) {
+	p := &Positions{}
+	for _, t := range terms {
+		expr := t.Text
+		if !t.Regex {
+			expr = regexp.QuoteMeta(expr)
+		}
+		if t.IgnoreCase {
+			expr = "(?i)" + expr
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		p.res = append(p.res, re)
+	}
+	return p, nil
+}
+
+// Find returns up to limit (all if limit <= 0) ranges where the terms
+// match val and the index of the term of each range.
+func (p *Positions) Find(val []byte, limit int) ([][2]int, []int) {
+	type position struct {
+		r    [2]int
+		term int
+	}
+
+	var all []position
+	for i, re := range p.res {
+		for _, loc := range re.FindAllIndex(val, limit) {
+			// an empty match highlights nothing
+			if loc[0] == loc[1] {
+				continue
+			}
+			all = append(all, position{r: [2]int{loc[0], loc[1]}, term: i})
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].r[0] < all[j].r[0] })
+	if limit > 0 && len(all) > limit {
+		all = all[:limit]
+	}
+
+	if len(all) == 0 {
+		return nil, nil
+	}
+
+	ranges := make([][2]int, len(all))
+	terms := make([]int, len(all))
+	for i, pos := range all {
+		ranges[i], terms[i] = pos.r, pos.term
+	}
+	return ranges, terms
+}
+
+// SearchMatches searches info from its Offset to its End for messages
+// whose decoded value matches any of terms and returns them with the
+// positions of the matches.  It stops once max messages have matched
+// (max <= 0 means no limit) and calls cb, which may be nil, with each
+// match as it is found.
+func (c *Client) SearchMatches(ctx context.Context, info Partition, terms []SearchTerm, max int, cb func(SearchMatch), opts ...CallOpt) ([]SearchMatch, error) {
+	pos, err := NewPositions(terms)
+	if err != nil {
+		return nil, err
+	}
+
+	o, done := getCallOpts(opts).withProgress(info.End - info.Offset)
+	defer done()
+
+	if info, err = c.applyRange(info, o.rng); err != nil {
+		return nil, err
+	}
+
+	limit := o.maxPositions
+	if limit == 0 {
+		limit = defaultMaxPositions
+	}
+
+	var out []SearchMatch
+	var derr error
+	err = c.consume(ctx, info, info.End, func(msg *sarama.ConsumerMessage) bool {
+		o.prog.scan(msg)
+		var ok bool
+		if msg, ok, derr = c.decodeMessage(ctx, o, msg); derr != nil || !ok {
+			return derr != nil
+		}
+
+		ranges, matched := pos.Find(msg.Value, limit)
+		if len(ranges) == 0 || !o.matches(info, msg) {
+			return false
+		}
+
+		m := SearchMatch{Offset: msg.Offset, Ranges: ranges, Terms: matched}
+		out = append(out, m)
+		o.prog.match()
+		if cb != nil {
+			cb(m)
+		}
+		return max > 0 && len(out) >= max
+	})
+
+	if derr != nil {
+		return out, derr
+	}
+	return out, err
+}
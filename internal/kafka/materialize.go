@@ -0,0 +1,96 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/IBM/sarama"
+)
+
+// ErrTooManyKeys is returned by Materialize when the number of live keys
+// grows past the limit set with MaxKeys.
+var ErrTooManyKeys = errors.New("too many keys")
+
+// KeyPrefix restricts Materialize and FetchKeys to keys that start with p.
+func KeyPrefix(p []byte) CallOpt {
+	return func(o *callOpts) {
+		o.keyPrefix = p
+	}
+}
+
+// MaxKeys makes Materialize fail with ErrTooManyKeys instead of holding
+// more than n keys in memory.
+func MaxKeys(n int) CallOpt {
+	return func(o *callOpts) {
+		o.maxKeys = n
+	}
+}
+
+// Materialize reads every partition of a (compacted) topic from its Start
+// up to the End captured when the call started and keeps the latest value
+// for each key.  Tombstones (nil values) remove the key and messages
+// without a key are ignored.  Once everything has been read cb is called,
+// in key order, for each key that survived.  It returns the number of
+// keys.
+func (c *Client) Materialize(ctx context.Context, topic string, cb func(key, value []byte), opts ...CallOpt) (int, error) {
+	o := getCallOpts(opts)
+	parts, err := c.topicPartitions(topic)
+	if err != nil {
+		return 0, err
+	}
+
+	state := map[string][]byte{}
+	for _, part := range parts {
+		var merr error
+		err := c.consume(ctx, part, part.End-part.Offset, func(msg *sarama.ConsumerMessage) bool {
+			if msg.Key == nil || !bytes.HasPrefix(msg.Key, o.keyPrefix) {
+				return false
+			}
+
+			if msg.Value == nil {
+				delete(state, string(msg.Key))
+				return false
+			}
+
+			val, ok, err := c.decode(ctx, o, topic, msg.Offset, msg.Value)
+			if err != nil {
+				merr = err
+				return true
+			}
+
+			if !ok {
+				return false
+			}
+
+			state[string(msg.Key)] = val
+			if o.maxKeys > 0 && len(state) > o.maxKeys {
+				merr = fmt.Errorf("%w: more than %d keys in %s", ErrTooManyKeys, o.maxKeys, topic)
+				return true
+			}
+			return false
+		})
+
+		if merr != nil {
+			return 0, merr
+		}
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	keys := make([]string, 0, len(state))
+	for k := range state {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		cb([]byte(k), state[k])
+	}
+
+	return len(keys), nil
+}
@@ -0,0 +1,144 @@
+package kafka
+
+import (
+	"container/heap"
+	"context"
+)
+
+// mergeBuffer is how many messages of each partition MergeTopics reads
+// ahead of the merge.
+const mergeBuffer = 64
+
+// MergeTopics reads every partition of topics from the offset spec
+// resolves to up to the high water mark it had when the merge started
+// and sends the messages that matcher (which may be nil) matches to cb
+// ordered by timestamp.  Messages with the same timestamp are ordered by
+// topic, partition and offset.  Each partition is read ahead by a small
+// buffer so memory doesn't grow with the size of the window.  cb is
+// called from a single goroutine and returning true stops the merge.  A
+// partition that fails leaves the merge without stopping it: the error
+// is a *PartialError once the rest are done.
+func (c *Client) MergeTopics(ctx context.Context, topics []string, from OffsetSpec, matcher Matcher, cb func(Message) bool, opts ...CallOpt) error {
+	o := getCallOpts(opts)
+	if matcher != nil {
+		o.matcher = matcher
+	}
+
+	var parts []Partition
+	for _, topic := range topics {
+		tp, err := c.topicPartitions(topic)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range tp {
+			if p.Offset, err = c.resolveSpec(p, from); err != nil {
+				return err
+			}
+			if p.Offset < p.End {
+				parts = append(parts, p)
+			}
+		}
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	srcs := make([]*mergeSource, len(parts))
+	for i, p := range parts {
+		srcs[i] = &mergeSource{part: p, msgs: make(chan Message, mergeBuffer)}
+		go srcs[i].run(ctx, c, o)
+	}
+
+	var partial PartialError
+	h := &mergeHeap{}
+	next := func(s *mergeSource) {
+		m, ok := <-s.msgs
+		if ok {
+			heap.Push(h, mergeHead{msg: m, src: s})
+			return
+		}
+		if s.err != nil && ctx.Err() == nil {
+			partial.add(s.part, s.err)
+		}
+	}
+
+	for _, s := range srcs {
+		next(s)
+	}
+
+	for h.Len() > 0 {
+		head := heap.Pop(h).(mergeHead)
+		if cb(head.msg) {
+			cancel()
+			break
+		}
+		next(head.src)
+	}
+
+	cancel()
+	for _, s := range srcs {
+		for range s.msgs {
+		}
+	}
+
+	if err := partial.orNil(); err != nil {
+		return err
+	}
+	return parent.Err()
+}
+
+// mergeSource is a partition being read by MergeTopics.  err is only set
+// once msgs is closed.
+type mergeSource struct {
+	part Partition
+	msgs chan Message
+	err  error
+}
+
+func (s *mergeSource) run(ctx context.Context, c *Client, o callOpts) {
+	defer close(s.msgs)
+	_, s.err = c.fetch(ctx, s.part, s.part.End-s.part.Offset, o, func(m Message) bool {
+		select {
+		case s.msgs <- m:
+			return false
+		case <-ctx.Done():
+			return true
+		}
+	})
+}
+
+type mergeHead struct {
+	msg Message
+	src *mergeSource
+}
+
+// mergeHeap holds the next message of every partition with messages left
+type mergeHeap []mergeHead
+
+func (h mergeHeap) Len() int { return len(h) }
+
+func (h mergeHeap) Less(i, j int) bool {
+	a, b := h[i].msg, h[j].msg
+	switch {
+	case !a.Timestamp.Equal(b.Timestamp):
+		return a.Timestamp.Before(b.Timestamp)
+	case a.Partition.Topic != b.Partition.Topic:
+		return a.Partition.Topic < b.Partition.Topic
+	case a.Partition.Partition != b.Partition.Partition:
+		return a.Partition.Partition < b.Partition.Partition
+	}
+	return a.Offset < b.Offset
+}
+
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.(mergeHead)) }
+
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ServeMetrics computes the lag of each of groups (and the watermarks of
+// the partitions they consume) every interval and serves them in the
+// Prometheus text format at http://addr/metrics until ctx is cancelled.
+// If collecting a group fails its kcli_up gauge is set to 0 and its
+// other metrics are dropped rather than left stale.
+func (c *Client) ServeMetrics(ctx context.Context, addr string, groups []string, interval time.Duration) error {
+	var lock sync.RWMutex
+	var page []byte
+
+	collect := func() {
+		d := c.collectMetrics(groups)
+		lock.Lock()
+		page = d
+		lock.Unlock()
+	}
+
+	collect()
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				collect()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		lock.RLock()
+		defer lock.RUnlock()
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write(page)
+	})
+
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (c *Client) collectMetrics(groups []string) []byte {
+	var up, lag, start, end bytes.Buffer
+	fmt.Fprintln(&up, "# HELP kcli_up Whether the last collection for a group succeeded.")
+	fmt.Fprintln(&up, "# TYPE kcli_up gauge")
+	fmt.Fprintln(&lag, "# HELP kcli_group_lag Messages between a group's committed offset and the end of the partition.")
+	fmt.Fprintln(&lag, "# TYPE kcli_group_lag gauge")
+	fmt.Fprintln(&start, "# HELP kcli_partition_start The oldest offset of a partition.")
+	fmt.Fprintln(&start, "# TYPE kcli_partition_start gauge")
+	fmt.Fprintln(&end, "# HELP kcli_partition_end The newest offset of a partition.")
+	fmt.Fprintln(&end, "# TYPE kcli_partition_end gauge")
+
+	seen := map[TopicPartition]bool{}
+	for _, g := range groups {
+		gl, err := c.GetGroupLag(g)
+		if err != nil {
+			fmt.Fprintf(&up, "kcli_up{group=%q} 0\n", g)
+			continue
+		}
+
+		fmt.Fprintf(&up, "kcli_up{group=%q} 1\n", g)
+		for _, p := range gl.Partitions {
+			fmt.Fprintf(&lag, "kcli_group_lag{group=%q,topic=%q,partition=\"%d\"} %d\n", g, p.Topic, p.Partition, p.Lag)
+			tp := TopicPartition{Topic: p.Topic, Partition: p.Partition}
+			if seen[tp] {
+				continue
+			}
+			seen[tp] = true
+			fmt.Fprintf(&start, "kcli_partition_start{topic=%q,partition=\"%d\"} %d\n", p.Topic, p.Partition, p.Start)
+			fmt.Fprintf(&end, "kcli_partition_end{topic=%q,partition=\"%d\"} %d\n", p.Topic, p.Partition, p.End)
+		}
+	}
+
+	var out bytes.Buffer
+	for _, b := range []*bytes.Buffer{&up, &lag, &start, &end} {
+		out.Write(b.Bytes())
+	}
+	return out.Bytes()
+}
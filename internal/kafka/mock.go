@@ -1,8 +1,13 @@
 package kafka
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
 )
 
 var (
@@ -56,3 +61,435 @@ func mockFetch(_ Partition, _ int64, cb func(string)) error {
 func mockSearch(info Partition, s string) (int64, error) {
 	return int64(-1), nil
 }
+
+var errNoBrokers = errors.New("kafka: the mock cluster has no brokers")
+
+// mockCluster is an in memory kafka for exercising the Client without a
+// broker.  It implements saramaClient and sarama.Consumer.  Calls that
+// need a real broker (ACLs, log dirs, groups and the like) fail with
+// errNoBrokers.
+type mockCluster struct {
+	lock    sync.Mutex
+	topics  map[string][][]*sarama.ConsumerMessage
+	starts  map[TopicPartition]int64
+	stalls  map[TopicPartition]map[int64]time.Duration
+	errs    map[TopicPartition][]error
+	denied  map[string]bool
+	changed chan struct{}
+}
+
+func newMockCluster() *mockCluster {
+	return &mockCluster{
+		topics:  map[string][][]*sarama.ConsumerMessage{},
+		starts:  map[TopicPartition]int64{},
+		stalls:  map[TopicPartition]map[int64]time.Duration{},
+		errs:    map[TopicPartition][]error{},
+		denied:  map[string]bool{},
+		changed: make(chan struct{}),
+	}
+}
+
+// client returns a Client backed by m
+func (m *mockCluster) client(opts ...Opt) (*Client, error) {
+	return New(nil, append(opts, withSaramaClient(m, func(*sarama.Config) (sarama.Consumer, error) { return m, nil }))...)
+}
+
+// createTopic adds topic with n empty partitions
+func (m *mockCluster) createTopic(topic string, n int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.topics[topic] = make([][]*sarama.ConsumerMessage, n)
+}
+
+// produce appends a message to a partition (creating the topic if it
+// doesn't exist) and returns its offset.
+func (m *mockCluster) produce(topic string, partition int32, key, value []byte, headers ...*sarama.RecordHeader) int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	parts := m.topics[topic]
+	for int(partition) >= len(parts) {
+		parts = append(parts, nil)
+	}
+
+	offset := int64(len(parts[partition]))
+	parts[partition] = append(parts[partition], &sarama.ConsumerMessage{
+		Topic:     topic,
+		Partition: partition,
+		Offset:    offset,
+		Key:       key,
+		Value:     value,
+		Headers:   headers,
+		Timestamp: time.Now(),
+	})
+	m.topics[topic] = parts
+
+	close(m.changed)
+	m.changed = make(chan struct{})
+	return offset
+}
+
+// hole takes up the next n offsets of a partition without records, the
+// way compaction and transaction markers leave offsets that are never
+// delivered.
+func (m *mockCluster) hole(topic string, partition int32, n int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	parts := m.topics[topic]
+	for int(partition) >= len(parts) {
+		parts = append(parts, nil)
+	}
+	parts[partition] = append(parts[partition], make([]*sarama.ConsumerMessage, n)...)
+	m.topics[topic] = parts
+
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+// stall makes consumers of a partition wait for d before delivering
+// offset, like a slow fetch from the broker.
+func (m *mockCluster) stall(topic string, partition int32, offset int64, d time.Duration) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	tp := TopicPartition{Topic: topic, Partition: partition}
+	if m.stalls[tp] == nil {
+		m.stalls[tp] = map[int64]time.Duration{}
+	}
+	m.stalls[tp][offset] = d
+}
+
+func (m *mockCluster) stallAt(topic string, partition int32, offset int64) time.Duration {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.stalls[TopicPartition{Topic: topic, Partition: partition}][offset]
+}
+
+// deleteTopic removes topic, consumers of its partitions stop getting
+// messages but (like sarama's) aren't told why.
+func (m *mockCluster) deleteTopic(topic string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.topics, topic)
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+// deny makes every read of topic fail the way it does when an ACL
+// doesn't let the principal read it.
+func (m *mockCluster) deny(topic string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.denied[topic] = true
+}
+
+// expire makes the messages of a partition before offset unreadable, the
+// way retention removes them.
+func (m *mockCluster) expire(topic string, partition int32, offset int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.starts[TopicPartition{Topic: topic, Partition: partition}] = offset
+}
+
+// start returns the oldest offset of a partition that hasn't expired.
+// m.lock must be held.
+func (m *mockCluster) start(topic string, partition int32) int64 {
+	return m.starts[TopicPartition{Topic: topic, Partition: partition}]
+}
+
+// injectError makes the next consumer of a partition to catch up report
+// err on its Errors channel, the way sarama reports a failed fetch.
+func (m *mockCluster) injectError(topic string, partition int32, err error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	tp := TopicPartition{Topic: topic, Partition: partition}
+	m.errs[tp] = append(m.errs[tp], err)
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+// takeErrors returns and forgets the errors injected into a partition
+func (m *mockCluster) takeErrors(topic string, partition int32) []error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	tp := TopicPartition{Topic: topic, Partition: partition}
+	errs := m.errs[tp]
+	delete(m.errs, tp)
+	return errs
+}
+
+func (m *mockCluster) partition(topic string, partition int32) ([]*sarama.ConsumerMessage, error) {
+	if m.denied[topic] {
+		return nil, sarama.ErrTopicAuthorizationFailed
+	}
+	parts, ok := m.topics[topic]
+	if !ok || partition < 0 || int(partition) >= len(parts) {
+		return nil, sarama.ErrUnknownTopicOrPartition
+	}
+	return parts[partition], nil
+}
+
+func (m *mockCluster) Topics() ([]string, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var out []string
+	for t := range m.topics {
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+func (m *mockCluster) Partitions(topic string) ([]int32, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.denied[topic] {
+		return nil, sarama.ErrTopicAuthorizationFailed
+	}
+	parts, ok := m.topics[topic]
+	if !ok {
+		return nil, sarama.ErrUnknownTopicOrPartition
+	}
+
+	out := make([]int32, len(parts))
+	for i := range parts {
+		out[i] = int32(i)
+	}
+	return out, nil
+}
+
+func (m *mockCluster) GetOffset(topic string, partition int32, t int64) (int64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	msgs, err := m.partition(topic, partition)
+	if err != nil {
+		return 0, err
+	}
+
+	start := m.start(topic, partition)
+	switch t {
+	case sarama.OffsetOldest:
+		return start, nil
+	case sarama.OffsetNewest:
+		return int64(len(msgs)), nil
+	}
+
+	for _, msg := range msgs[start:] {
+		if msg != nil && msg.Timestamp.UnixNano()/int64(time.Millisecond) >= t {
+			return msg.Offset, nil
+		}
+	}
+	return int64(len(msgs)), nil
+}
+
+func (m *mockCluster) Replicas(topic string, partition int32) ([]int32, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, err := m.partition(topic, partition); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (m *mockCluster) RefreshMetadata(topics ...string) error { return nil }
+
+func (m *mockCluster) Brokers() []*sarama.Broker { return nil }
+
+func (m *mockCluster) Controller() (*sarama.Broker, error) { return nil, m.noBroker() }
+
+func (m *mockCluster) Leader(string, int32) (*sarama.Broker, error) { return nil, m.noBroker() }
+
+func (m *mockCluster) Coordinator(string) (*sarama.Broker, error) { return nil, m.noBroker() }
+
+func (m *mockCluster) noBroker() error {
+	return errNoBrokers
+}
+
+func (m *mockCluster) HighWaterMarks() map[string]map[int32]int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	out := map[string]map[int32]int64{}
+	for t, parts := range m.topics {
+		out[t] = map[int32]int64{}
+		for i, msgs := range parts {
+			out[t][int32(i)] = int64(len(msgs))
+		}
+	}
+	return out
+}
+
+// Close does nothing, the Client closes its consumers after every call
+// and the cluster has to outlive them.
+func (m *mockCluster) Close() error { return nil }
+
+// Pause, Resume, PauseAll and ResumeAll do nothing, the Client only
+// pauses single partition consumers.
+func (m *mockCluster) Pause(map[string][]int32) {}
+
+func (m *mockCluster) Resume(map[string][]int32) {}
+
+func (m *mockCluster) PauseAll() {}
+
+func (m *mockCluster) ResumeAll() {}
+
+func (m *mockCluster) ConsumePartition(topic string, partition int32, offset int64) (sarama.PartitionConsumer, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	msgs, err := m.partition(topic, partition)
+	if err != nil {
+		return nil, err
+	}
+
+	start := m.start(topic, partition)
+	switch offset {
+	case sarama.OffsetOldest:
+		offset = start
+	case sarama.OffsetNewest:
+		offset = int64(len(msgs))
+	}
+
+	if offset < start || offset > int64(len(msgs)) {
+		return nil, sarama.ErrOffsetOutOfRange
+	}
+
+	pc := &mockPartitionConsumer{
+		cluster:   m,
+		topic:     topic,
+		partition: partition,
+		messages:  make(chan *sarama.ConsumerMessage),
+		errors:    make(chan *sarama.ConsumerError),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+		resumed:   make(chan struct{}),
+	}
+	go pc.run(offset)
+	return pc, nil
+}
+
+// mockPartitionConsumer delivers a partition of a mockCluster from an
+// offset and then waits for newly produced messages until it is closed.
+type mockPartitionConsumer struct {
+	cluster   *mockCluster
+	topic     string
+	partition int32
+	messages  chan *sarama.ConsumerMessage
+	errors    chan *sarama.ConsumerError
+	done      chan struct{}
+	stopped   chan struct{}
+	once      sync.Once
+
+	pauseLock sync.Mutex
+	paused    bool
+	resumed   chan struct{}
+}
+
+func (pc *mockPartitionConsumer) run(offset int64) {
+	defer close(pc.stopped)
+	defer close(pc.messages)
+	for {
+		pc.cluster.lock.Lock()
+		msgs, _ := pc.cluster.partition(pc.topic, pc.partition)
+		changed := pc.cluster.changed
+		pc.cluster.lock.Unlock()
+
+		for ; offset < int64(len(msgs)); offset++ {
+			if msgs[offset] == nil {
+				continue
+			}
+			if d := pc.cluster.stallAt(pc.topic, pc.partition, offset); d > 0 {
+				select {
+				case <-time.After(d):
+				case <-pc.done:
+					return
+				}
+			}
+			if !pc.wait() {
+				return
+			}
+			select {
+			case pc.messages <- msgs[offset]:
+			case <-pc.done:
+				return
+			}
+		}
+
+		for _, err := range pc.cluster.takeErrors(pc.topic, pc.partition) {
+			select {
+			case pc.errors <- &sarama.ConsumerError{Topic: pc.topic, Partition: pc.partition, Err: err}:
+			case <-pc.done:
+				return
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-pc.done:
+			return
+		}
+	}
+}
+
+// wait blocks while pc is paused.  It returns false if pc is closed.
+func (pc *mockPartitionConsumer) wait() bool {
+	pc.pauseLock.Lock()
+	paused, resumed := pc.paused, pc.resumed
+	pc.pauseLock.Unlock()
+	if !paused {
+		return true
+	}
+
+	select {
+	case <-resumed:
+		return true
+	case <-pc.done:
+		return false
+	}
+}
+
+func (pc *mockPartitionConsumer) Pause() {
+	pc.pauseLock.Lock()
+	defer pc.pauseLock.Unlock()
+	pc.paused = true
+}
+
+func (pc *mockPartitionConsumer) Resume() {
+	pc.pauseLock.Lock()
+	defer pc.pauseLock.Unlock()
+	if pc.paused {
+		pc.paused = false
+		close(pc.resumed)
+		pc.resumed = make(chan struct{})
+	}
+}
+
+func (pc *mockPartitionConsumer) IsPaused() bool {
+	pc.pauseLock.Lock()
+	defer pc.pauseLock.Unlock()
+	return pc.paused
+}
+
+func (pc *mockPartitionConsumer) AsyncClose() {
+	pc.once.Do(func() { close(pc.done) })
+}
+
+func (pc *mockPartitionConsumer) Close() error {
+	pc.AsyncClose()
+	<-pc.stopped
+	return nil
+}
+
+func (pc *mockPartitionConsumer) Messages() <-chan *sarama.ConsumerMessage { return pc.messages }
+
+func (pc *mockPartitionConsumer) Errors() <-chan *sarama.ConsumerError { return pc.errors }
+
+func (pc *mockPartitionConsumer) HighWaterMarkOffset() int64 {
+	pc.cluster.lock.Lock()
+	defer pc.cluster.lock.Unlock()
+	msgs, _ := pc.cluster.partition(pc.topic, pc.partition)
+	return int64(len(msgs))
+}
@@ -0,0 +1,89 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/IBM/sarama"
+)
+
+// MovedMessage records a message that MoveMatching moved (or, in a dry
+// run, would have moved).  Partition and Offset are where it was
+// produced and are -1 in a dry run.
+type MovedMessage struct {
+	SourceOffset int64 `json:"source_offset"`
+	Partition    int32 `json:"partition"`
+	Offset       int64 `json:"offset"`
+}
+
+// MoveReport is the audit trail of a MoveMatching.  The source messages
+// are left where they are (kafka can't delete single records).
+// LastMoved is the source offset of the last message that was produced
+// (-1 if none was) and Next the offset to resume the scan from.
+type MoveReport struct {
+	DryRun    bool           `json:"dry_run"`
+	Scanned   int64          `json:"scanned"`
+	Moved     []MovedMessage `json:"moved"`
+	LastMoved int64          `json:"last_moved"`
+	Next      int64          `json:"next"`
+}
+
+// MoveMatching scans from, from its Offset to its End, and produces the
+// raw messages whose decoded form satisfies m to toTopic with their keys
+// and headers.  It stops after limit messages have been moved (limit <= 0
+// means no limit).  With dryRun nothing is produced and the report lists
+// what would have been moved.  If producing fails the scan stops, the
+// error is returned, and the report's Next is the offset of the message
+// that failed so the move can be resumed from there.
+func (c *Client) MoveMatching(ctx context.Context, from Partition, m Matcher, toTopic string, limit int, dryRun bool) (MoveReport, error) {
+	report := MoveReport{DryRun: dryRun, LastMoved: -1, Next: from.Offset}
+
+	var prod sarama.SyncProducer
+	if !dryRun {
+		var err error
+		if prod, err = c.newProducer(func(*sarama.Config) {}); err != nil {
+			return report, wrapErr("create producer", toTopic, -1, -1, err)
+		}
+		defer prod.Close()
+	}
+
+	o := getCallOpts([]CallOpt{WithMatcher(m)})
+	var merr error
+	err := c.consume(ctx, from, from.End-from.Offset, func(msg *sarama.ConsumerMessage) bool {
+		report.Scanned++
+		decoded, ok, err := c.decodeMessage(ctx, o, msg)
+		if err != nil {
+			merr = err
+			return true
+		}
+
+		if !ok || !o.matches(from, decoded) {
+			report.Next = msg.Offset + 1
+			return false
+		}
+
+		moved := MovedMessage{SourceOffset: msg.Offset, Partition: -1, Offset: -1}
+		if !dryRun {
+			var headers []Header
+			for _, h := range msg.Headers {
+				headers = append(headers, Header{Key: string(h.Key), Value: h.Value})
+			}
+
+			p, off, err := prod.SendMessage(producerMessage(toTopic, 0, msg.Key, msg.Value, headers))
+			if err != nil {
+				merr = wrapErr("produce", toTopic, -1, -1, err)
+				return true
+			}
+			moved.Partition, moved.Offset = p, off
+			report.LastMoved = msg.Offset
+		}
+
+		report.Moved = append(report.Moved, moved)
+		report.Next = msg.Offset + 1
+		return limit > 0 && len(report.Moved) >= limit
+	})
+
+	if merr != nil {
+		return report, merr
+	}
+	return report, err
+}
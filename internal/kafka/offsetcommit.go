@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/IBM/sarama"
+)
+
+// ErrGroupActive is returned when committing offsets for a group that has
+// active members, which would overwrite (or be overwritten by) their
+// own commits.
+var ErrGroupActive = errors.New("consumer group has active members")
+
+// GroupOffset is the committed position of a consumer group on a partition
+type GroupOffset struct {
+	Offset   int64  `json:"offset"`
+	Metadata string `json:"metadata,omitempty"`
+}
+
+// CommitGroupOffset commits offset (with metadata) for a single partition
+// of group.  The offset must be within the partition's current [Start,
+// End] range and the group must not have any active members.  This is
+// useful for skipping a message that a consumer is stuck on.  It
+// requires AllowDestructive.
+func (c *Client) CommitGroupOffset(group, topic string, partition int32, offset int64, metadata string) error {
+	if err := c.checkDestructive(); err != nil {
+		return err
+	}
+
+	start, end, err := c.watermarks(topic, partition)
+	if err != nil {
+		return err
+	}
+
+	if offset < start || offset > end {
+		return fmt.Errorf("offset %d is outside of the range [%d, %d] of %s partition %d", offset, start, end, topic, partition)
+	}
+
+	if err := c.checkGroupInactive(group); err != nil {
+		return err
+	}
+
+	errs, err := c.commitOffsets(group, map[string]map[int32]GroupOffset{
+		topic: {partition: {Offset: offset, Metadata: metadata}},
+	})
+	if err != nil {
+		return err
+	}
+
+	return errs[topic][partition]
+}
+
+// checkGroupInactive returns ErrGroupActive if the group is stable and has
+// members.
+func (c *Client) checkGroupInactive(group string) error {
+	admin, err := c.newAdmin()
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	groups, err := admin.DescribeConsumerGroups([]string{group})
+	if err != nil {
+		return err
+	}
+
+	for _, g := range groups {
+		if g.Err != sarama.ErrNoError {
+			return g.Err
+		}
+		if g.State == "Stable" && len(g.Members) > 0 {
+			return fmt.Errorf("%w: %s has %d members", ErrGroupActive, group, len(g.Members))
+		}
+	}
+
+	return nil
+}
+
+// commitOffsets sends a single offset commit for group to its coordinator
+// and returns the per-partition results.
+func (c *Client) commitOffsets(group string, offsets map[string]map[int32]GroupOffset) (map[string]map[int32]error, error) {
+	coordinator, err := c.sarama.Coordinator(group)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &sarama.OffsetCommitRequest{
+		Version:                 2,
+		ConsumerGroup:           group,
+		ConsumerGroupGeneration: -1,
+		RetentionTime:           -1,
+	}
+
+	for topic, parts := range offsets {
+		for p, o := range parts {
+			req.AddBlock(topic, p, o.Offset, 0, o.Metadata)
+		}
+	}
+
+	resp, err := coordinator.CommitOffset(req)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]map[int32]error{}
+	for topic, parts := range offsets {
+		out[topic] = map[int32]error{}
+		for p := range parts {
+			kerr, ok := resp.Errors[topic][p]
+			if !ok {
+				out[topic][p] = fmt.Errorf("no commit result returned for %s partition %d", topic, p)
+			} else if kerr != sarama.ErrNoError {
+				out[topic][p] = kerr
+			}
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,126 @@
+package kafka
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/IBM/sarama"
+)
+
+// ClampOffsets, when on, makes reads that start at an offset retention has
+// already removed (or past the end of the partition) start from the
+// nearest valid offset instead of failing.  The Partition of each message
+// returned by GetPartition has Clamped set when this happens.
+func ClampOffsets(on bool) Opt {
+	return func(c *Client) {
+		c.clampOffsets = on
+	}
+}
+
+// OffsetAtFraction returns the offset that is roughly f of the way from
+// Start to the last message in the partition.  f is clamped to [0, 1].
+func (p Partition) OffsetAtFraction(f float64) int64 {
+	if p.End <= p.Start {
+		return p.Start
+	}
+
+	f = math.Max(0, math.Min(1, f))
+	last := p.End - 1
+	return p.Start + int64(math.Round(f*float64(last-p.Start)))
+}
+
+// SeekFraction returns the partition with fresh Start and End values and
+// its Offset set to roughly f of the way through it.  f must be between
+// 0 and 1.
+func (c *Client) SeekFraction(topic string, partition int32, f float64) (Partition, error) {
+	if f < 0 || f > 1 || math.IsNaN(f) {
+		return Partition{}, fmt.Errorf("invalid fraction %v, it must be between 0 and 1", f)
+	}
+
+	start, end, err := c.watermarks(topic, partition)
+	if err != nil {
+		return Partition{}, err
+	}
+
+	p := Partition{
+		Topic:     topic,
+		Partition: partition,
+		Start:     start,
+		End:       end,
+	}
+	p.Offset = p.OffsetAtFraction(f)
+	return p, nil
+}
+
+// RefreshPartition returns a copy of p with its Start and End re-read from
+// kafka.  If retention (or truncation) has moved Start past p's Offset then
+// Offset is clamped and Clamped is set.
+func (c *Client) RefreshPartition(p Partition) (Partition, error) {
+	start, end, err := c.watermarks(p.Topic, p.Partition)
+	if err != nil {
+		return p, err
+	}
+
+	p.Start = start
+	p.End = end
+	p.Clamped = false
+	if p.Offset < start {
+		p.Offset = start
+		p.Clamped = true
+	} else if p.Offset > end {
+		p.Offset = end
+		p.Clamped = true
+	}
+	return p, nil
+}
+
+// RefreshPartitions concurrently refreshes each of parts, see
+// RefreshPartition.
+func (c *Client) RefreshPartitions(parts []Partition) ([]Partition, error) {
+	out := make([]Partition, len(parts))
+	errs := make([]error, len(parts))
+	sem := make(chan struct{}, c.concurrency)
+	var wg sync.WaitGroup
+	for i, p := range parts {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, p Partition) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			out[i], errs[i] = c.RefreshPartition(p)
+		}(i, p)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// consumePartition starts consuming part at part.Offset.  If the offset is
+// out of range it is either clamped (see ClampOffsets) or the error
+// reports the offsets that are valid.
+func (c *Client) consumePartition(consumer sarama.Consumer, part Partition) (sarama.PartitionConsumer, Partition, error) {
+	pc, err := c.dial(consumer, part.Topic, part.Partition, part.Offset)
+	if err != sarama.ErrOffsetOutOfRange {
+		return pc, part, err
+	}
+
+	p, rerr := c.RefreshPartition(part)
+	if rerr != nil {
+		return nil, part, err
+	}
+
+	if !c.clampOffsets {
+		return nil, part, fmt.Errorf("offset %d of %s partition %d is out of range, valid offsets are %d to %d: %w", part.Offset, part.Topic, part.Partition, p.Start, p.End, err)
+	}
+
+	pc, err = c.dial(consumer, p.Topic, p.Partition, p.Offset)
+	return pc, p, err
+}
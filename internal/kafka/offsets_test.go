@@ -0,0 +1,104 @@
+package kafka
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOffsetAtFraction(t *testing.T) {
+	tests := []struct {
+		start, end int64
+		f          float64
+		want       int64
+	}{
+		{start: 0, end: 101, f: 0, want: 0},
+		{start: 0, end: 101, f: 0.5, want: 50},
+		{start: 0, end: 101, f: 1, want: 100},
+		{start: 10, end: 21, f: 0.25, want: 13},
+		{start: 10, end: 21, f: -1, want: 10},
+		{start: 10, end: 21, f: 2, want: 20},
+		{start: 5, end: 5, f: 0.5, want: 5},
+		{start: 0, end: 1, f: 1, want: 0},
+	}
+
+	for _, tt := range tests {
+		p := Partition{Start: tt.start, End: tt.end}
+		if got := p.OffsetAtFraction(tt.f); got != tt.want {
+			t.Errorf("OffsetAtFraction(%v) of %d-%d = %d, want %d", tt.f, tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestSeekFraction(t *testing.T) {
+	m := newMockCluster()
+	for i := 0; i < 21; i++ {
+		m.produce("t", 0, nil, []byte("x"))
+	}
+	m.expire("t", 0, 10)
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	p, err := cli.SeekFraction("t", 0, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Start != 10 || p.End != 21 || p.Offset != 15 {
+		t.Errorf("got %+v, want Start 10, End 21 and Offset 15", p)
+	}
+
+	for _, f := range []float64{-0.1, 1.1, math.NaN()} {
+		if _, err := cli.SeekFraction("t", 0, f); err == nil {
+			t.Errorf("expected an error for fraction %v", f)
+		}
+	}
+}
+
+func TestRefreshPartition(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, nil, []byte("x"))
+	m.produce("t", 1, nil, []byte("x"))
+
+	cli, err := m.client(Concurrency(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	parts, err := cli.GetTopic("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		m.produce("t", 0, nil, []byte("x"))
+	}
+	m.expire("t", 0, 3)
+
+	p, err := cli.RefreshPartition(parts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Start != 3 || p.End != 5 || p.Offset != 3 || !p.Clamped {
+		t.Errorf("got %+v, want Start 3, End 5 and Offset clamped to 3", p)
+	}
+
+	refreshed, err := cli.RefreshPartitions(parts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refreshed) != 2 || refreshed[0] != p {
+		t.Errorf("got %+v, want partition 0 to be %+v", refreshed, p)
+	}
+	if q := refreshed[1]; q.Partition != 1 || q.End != 1 || q.Offset != 0 || q.Clamped {
+		t.Errorf("got %+v for partition 1, want it unchanged", q)
+	}
+
+	m.deleteTopic("t")
+	if _, err := cli.RefreshPartitions(parts); err == nil {
+		t.Error("expected an error once the topic is deleted")
+	}
+}
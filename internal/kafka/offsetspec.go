@@ -0,0 +1,169 @@
+package kafka
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OffsetSpecKind says how an OffsetSpec is resolved
+type OffsetSpecKind int
+
+const (
+	// SpecAbsolute is a literal offset
+	SpecAbsolute OffsetSpecKind = iota
+	// SpecOldest is the oldest offset still in the partition
+	SpecOldest
+	// SpecNewest is the high water mark (the offset of the next message)
+	SpecNewest
+	// SpecFromEnd is Offset (<= 0) messages back from the newest
+	SpecFromEnd
+	// SpecFromStart is Offset (>= 0) messages on from the oldest
+	SpecFromStart
+	// SpecTime is the first message at or after Time
+	SpecTime
+)
+
+// OffsetSpec is an offset that is resolved against a partition's
+// watermarks, see ParseOffsetSpec.
+type OffsetSpec struct {
+	Kind   OffsetSpecKind
+	Offset int64
+	Time   time.Time
+}
+
+// ParseOffsetSpec parses:
+//
+//	oldest, newest           the partition's watermarks
+//	123                      offset 123
+//	-100                     100 messages before the newest
+//	+100                     100 messages after the oldest
+//	@2024-06-01T12:00:00Z    the first message at or after the time (RFC 3339)
+//	@1717243200000           the same, in milliseconds since the epoch
+//
+// Kafka offsets are never negative so a negative number is always
+// relative to the end, there is no way to spell a literal negative
+// offset.  "-0" is the same as newest and "+0" the same as oldest.
+func ParseOffsetSpec(s string) (OffsetSpec, error) {
+	s = strings.TrimSpace(s)
+	switch s {
+	case "oldest":
+		return OffsetSpec{Kind: SpecOldest}, nil
+	case "newest":
+		return OffsetSpec{Kind: SpecNewest}, nil
+	case "":
+		return OffsetSpec{}, fmt.Errorf("empty offset")
+	}
+
+	if strings.HasPrefix(s, "@") {
+		ts := s[1:]
+		if ms, err := strconv.ParseInt(ts, 10, 64); err == nil {
+			return OffsetSpec{Kind: SpecTime, Time: time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond))}, nil
+		}
+		t, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			return OffsetSpec{}, fmt.Errorf("invalid offset time %q: want RFC 3339 or milliseconds since the epoch", ts)
+		}
+		return OffsetSpec{Kind: SpecTime, Time: t}, nil
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return OffsetSpec{}, fmt.Errorf("invalid offset %q: want oldest, newest, a number, -N, +N or @time", s)
+	}
+
+	switch s[0] {
+	case '-':
+		return OffsetSpec{Kind: SpecFromEnd, Offset: n}, nil
+	case '+':
+		return OffsetSpec{Kind: SpecFromStart, Offset: n}, nil
+	}
+	return OffsetSpec{Kind: SpecAbsolute, Offset: n}, nil
+}
+
+// String returns the spec in the form ParseOffsetSpec takes
+func (s OffsetSpec) String() string {
+	switch s.Kind {
+	case SpecOldest:
+		return "oldest"
+	case SpecNewest:
+		return "newest"
+	case SpecFromEnd:
+		return fmt.Sprintf("-%d", -s.Offset)
+	case SpecFromStart:
+		return fmt.Sprintf("+%d", s.Offset)
+	case SpecTime:
+		return "@" + s.Time.UTC().Format(time.RFC3339Nano)
+	}
+	return strconv.FormatInt(s.Offset, 10)
+}
+
+// MarshalText implements encoding.TextMarshaler with String
+func (s OffsetSpec) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler with ParseOffsetSpec
+func (s *OffsetSpec) UnmarshalText(text []byte) error {
+	spec, err := ParseOffsetSpec(string(text))
+	if err != nil {
+		return err
+	}
+	*s = spec
+	return nil
+}
+
+// StartFrom sets where GetTopic puts each partition's Offset for topics
+// whose TopicDefaults don't have a Start, ie ParseOffsetSpec("-100") to
+// show the last 100 messages of each partition first.  The default is the
+// oldest message.  An absolute offset that a partition no longer has (or
+// doesn't have yet) leaves the Offset at the partition's Start with
+// Clamped set.  Only GetTopic is affected: calls that read whole topics,
+// like Materialize and ConsumeSince, still start at Start.
+func StartFrom(spec OffsetSpec) Opt {
+	return func(c *Client) {
+		c.startFrom = &spec
+	}
+}
+
+// ResolveOffset turns spec into an offset of topic/partition.  Relative
+// offsets are clamped to the partition's watermarks, an absolute offset
+// outside of them is an ErrOffsetOutOfRange and a time after the newest
+// message resolves to the newest offset.
+func (c *Client) ResolveOffset(topic string, partition int32, spec OffsetSpec) (int64, error) {
+	start, end, err := c.watermarks(topic, partition)
+	if err != nil {
+		return 0, err
+	}
+
+	return c.resolveSpec(Partition{Topic: topic, Partition: partition, Start: start, End: end}, spec)
+}
+
+// resolveSpec resolves spec against p's Start and End
+func (c *Client) resolveSpec(p Partition, spec OffsetSpec) (int64, error) {
+	topic, partition, start, end := p.Topic, p.Partition, p.Start, p.End
+	switch spec.Kind {
+	case SpecOldest:
+		return start, nil
+	case SpecNewest:
+		return end, nil
+	case SpecFromEnd:
+		if o := end + spec.Offset; o > start {
+			return o, nil
+		}
+		return start, nil
+	case SpecFromStart:
+		if o := start + spec.Offset; o < end {
+			return o, nil
+		}
+		return end, nil
+	case SpecTime:
+		return c.offsetForTime(p, spec.Time)
+	}
+
+	if spec.Offset < start || spec.Offset > end {
+		return 0, wrapErr("resolve offset", topic, partition, spec.Offset, fmt.Errorf("%w (valid offsets are %d to %d)", ErrOffsetOutOfRange, start, end))
+	}
+	return spec.Offset, nil
+}
@@ -0,0 +1,153 @@
+package kafka
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseOffsetSpec(t *testing.T) {
+	at := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    OffsetSpec
+		str     string
+		wantErr bool
+	}{
+		{in: "oldest", want: OffsetSpec{Kind: SpecOldest}, str: "oldest"},
+		{in: " newest ", want: OffsetSpec{Kind: SpecNewest}, str: "newest"},
+		{in: "123", want: OffsetSpec{Kind: SpecAbsolute, Offset: 123}, str: "123"},
+		{in: "-100", want: OffsetSpec{Kind: SpecFromEnd, Offset: -100}, str: "-100"},
+		{in: "+100", want: OffsetSpec{Kind: SpecFromStart, Offset: 100}, str: "+100"},
+		{in: "-0", want: OffsetSpec{Kind: SpecFromEnd}, str: "-0"},
+		{in: "@2024-06-01T12:00:00Z", want: OffsetSpec{Kind: SpecTime, Time: at}, str: "@2024-06-01T12:00:00Z"},
+		{in: "@1717243200000", want: OffsetSpec{Kind: SpecTime, Time: at}, str: "@2024-06-01T12:00:00Z"},
+		{in: "", wantErr: true},
+		{in: "first", wantErr: true},
+		{in: "12x", wantErr: true},
+		{in: "@yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseOffsetSpec(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseOffsetSpec(%q) err = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+
+		if got.Kind != tt.want.Kind || got.Offset != tt.want.Offset || !got.Time.Equal(tt.want.Time) {
+			t.Errorf("ParseOffsetSpec(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if s := got.String(); s != tt.str {
+			t.Errorf("ParseOffsetSpec(%q).String() = %q, want %q", tt.in, s, tt.str)
+		}
+	}
+}
+
+func TestOffsetSpecJSON(t *testing.T) {
+	in := TopicDefaults{Start: &OffsetSpec{Kind: SpecFromEnd, Offset: -10}}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"start":"-10"}` {
+		t.Errorf("got %s", b)
+	}
+
+	var out TopicDefaults
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if *out.Start != *in.Start {
+		t.Errorf("got %+v, want %+v", *out.Start, *in.Start)
+	}
+
+	if err := json.Unmarshal([]byte(`{"start":"soon"}`), &out); err == nil {
+		t.Error("expected an error for an invalid spec")
+	}
+}
+
+func TestResolveOffset(t *testing.T) {
+	m := newMockCluster()
+	for i := 0; i < 10; i++ {
+		m.produce("t", 0, nil, []byte("x"))
+	}
+	m.expire("t", 0, 2)
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	tests := []struct {
+		spec string
+		want int64
+	}{
+		{spec: "oldest", want: 2},
+		{spec: "newest", want: 10},
+		{spec: "-3", want: 7},
+		{spec: "-30", want: 2},
+		{spec: "+3", want: 5},
+		{spec: "+30", want: 10},
+		{spec: "4", want: 4},
+		{spec: "10", want: 10},
+		{spec: "@1", want: 2},
+	}
+
+	for _, tt := range tests {
+		spec, err := ParseOffsetSpec(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := cli.ResolveOffset("t", 0, spec)
+		if err != nil {
+			t.Errorf("ResolveOffset(%s): %s", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ResolveOffset(%s) = %d, want %d", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, off := range []int64{1, 11} {
+		if _, err := cli.ResolveOffset("t", 0, OffsetSpec{Offset: off}); !errors.Is(err, ErrOffsetOutOfRange) {
+			t.Errorf("ResolveOffset(%d) got %v, want %v", off, err, ErrOffsetOutOfRange)
+		}
+	}
+}
+
+func TestStartFrom(t *testing.T) {
+	m := newMockCluster()
+	for i := 0; i < 10; i++ {
+		m.produce("t", 0, nil, []byte("x"))
+	}
+	m.produce("t", 1, nil, []byte("x"))
+
+	cli, err := m.client(StartFrom(OffsetSpec{Kind: SpecFromEnd, Offset: -3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	parts, err := cli.GetTopic("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parts[0].Offset != 7 || parts[1].Offset != 0 {
+		t.Errorf("got offsets %d and %d, want 7 and 0", parts[0].Offset, parts[1].Offset)
+	}
+
+	// reads of whole topics aren't affected
+	all, err := cli.topicPartitions("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all[0].Offset != 0 {
+		t.Errorf("got offset %d, want 0", all[0].Offset)
+	}
+}
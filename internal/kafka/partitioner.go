@@ -0,0 +1,130 @@
+package kafka
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// HashStrategy is the algorithm a producer uses to map keys to partitions
+type HashStrategy int
+
+const (
+	// HashMurmur2 is the default partitioner of the Java client
+	HashMurmur2 HashStrategy = iota
+	// HashFNV1a is sarama's default hash partitioner
+	HashFNV1a
+)
+
+// KeyHash sets the HashStrategy that LocateKey uses (HashMurmur2 by default).
+func KeyHash(s HashStrategy) func(*Client) {
+	return func(c *Client) {
+		c.keyHash = s
+	}
+}
+
+// PartitionForKey returns the partition that a producer using strategy
+// would write key to.  Producers don't hash nil or empty keys, so -1 is
+// returned for them.
+func PartitionForKey(key []byte, numPartitions int32, strategy HashStrategy) int32 {
+	if len(key) == 0 || numPartitions <= 0 {
+		return -1
+	}
+
+	switch strategy {
+	case HashFNV1a:
+		h := fnv.New32a()
+		h.Write(key)
+		p := int32(h.Sum32()) % numPartitions
+		if p < 0 {
+			p = -p
+		}
+		return p
+	default:
+		// the java client masks off the sign bit rather than taking the
+		// absolute value, which matters for math.MinInt32
+		return int32(murmur2(key)&0x7fffffff) % numPartitions
+	}
+}
+
+// murmur2 is a port of org.apache.kafka.common.utils.Utils.murmur2
+func murmur2(data []byte) uint32 {
+	const (
+		seed uint32 = 0x9747b28c
+		m    uint32 = 0x5bd1e995
+		r           = 24
+	)
+
+	length := len(data)
+	h := seed ^ uint32(length)
+	n := length / 4
+	for i := 0; i < n; i++ {
+		j := i * 4
+		k := uint32(data[j]) | uint32(data[j+1])<<8 | uint32(data[j+2])<<16 | uint32(data[j+3])<<24
+		k *= m
+		k ^= k >> r
+		k *= m
+		h *= m
+		h ^= k
+	}
+
+	tail := length &^ 3
+	switch length % 4 {
+	case 3:
+		h ^= uint32(data[tail+2]) << 16
+		fallthrough
+	case 2:
+		h ^= uint32(data[tail+1]) << 8
+		fallthrough
+	case 1:
+		h ^= uint32(data[tail])
+		h *= m
+	}
+
+	h ^= h >> 13
+	h *= m
+	h ^= h >> 15
+	return h
+}
+
+// LocateKey returns the partition of topic that messages with key are
+// written to, using the Client's KeyHash strategy.
+func (c *Client) LocateKey(topic string, key []byte) (Partition, error) {
+	partitions, err := c.sarama.Partitions(topic)
+	if err != nil {
+		return Partition{}, wrapErr("get partitions", topic, -1, -1, err)
+	}
+
+	p := PartitionForKey(key, int32(len(partitions)), c.keyHash)
+	if p < 0 {
+		return Partition{}, fmt.Errorf("unable to locate a partition for an empty key")
+	}
+
+	return c.RefreshPartition(Partition{Topic: topic, Partition: p})
+}
+
+// PreviewDistribution counts how many of keys a producer using strategy
+// would write to each partition.  Every partition is in the result, even
+// if no key lands on it, so that skew is easy to see.  Empty keys aren't
+// hashed (producers spread them round robin) and are counted under -1.
+func PreviewDistribution(keys [][]byte, numPartitions int32, strategy HashStrategy) map[int32]int {
+	out := make(map[int32]int, numPartitions)
+	for p := int32(0); p < numPartitions; p++ {
+		out[p] = 0
+	}
+
+	for _, key := range keys {
+		out[PartitionForKey(key, numPartitions, strategy)]++
+	}
+	return out
+}
+
+// PreviewTopicDistribution is PreviewDistribution for the current number
+// of partitions of topic and the Client's KeyHash strategy.
+func (c *Client) PreviewTopicDistribution(topic string, keys [][]byte) (map[int32]int, error) {
+	partitions, err := c.sarama.Partitions(topic)
+	if err != nil {
+		return nil, wrapErr("get partitions", topic, -1, -1, err)
+	}
+
+	return PreviewDistribution(keys, int32(len(partitions)), c.keyHash), nil
+}
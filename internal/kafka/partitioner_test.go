@@ -0,0 +1,131 @@
+package kafka
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func TestMurmur2(t *testing.T) {
+	// the vectors of the java client's UtilsTest
+	tests := []struct {
+		in   string
+		want int32
+	}{
+		{in: "21", want: -973932308},
+		{in: "foobar", want: -790332482},
+		{in: "a-little-bit-long-string", want: -985981536},
+		{in: "a-little-bit-longer-string", want: -1486304829},
+		{in: "lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8", want: -58897971},
+		{in: "abc", want: 479470107},
+	}
+
+	for _, tt := range tests {
+		if got := int32(murmur2([]byte(tt.in))); got != tt.want {
+			t.Errorf("murmur2(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPartitionForKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		n        int32
+		strategy HashStrategy
+		want     int32
+	}{
+		// toPositive(-790332482) % 10
+		{key: "foobar", n: 10, strategy: HashMurmur2, want: 6},
+		{key: "abc", n: 7, strategy: HashMurmur2, want: 479470107 % 7},
+		{key: "", n: 10, strategy: HashMurmur2, want: -1},
+		{key: "abc", n: 0, strategy: HashFNV1a, want: -1},
+	}
+
+	for _, tt := range tests {
+		if got := PartitionForKey([]byte(tt.key), tt.n, tt.strategy); got != tt.want {
+			t.Errorf("PartitionForKey(%q, %d, %d) = %d, want %d", tt.key, tt.n, tt.strategy, got, tt.want)
+		}
+	}
+}
+
+func TestPartitionForKeyFNV(t *testing.T) {
+	// HashFNV1a has to agree with sarama's hash partitioner
+	p := sarama.NewHashPartitioner("t")
+	for _, n := range []int32{1, 3, 12, 100} {
+		for i := 0; i < 200; i++ {
+			key := []byte(fmt.Sprintf("key-%d", i))
+			want, err := p.Partition(&sarama.ProducerMessage{Key: sarama.ByteEncoder(key)}, n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := PartitionForKey(key, n, HashFNV1a); got != want {
+				t.Fatalf("PartitionForKey(%q, %d) = %d, sarama says %d", key, n, got, want)
+			}
+		}
+	}
+}
+
+func TestPreviewDistribution(t *testing.T) {
+	keys := [][]byte{[]byte("foobar"), []byte("foobar"), nil, []byte("abc")}
+	got := PreviewDistribution(keys, 10, HashMurmur2)
+
+	want := map[int32]int{-1: 1}
+	for p := int32(0); p < 10; p++ {
+		want[p] = 0
+	}
+	want[6] += 2
+	want[479470107%10]++
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLocateKey(t *testing.T) {
+	m := newMockCluster()
+	m.createTopic("t", 10)
+	m.produce("t", 6, nil, []byte("x"))
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	p, err := cli.LocateKey("t", []byte("foobar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Partition != 6 || p.End != 1 {
+		t.Errorf("got %+v, want partition 6 with 1 message", p)
+	}
+
+	if _, err := cli.LocateKey("t", nil); err == nil {
+		t.Error("expected an error for an empty key")
+	}
+}
+
+func TestPreviewTopicDistribution(t *testing.T) {
+	m := newMockCluster()
+	m.createTopic("t", 10)
+
+	cli, err := m.client(KeyHash(HashFNV1a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	keys := [][]byte{[]byte("a"), []byte("b"), []byte("c")}
+	got, err := cli.PreviewTopicDistribution("t", keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, PreviewDistribution(keys, 10, HashFNV1a)) {
+		t.Errorf("got %v, want the FNV-1a distribution over 10 partitions", got)
+	}
+
+	if _, err := cli.PreviewTopicDistribution("nope", keys); err == nil {
+		t.Error("expected an error for a topic that doesn't exist")
+	}
+}
@@ -0,0 +1,156 @@
+package kafka
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// TopicDefaults are the remembered settings of a topic.  Start, when
+// set, is where GetTopic puts each partition's Offset and Filter becomes
+// each partition's Filter.  PageSize is for the caller to use.  Decoder
+// names a decoder registered with NamedDecoder that is used for the
+// topic's messages instead of the Client's Decoder.
+type TopicDefaults struct {
+	Start    *OffsetSpec `json:"start,omitempty"`
+	PageSize int         `json:"page_size,omitempty"`
+	Filter   string      `json:"filter,omitempty"`
+	Decoder  string      `json:"decoder,omitempty"`
+}
+
+// PreferencesStore persists TopicDefaults by topic.
+type PreferencesStore interface {
+	Load() (map[string]TopicDefaults, error)
+	Save(map[string]TopicDefaults) error
+}
+
+// WithPreferences loads the topic defaults from s when the Client is
+// created and saves them to it on every SetTopicDefaults.
+func WithPreferences(s PreferencesStore) Opt {
+	return func(c *Client) {
+		c.prefs.store = s
+	}
+}
+
+// NamedDecoder registers d under name so TopicDefaults can route topics
+// to it.
+func NamedDecoder(name string, d Decoder) Opt {
+	return func(c *Client) {
+		if c.prefs.decoders == nil {
+			c.prefs.decoders = map[string]Decoder{}
+		}
+		c.prefs.decoders[name] = d
+	}
+}
+
+// preferences are the Client's TopicDefaults
+type preferences struct {
+	mu       sync.Mutex
+	store    PreferencesStore
+	topics   map[string]TopicDefaults
+	decoders map[string]Decoder
+}
+
+func (p *preferences) load() error {
+	p.topics = map[string]TopicDefaults{}
+	if p.store == nil {
+		return nil
+	}
+
+	topics, err := p.store.Load()
+	if err != nil {
+		return err
+	}
+	for t, d := range topics {
+		p.topics[t] = d
+	}
+	return nil
+}
+
+func (p *preferences) get(topic string) (TopicDefaults, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d, ok := p.topics[topic]
+	return d, ok
+}
+
+// decoder returns the decoder the topic is routed to, if any
+func (p *preferences) decoder(topic string) Decoder {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if name := p.topics[topic].Decoder; name != "" {
+		return p.decoders[name]
+	}
+	return nil
+}
+
+// SetTopicDefaults remembers d for topic (and saves every topic's
+// defaults to the PreferencesStore, if there is one).  A zero d forgets
+// the topic.
+func (c *Client) SetTopicDefaults(topic string, d TopicDefaults) error {
+	p := &c.prefs
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if d == (TopicDefaults{}) {
+		delete(p.topics, topic)
+	} else {
+		p.topics[topic] = d
+	}
+
+	if p.store == nil {
+		return nil
+	}
+	return p.store.Save(p.topics)
+}
+
+// GetTopicDefaults returns the defaults of topic and whether it has any.
+func (c *Client) GetTopicDefaults(topic string) (TopicDefaults, bool) {
+	return c.prefs.get(topic)
+}
+
+// FilePreferences is a PreferencesStore that keeps the defaults in a
+// JSON file.
+type FilePreferences struct {
+	Path string
+}
+
+// Load reads the file.  A file that doesn't exist yet holds no defaults.
+func (f FilePreferences) Load() (map[string]TopicDefaults, error) {
+	d, err := ioutil.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return map[string]TopicDefaults{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]TopicDefaults{}
+	return out, json.Unmarshal(d, &out)
+}
+
+// Save replaces the file, writing to a temporary file first so a crash
+// can't leave it half written.
+func (f FilePreferences) Save(topics map[string]TopicDefaults) error {
+	d, err := json.MarshalIndent(topics, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), filepath.Base(f.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(d); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
@@ -0,0 +1,147 @@
+package kafka
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+const progressInterval = 200 * time.Millisecond
+
+// Progress reports how far a Search, SearchTopic, Fetch, FetchTopic or
+// Export has got.  Scanned is the number of messages read so far and
+// Total the number there are to read (the sum of End-Offset of the
+// partitions), although a call can stop well before Scanned reaches
+// Total.  Matched is the number of messages found by a search or
+// delivered by a fetch.  Partition and Offset are where the most recent
+// message was read.
+type Progress struct {
+	Scanned   int64 `json:"scanned"`
+	Total     int64 `json:"total"`
+	Matched   int64 `json:"matched"`
+	Partition int32 `json:"partition"`
+	Offset    int64 `json:"offset"`
+}
+
+// WithProgress calls f every so often, and once more when the call is
+// done, with the call's Progress.  f is only ever called from a single
+// goroutine at a time and calls are throttled so it may be slow-ish.
+func WithProgress(f func(Progress)) CallOpt {
+	return func(o *callOpts) {
+		o.progress = f
+	}
+}
+
+// withProgress returns o with a progress reporter for a call that will
+// read total messages.  It returns a no-op close func when the call
+// has no WithProgress or is part of a call that is already reporting.
+func (o callOpts) withProgress(total int64) (callOpts, func()) {
+	if o.progress == nil || o.prog != nil {
+		return o, func() {}
+	}
+	o.prog = newProgress(total, o.progress)
+	return o, o.prog.close
+}
+
+// progressFunc adapts the (scanned, total) callbacks that Search and
+// SearchTopic take to a Progress callback.
+func progressFunc(cb func(int64, int64)) CallOpt {
+	return func(o *callOpts) {
+		if cb == nil {
+			return
+		}
+
+		f := o.progress
+		o.progress = func(p Progress) {
+			cb(p.Scanned, p.Total)
+			if f != nil {
+				f(p)
+			}
+		}
+	}
+}
+
+// progress aggregates what concurrent workers have read and reports it
+// to a callback at a throttled rate.  The callback is only ever called
+// from a single goroutine.  A nil progress reports nothing.
+type progress struct {
+	scanned   int64
+	matched   int64
+	partition int32
+	offset    int64
+	total     int64
+	cb        func(Progress)
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+func newProgress(total int64, cb func(Progress)) *progress {
+	p := &progress{
+		total: total,
+		cb:    cb,
+		done:  make(chan struct{}),
+	}
+
+	p.wg.Add(1)
+	go p.report()
+	return p
+}
+
+// scan counts a message that was read
+func (p *progress) scan(msg *sarama.ConsumerMessage) {
+	if p == nil {
+		return
+	}
+	atomic.AddInt64(&p.scanned, 1)
+	atomic.StoreInt32(&p.partition, msg.Partition)
+	atomic.StoreInt64(&p.offset, msg.Offset)
+}
+
+// match counts a message that was found or delivered
+func (p *progress) match() {
+	if p == nil {
+		return
+	}
+	atomic.AddInt64(&p.matched, 1)
+}
+
+func (p *progress) snapshot() Progress {
+	return Progress{
+		Scanned:   atomic.LoadInt64(&p.scanned),
+		Total:     p.total,
+		Matched:   atomic.LoadInt64(&p.matched),
+		Partition: atomic.LoadInt32(&p.partition),
+		Offset:    atomic.LoadInt64(&p.offset),
+	}
+}
+
+func (p *progress) report() {
+	defer p.wg.Done()
+	t := time.NewTicker(progressInterval)
+	defer t.Stop()
+
+	var last Progress
+	for {
+		select {
+		case <-t.C:
+			if s := p.snapshot(); s != last {
+				p.cb(s)
+				last = s
+			}
+		case <-p.done:
+			p.cb(p.snapshot())
+			return
+		}
+	}
+}
+
+// close stops the reporter after sending the final count.
+func (p *progress) close() {
+	if p == nil {
+		return
+	}
+	close(p.done)
+	p.wg.Wait()
+}
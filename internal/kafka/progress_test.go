@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFetchTopicProgress(t *testing.T) {
+	m := newMockCluster()
+	for i := 0; i < 6; i++ {
+		m.produce("t", int32(i%2), nil, []byte{byte('a' + i)})
+	}
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	parts, err := cli.GetTopic("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls int
+	var last Progress
+	_, err = cli.FetchTopic(context.Background(), parts, 10, func(Message) bool { return false }, WithProgress(func(p Progress) {
+		calls++
+		last = p
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls == 0 || last.Scanned != 6 || last.Total != 6 || last.Matched != 6 {
+		t.Errorf("got %d calls, the last with %+v, want 6 scanned and matched out of 6", calls, last)
+	}
+}
+
+func TestSearchProgress(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, nil, []byte("x"))
+	m.produce("t", 0, nil, []byte("x"))
+	m.produce("t", 0, nil, []byte("needle"))
+	m.produce("t", 0, nil, []byte("x"))
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	// the (scanned, total) callback and WithProgress see the same counts
+	var scanned, total int64
+	var last Progress
+	off, err := cli.Search(Partition{Topic: "t", Partition: 0, End: 4}, "needle", func(s, t int64) { scanned, total = s, t }, WithProgress(func(p Progress) { last = p }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if off != 2 {
+		t.Errorf("found the needle at %d, want 2", off)
+	}
+	if scanned != 3 || total != 4 {
+		t.Errorf("got %d of %d scanned, want 3 of 4", scanned, total)
+	}
+	if last.Scanned != 3 || last.Matched != 1 || last.Offset != 2 {
+		t.Errorf("got %+v, want 3 scanned and a match at 2", last)
+	}
+}
+
+func TestProgressNil(t *testing.T) {
+	var p *progress
+	p.scan(nil)
+	p.match()
+	p.close()
+
+	o, done := callOpts{}.withProgress(10)
+	defer done()
+	if o.prog != nil {
+		t.Error("a call without WithProgress has a reporter")
+	}
+}
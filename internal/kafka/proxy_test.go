@@ -0,0 +1,259 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// socksServer is a SOCKS5 proxy (no auth, CONNECT only) that records the
+// address of every connection it makes.
+type socksServer struct {
+	ln    net.Listener
+	lock  sync.Mutex
+	dials []string
+}
+
+func newSocksServer(t *testing.T) *socksServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &socksServer{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *socksServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	// greeting: version, number of methods, methods
+	buf := make([]byte, 262)
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+		return
+	}
+	if _, err := conn.Write([]byte{5, 0}); err != nil {
+		return
+	}
+
+	// request: version, command, reserved, address type, address, port
+	if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+		return
+	}
+	var host string
+	switch buf[3] {
+	case 1:
+		if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+			return
+		}
+		host = net.IP(buf[:4]).String()
+	case 3:
+		if _, err := io.ReadFull(conn, buf[:1]); err != nil {
+			return
+		}
+		n := int(buf[0])
+		if _, err := io.ReadFull(conn, buf[:n]); err != nil {
+			return
+		}
+		host = string(buf[:n])
+	default:
+		return
+	}
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2]))))
+
+	target, err := net.Dial("tcp", addr)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+
+	s.lock.Lock()
+	s.dials = append(s.dials, addr)
+	s.lock.Unlock()
+
+	if _, err := conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
+
+// dialed returns the number of connections made to each address
+func (s *socksServer) dialed() map[string]int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	out := map[string]int{}
+	for _, a := range s.dials {
+		out[a]++
+	}
+	return out
+}
+
+// TestProxyURL reads a partition and a group's offsets from a broker
+// that is only known from the metadata, all through a SOCKS proxy.
+func TestProxyURL(t *testing.T) {
+	socks := newSocksServer(t)
+
+	seed := sarama.NewMockBroker(t, 1)
+	defer seed.Close()
+	other := sarama.NewMockBroker(t, 2)
+	defer other.Close()
+
+	metadata := sarama.NewMockMetadataResponse(t).
+		SetBroker(seed.Addr(), seed.BrokerID()).
+		SetBroker(other.Addr(), other.BrokerID()).
+		SetController(other.BrokerID()).
+		SetLeader("t", 0, other.BrokerID())
+	seed.SetHandlerByMap(map[string]sarama.MockResponse{"MetadataRequest": metadata})
+	other.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": metadata,
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("t", 0, sarama.OffsetOldest, 0).
+			SetOffset("t", 0, sarama.OffsetNewest, 1),
+		"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			SetMessage("t", 0, 0, sarama.StringEncoder("a")).
+			SetHighWaterMark("t", 0, 1),
+		"FindCoordinatorRequest": sarama.NewMockFindCoordinatorResponse(t).
+			SetCoordinator(sarama.CoordinatorGroup, "g", other),
+		"OffsetFetchRequest": sarama.NewMockOffsetFetchResponse(t).
+			SetOffset("g", "t", 0, 1, "", sarama.ErrNoError),
+	})
+
+	cli, err := New([]string{seed.Addr()}, ProxyURL("socks5://"+socks.ln.Addr().String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	if n := socks.dialed()[seed.Addr()]; n == 0 {
+		t.Fatalf("the metadata client didn't connect through the proxy: %v", socks.dialed())
+	}
+
+	before := socks.dialed()[other.Addr()]
+	msgs, err := cli.GetPartition(Partition{Topic: "t", Partition: 0, End: 1}, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a"}; !reflect.DeepEqual(values(msgs), want) {
+		t.Errorf("got %v, want %v", values(msgs), want)
+	}
+	if n := socks.dialed()[other.Addr()]; n <= before {
+		t.Errorf("the consumer didn't connect to %s through the proxy: %v", other.Addr(), socks.dialed())
+	}
+
+	before = socks.dialed()[other.Addr()]
+	offsets, err := cli.DumpGroupOffsets("g")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (GroupOffsets{"t": {0: {Offset: 1}}}); !reflect.DeepEqual(offsets, want) {
+		t.Errorf("got %+v, want %+v", offsets, want)
+	}
+	if n := socks.dialed()[other.Addr()]; n <= before {
+		t.Errorf("the admin client didn't connect to %s through the proxy: %v", other.Addr(), socks.dialed())
+	}
+}
+
+func TestProxyURLInvalid(t *testing.T) {
+	if _, err := newMockCluster().client(ProxyURL("ftp://bastion:21")); err == nil {
+		t.Error("expected an error for a proxy url that isn't socks5")
+	}
+
+	cli, err := newMockCluster().client(ProxyURL("ftp://bastion:21"), ProxyURL("socks5://bastion:1080"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli.Close()
+}
+
+// countingDialer dials directly and counts the connections.  It only
+// has DialContext.
+type countingDialer struct {
+	lock sync.Mutex
+	n    int
+}
+
+func (d *countingDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	d.lock.Lock()
+	d.n++
+	d.lock.Unlock()
+	var nd net.Dialer
+	return nd.DialContext(ctx, network, addr)
+}
+
+func TestWithDialer(t *testing.T) {
+	mb := sarama.NewMockBroker(t, 1)
+	defer mb.Close()
+	mb.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(mb.Addr(), mb.BrokerID()).
+			SetController(mb.BrokerID()),
+	})
+
+	d := &countingDialer{}
+	cli, err := New([]string{mb.Addr()}, WithDialer(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.n == 0 {
+		t.Error("the client didn't connect with the dialer")
+	}
+}
+
+// hangingDialer never connects, it waits for the dial to be cancelled
+type hangingDialer struct{}
+
+func (hangingDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestBrokerDialerTimeout(t *testing.T) {
+	d := &brokerDialer{forward: hangingDialer{}, timeout: 50 * time.Millisecond}
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := d.Dial("tcp", "127.0.0.1:9092")
+		errs <- err
+	}()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the dial wasn't cancelled after the dial timeout")
+	}
+}
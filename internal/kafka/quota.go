@@ -0,0 +1,254 @@
+package kafka
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/IBM/sarama"
+)
+
+// Quota entity types
+const (
+	QuotaUser     = "user"
+	QuotaClientID = "client-id"
+	QuotaIP       = "ip"
+)
+
+// Quota keys
+const (
+	QuotaProducerByteRate  = "producer_byte_rate"
+	QuotaConsumerByteRate  = "consumer_byte_rate"
+	QuotaRequestPercentage = "request_percentage"
+)
+
+// QuotaEntity is one part of the entity a quota applies to (ie the user
+// of a user/client-id pair).  A nil Name is the default entity of Type,
+// the quota that applies to every user (or client, or ip) that doesn't
+// have its own.
+type QuotaEntity struct {
+	Type string  `json:"type"`
+	Name *string `json:"name"`
+}
+
+func (e QuotaEntity) String() string {
+	if e.Name == nil {
+		return e.Type + "=<default>"
+	}
+	return e.Type + "=" + *e.Name
+}
+
+// QuotaEntry is the quotas that are set for an entity, keyed by quota
+// key (QuotaProducerByteRate etc).
+type QuotaEntry struct {
+	Entity []QuotaEntity      `json:"entity"`
+	Values map[string]float64 `json:"values"`
+}
+
+// QuotaFilterComponent matches one part of an entity.  With Default set
+// it matches the default entity of Type, otherwise a nil Name matches any
+// name (including the default) and a non-nil Name only that name.
+type QuotaFilterComponent struct {
+	Type    string  `json:"type"`
+	Name    *string `json:"name,omitempty"`
+	Default bool    `json:"default,omitempty"`
+}
+
+// QuotaFilter selects the entries returned by DescribeClientQuotas.
+// With Strict set, entities that have parts other than the components'
+// types are left out.
+type QuotaFilter struct {
+	Components []QuotaFilterComponent `json:"components"`
+	Strict     bool                   `json:"strict"`
+}
+
+// QuotaAlteration sets, or with a nil value removes, quotas of an
+// entity.
+type QuotaAlteration struct {
+	Entity []QuotaEntity       `json:"entity"`
+	Values map[string]*float64 `json:"values"`
+}
+
+// DescribeClientQuotas returns the quotas that match filter in entity
+// order.  It needs kafka 2.6 or later, with an older KafkaVersion it
+// returns ErrUnsupportedVersion.
+func (c *Client) DescribeClientQuotas(filter QuotaFilter) ([]QuotaEntry, error) {
+	if err := c.checkVersion(sarama.V2_6_0_0, "describing client quotas"); err != nil {
+		return nil, wrapErr("describe client quotas", "", -1, -1, err)
+	}
+
+	req := &sarama.DescribeClientQuotasRequest{Strict: filter.Strict}
+	for _, fc := range filter.Components {
+		if err := checkQuotaType(fc.Type); err != nil {
+			return nil, wrapErr("describe client quotas", "", -1, -1, err)
+		}
+
+		q := sarama.QuotaFilterComponent{EntityType: sarama.QuotaEntityType(fc.Type), MatchType: sarama.QuotaMatchAny}
+		switch {
+		case fc.Default && fc.Name != nil:
+			return nil, wrapErr("describe client quotas", "", -1, -1, fmt.Errorf("%s filter has both a name and default set", fc.Type))
+		case fc.Default:
+			q.MatchType = sarama.QuotaMatchDefault
+		case fc.Name != nil:
+			q.MatchType, q.Match = sarama.QuotaMatchExact, *fc.Name
+		}
+		req.Components = append(req.Components, q)
+	}
+
+	b, err := c.sarama.Controller()
+	if err != nil {
+		return nil, wrapErr("describe client quotas", "", -1, -1, err)
+	}
+
+	resp, err := b.DescribeClientQuotas(req)
+	if err == nil {
+		err = quotaErr(resp.ErrorCode, resp.ErrorMsg)
+	}
+	if err != nil {
+		return nil, wrapErr("describe client quotas", "", -1, -1, err)
+	}
+
+	out := make([]QuotaEntry, 0, len(resp.Entries))
+	for _, e := range resp.Entries {
+		entry := QuotaEntry{Values: e.Values}
+		for _, ec := range e.Entity {
+			qe := QuotaEntity{Type: string(ec.EntityType)}
+			if ec.MatchType != sarama.QuotaMatchDefault {
+				name := ec.Name
+				qe.Name = &name
+			}
+			entry.Entity = append(entry.Entity, qe)
+		}
+		out = append(out, entry)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return quotaEntityString(out[i].Entity) < quotaEntityString(out[j].Entity) })
+	return out, nil
+}
+
+// AlterClientQuotas applies alterations.  It requires AllowDestructive
+// and kafka 2.6 or later (ErrUnsupportedVersion).  Nothing is changed unless every alteration is
+// valid, but the broker applies each entity on its own so an error can
+// leave some of them altered.
+func (c *Client) AlterClientQuotas(alterations []QuotaAlteration) error {
+	if err := c.checkDestructive(); err != nil {
+		return err
+	}
+
+	if err := c.checkVersion(sarama.V2_6_0_0, "altering client quotas"); err != nil {
+		return wrapErr("alter client quotas", "", -1, -1, err)
+	}
+
+	req := &sarama.AlterClientQuotasRequest{}
+	for _, a := range alterations {
+		if err := checkQuotaEntity(a.Entity); err != nil {
+			return wrapErr("alter client quotas", "", -1, -1, err)
+		}
+
+		entry := sarama.AlterClientQuotasEntry{}
+		for _, e := range a.Entity {
+			ec := sarama.QuotaEntityComponent{EntityType: sarama.QuotaEntityType(e.Type), MatchType: sarama.QuotaMatchDefault}
+			if e.Name != nil {
+				ec.MatchType, ec.Name = sarama.QuotaMatchExact, *e.Name
+			}
+			entry.Entity = append(entry.Entity, ec)
+		}
+
+		for k, v := range a.Values {
+			if err := checkQuotaKey(k); err != nil {
+				return wrapErr("alter client quotas", "", -1, -1, err)
+			}
+			if v != nil && *v < 0 {
+				return wrapErr("alter client quotas", "", -1, -1, fmt.Errorf("%s of %s can't be negative", k, quotaEntityString(a.Entity)))
+			}
+
+			op := sarama.ClientQuotasOp{Key: k, Remove: v == nil}
+			if v != nil {
+				op.Value = *v
+			}
+			entry.Ops = append(entry.Ops, op)
+		}
+		sort.Slice(entry.Ops, func(i, j int) bool { return entry.Ops[i].Key < entry.Ops[j].Key })
+		req.Entries = append(req.Entries, entry)
+	}
+
+	b, err := c.sarama.Controller()
+	if err != nil {
+		return wrapErr("alter client quotas", "", -1, -1, err)
+	}
+
+	resp, err := b.AlterClientQuotas(req)
+	if err != nil {
+		return wrapErr("alter client quotas", "", -1, -1, err)
+	}
+
+	for i, e := range resp.Entries {
+		if err := quotaErr(e.ErrorCode, e.ErrorMsg); err != nil {
+			entity := "entity"
+			if i < len(alterations) {
+				entity = quotaEntityString(alterations[i].Entity)
+			}
+			return wrapErr("alter client quotas", "", -1, -1, fmt.Errorf("%s: %w", entity, err))
+		}
+	}
+	return nil
+}
+
+// quotaErr prefers the broker's message to the bare error code
+func quotaErr(code sarama.KError, msg *string) error {
+	switch {
+	case code == sarama.ErrNoError:
+		return nil
+	case msg != nil && *msg != "":
+		return fmt.Errorf("%w: %s", code, *msg)
+	}
+	return code
+}
+
+func checkQuotaType(t string) error {
+	switch t {
+	case QuotaUser, QuotaClientID, QuotaIP:
+		return nil
+	}
+	return fmt.Errorf("unknown quota entity type %q", t)
+}
+
+func checkQuotaKey(k string) error {
+	switch k {
+	case QuotaProducerByteRate, QuotaConsumerByteRate, QuotaRequestPercentage:
+		return nil
+	}
+	return fmt.Errorf("unknown quota %q", k)
+}
+
+// checkQuotaEntity allows a user, a client-id, a user/client-id pair or
+// an ip, which are the entities kafka accepts.
+func checkQuotaEntity(entity []QuotaEntity) error {
+	seen := map[string]bool{}
+	for _, e := range entity {
+		if err := checkQuotaType(e.Type); err != nil {
+			return err
+		}
+		if seen[e.Type] {
+			return fmt.Errorf("entity %s has more than one %s", quotaEntityString(entity), e.Type)
+		}
+		seen[e.Type] = true
+	}
+
+	switch {
+	case len(entity) == 0:
+		return fmt.Errorf("empty quota entity")
+	case seen[QuotaIP] && len(entity) > 1:
+		return fmt.Errorf("ip quotas can't be combined with other entity types: %s", quotaEntityString(entity))
+	}
+	return nil
+}
+
+func quotaEntityString(entity []QuotaEntity) string {
+	parts := make([]string, len(entity))
+	for i, e := range entity {
+		parts[i] = e.String()
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
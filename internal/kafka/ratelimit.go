@@ -0,0 +1,90 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimit caps how fast messages are read, in messages and bytes (keys
+// plus values) per second, with 0 meaning unlimited.  The limit is shared
+// by everything the Client reads, so concurrent searches and fetches over
+// many partitions are bounded in aggregate.
+func RateLimit(messagesPerSec int, bytesPerSec int64) Opt {
+	return func(c *Client) {
+		if messagesPerSec <= 0 && bytesPerSec <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = newRateLimiter(float64(messagesPerSec), float64(bytesPerSec))
+	}
+}
+
+// rateLimiter is a pair of token buckets (one for messages, one for
+// bytes) that each hold up to a second's worth of tokens.
+type rateLimiter struct {
+	mu    sync.Mutex
+	last  time.Time
+	msgs  bucket
+	bytes bucket
+}
+
+type bucket struct {
+	rate   float64
+	tokens float64
+}
+
+func newRateLimiter(msgs, bytes float64) *rateLimiter {
+	return &rateLimiter{
+		last:  time.Now(),
+		msgs:  bucket{rate: msgs, tokens: msgs},
+		bytes: bucket{rate: bytes, tokens: bytes},
+	}
+}
+
+// take reserves the tokens for n bytes worth of message and returns how
+// long the caller has to wait before using them.
+func (b *bucket) take(n, elapsed float64) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+
+	b.tokens += elapsed * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// wait blocks until a message of size bytes may be read.
+func (r *rateLimiter) wait(ctx context.Context, size int) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	now := time.Now()
+	elapsed := now.Sub(r.last).Seconds()
+	r.last = now
+	d := r.msgs.take(1, elapsed)
+	if bd := r.bytes.take(float64(size), elapsed); bd > d {
+		d = bd
+	}
+	r.mu.Unlock()
+
+	if d <= 0 {
+		return nil
+	}
+
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,90 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBucket(t *testing.T) {
+	b := bucket{rate: 10, tokens: 10}
+
+	// a full bucket lets a second's worth through without waiting
+	for i := 0; i < 10; i++ {
+		if d := b.take(1, 0); d != 0 {
+			t.Fatalf("take %d waited %s", i, d)
+		}
+	}
+
+	if d := b.take(1, 0); d != 100*time.Millisecond {
+		t.Errorf("an empty bucket waited %s, want 100ms", d)
+	}
+
+	// the wait is owed, so half a second refills 5 tokens minus the one
+	// that was already taken
+	b.take(0, 0.5)
+	if b.tokens != 4 {
+		t.Errorf("got %v tokens, want 4", b.tokens)
+	}
+
+	// refills never go past a second's worth
+	b.take(0, 60)
+	if b.tokens != 10 {
+		t.Errorf("got %v tokens, want 10", b.tokens)
+	}
+
+	unlimited := bucket{}
+	if d := unlimited.take(1e9, 0); d != 0 {
+		t.Errorf("an unlimited bucket waited %s", d)
+	}
+}
+
+func TestRateLimiterBytes(t *testing.T) {
+	r := newRateLimiter(0, 100)
+	ctx := context.Background()
+	if err := r.wait(ctx, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if err := r.wait(ctx, 20); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 150*time.Millisecond {
+		t.Errorf("waited %s for 20 bytes at 100 a second, want about 200ms", d)
+	}
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	r := newRateLimiter(1, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := r.wait(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	if err := r.wait(ctx, 0); err != context.Canceled {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+
+	var none *rateLimiter
+	if err := none.wait(ctx, 1<<30); err != nil {
+		t.Errorf("a nil limiter returned %v", err)
+	}
+}
+
+func TestRateLimitOpt(t *testing.T) {
+	cli, err := newMockCluster().client(RateLimit(5, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+	if cli.limiter == nil || cli.limiter.msgs.rate != 5 || cli.limiter.bytes.rate != 0 {
+		t.Errorf("got limiter %+v", cli.limiter)
+	}
+
+	RateLimit(0, 0)(cli)
+	if cli.limiter != nil {
+		t.Error("RateLimit(0, 0) didn't remove the limit")
+	}
+}
@@ -0,0 +1,154 @@
+package kafka
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Reassignment is a partition that is being moved between brokers.
+// Replicas is the full replica set during the move, Adding the brokers
+// that are catching up and Removing the ones that will be dropped once
+// they have.
+type Reassignment struct {
+	Topic     string  `json:"topic"`
+	Partition int32   `json:"partition"`
+	Replicas  []int32 `json:"replicas"`
+	Adding    []int32 `json:"adding"`
+	Removing  []int32 `json:"removing"`
+}
+
+// ReplicaProgress estimates how far a replica that is being added has
+// got: Size is its size on disk and Target the size of the largest
+// existing replica.
+type ReplicaProgress struct {
+	Broker   int32   `json:"broker"`
+	Size     int64   `json:"size"`
+	Target   int64   `json:"target"`
+	Fraction float64 `json:"fraction"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// ListPartitionReassignments returns the in progress reassignments of
+// topic (of every topic when topic is empty), in topic and partition
+// order.  It needs kafka 2.4 or later, with an older KafkaVersion it
+// returns ErrUnsupportedVersion.  ReassignmentProgress also works
+// with a Reassignment built by hand, ie from the output of
+// kafka-reassign-partitions.
+func (c *Client) ListPartitionReassignments(topic string) ([]Reassignment, error) {
+	if err := c.checkVersion(sarama.V2_4_0_0, "listing partition reassignments"); err != nil {
+		return nil, wrapErr("list partition reassignments", topic, -1, -1, err)
+	}
+
+	topics := []string{topic}
+	if topic == "" {
+		var err error
+		if topics, err = c.GetTopics(); err != nil {
+			return nil, err
+		}
+	}
+
+	req := &sarama.ListPartitionReassignmentsRequest{TimeoutMs: int32(c.cfg.Admin.Timeout / time.Millisecond)}
+	for _, t := range topics {
+		parts, err := c.sarama.Partitions(t)
+		if err != nil {
+			return nil, wrapErr("list partition reassignments", t, -1, -1, err)
+		}
+		req.AddBlock(t, parts)
+	}
+
+	b, err := c.sarama.Controller()
+	if err != nil {
+		return nil, wrapErr("list partition reassignments", topic, -1, -1, err)
+	}
+
+	resp, err := b.ListPartitionReassignments(req)
+	if err != nil {
+		return nil, wrapErr("list partition reassignments", topic, -1, -1, err)
+	}
+
+	if resp.ErrorCode != sarama.ErrNoError {
+		err := error(resp.ErrorCode)
+		if resp.ErrorMessage != nil && *resp.ErrorMessage != "" {
+			err = fmt.Errorf("%w: %s", resp.ErrorCode, *resp.ErrorMessage)
+		}
+		return nil, wrapErr("list partition reassignments", topic, -1, -1, err)
+	}
+
+	var out []Reassignment
+	for t, parts := range resp.TopicStatus {
+		for p, st := range parts {
+			out = append(out, Reassignment{
+				Topic:     t,
+				Partition: p,
+				Replicas:  st.Replicas,
+				Adding:    st.AddingReplicas,
+				Removing:  st.RemovingReplicas,
+			})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Topic != out[j].Topic {
+			return out[i].Topic < out[j].Topic
+		}
+		return out[i].Partition < out[j].Partition
+	})
+	return out, nil
+}
+
+// ReassignmentProgress estimates how much of r's partition each of the
+// Adding brokers has copied by comparing the size of their replica on
+// disk to that of the largest of the other replicas.  Brokers whose log
+// dirs couldn't be read are returned with Error set.
+func (c *Client) ReassignmentProgress(r Reassignment) ([]ReplicaProgress, error) {
+	dirs, err := c.logDirs([]sarama.DescribeLogDirsRequestTopic{{Topic: r.Topic, PartitionIDs: []int32{r.Partition}}})
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := map[int32]int64{}
+	errs := map[int32]string{}
+	for id, ds := range dirs {
+		for _, d := range ds {
+			if d.Error != "" {
+				errs[id] = d.Error
+				continue
+			}
+			for _, rs := range d.Replicas {
+				if rs.Topic == r.Topic && rs.Partition == r.Partition && rs.Size > sizes[id] {
+					sizes[id] = rs.Size
+				}
+			}
+		}
+	}
+
+	adding := map[int32]bool{}
+	for _, id := range r.Adding {
+		adding[id] = true
+	}
+
+	var target int64
+	for _, id := range r.Replicas {
+		if !adding[id] && sizes[id] > target {
+			target = sizes[id]
+		}
+	}
+
+	out := make([]ReplicaProgress, 0, len(r.Adding))
+	for _, id := range r.Adding {
+		p := ReplicaProgress{Broker: id, Size: sizes[id], Target: target, Error: errs[id]}
+		switch {
+		case target == 0:
+			p.Fraction = 1
+		case p.Size < target:
+			p.Fraction = float64(p.Size) / float64(target)
+		default:
+			p.Fraction = 1
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
@@ -0,0 +1,151 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Encoding is a guess at what a value holds
+type Encoding int
+
+const (
+	// EncodingBinary is anything that isn't valid, printable UTF-8
+	EncodingBinary Encoding = iota
+	// EncodingText is printable UTF-8
+	EncodingText
+	// EncodingJSON is a JSON object or array
+	EncodingJSON
+	// EncodingBase64 is text made up only of standard base64
+	EncodingBase64
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case EncodingText:
+		return "text"
+	case EncodingJSON:
+		return "json"
+	case EncodingBase64:
+		return "base64"
+	}
+	return "binary"
+}
+
+// DetectEncoding classifies data.  It's a heuristic: a short word made of
+// base64 characters (ie "test") is reported as text, not base64.
+func DetectEncoding(data []byte) Encoding {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return EncodingJSON
+	}
+
+	if !utf8.Valid(data) {
+		return EncodingBinary
+	}
+
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return EncodingBinary
+		}
+	}
+
+	if looksBase64(trimmed) {
+		return EncodingBase64
+	}
+
+	return EncodingText
+}
+
+// looksBase64 requires at least 16 characters so that ordinary words
+// aren't mistaken for base64.
+func looksBase64(data []byte) bool {
+	if len(data) < 16 || len(data)%4 != 0 {
+		return false
+	}
+
+	_, err := base64.StdEncoding.DecodeString(string(data))
+	return err == nil
+}
+
+// RenderHexDump returns an xxd style dump of data with width bytes per
+// line (16 if width < 1).  Bytes that aren't printable ASCII, including
+// every byte of a multi-byte UTF-8 character, are shown as '.' in the text
+// column so that lines always line up.
+func RenderHexDump(data []byte, width int) string {
+	if width < 1 {
+		width = 16
+	}
+
+	var b strings.Builder
+	for off := 0; off < len(data); off += width {
+		end := off + width
+		if end > len(data) {
+			end = len(data)
+		}
+		line := data[off:end]
+
+		fmt.Fprintf(&b, "%08x: ", off)
+		for i := 0; i < width; i++ {
+			if i < len(line) {
+				fmt.Fprintf(&b, "%02x", line[i])
+			} else {
+				b.WriteString("  ")
+			}
+			if i%2 == 1 {
+				b.WriteByte(' ')
+			}
+		}
+
+		b.WriteByte(' ')
+		for _, c := range line {
+			if c >= 0x20 && c < 0x7f {
+				b.WriteByte(c)
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Rendering controls how Fetch turns values into strings
+type Rendering int
+
+const (
+	// RenderRaw passes values through as is (the default)
+	RenderRaw Rendering = iota
+	// RenderAuto hex dumps binary values and leaves text alone
+	RenderAuto
+	// RenderHex hex dumps every value
+	RenderHex
+	// RenderBase64 base64 encodes every value
+	RenderBase64
+)
+
+// BinaryRendering sets how Fetch renders values for its string callback.
+func BinaryRendering(r Rendering) Opt {
+	return func(c *Client) {
+		c.rendering = r
+	}
+}
+
+// render turns a value into a string according to r
+func (r Rendering) render(val []byte) string {
+	switch r {
+	case RenderHex:
+		return RenderHexDump(val, 16)
+	case RenderBase64:
+		return base64.StdEncoding.EncodeToString(val)
+	case RenderAuto:
+		if DetectEncoding(val) == EncodingBinary {
+			return RenderHexDump(val, 16)
+		}
+	}
+	return string(val)
+}
@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Encoding
+	}{
+		{in: `{"a": 1}`, want: EncodingJSON},
+		{in: ` [1, 2] `, want: EncodingJSON},
+		{in: `{"a": `, want: EncodingText},
+		{in: "hello world\n", want: EncodingText},
+		{in: "test", want: EncodingText},
+		{in: "aGVsbG8gd29ybGQgaGVsbG8=", want: EncodingBase64},
+		{in: "héllo", want: EncodingText},
+		{in: "\x00\x16avro", want: EncodingBinary},
+		{in: "\xff\xfe", want: EncodingBinary},
+		{in: "", want: EncodingText},
+	}
+
+	for _, tt := range tests {
+		if got := DetectEncoding([]byte(tt.in)); got != tt.want {
+			t.Errorf("DetectEncoding(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenderHexDump(t *testing.T) {
+	got := RenderHexDump([]byte("hello, world\x00\x01é!"), 8)
+	want := "00000000: 6865 6c6c 6f2c 2077  hello, w\n" +
+		"00000008: 6f72 6c64 0001 c3a9  orld....\n" +
+		"00000010: 21                   !\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	if got := RenderHexDump(nil, 0); got != "" {
+		t.Errorf("got %q for no data", got)
+	}
+}
+
+func TestRendering(t *testing.T) {
+	bin := []byte{0, 1}
+	hex := "00000000: 0001                                     ..\n"
+
+	tests := []struct {
+		r    Rendering
+		in   []byte
+		want string
+	}{
+		{r: RenderRaw, in: bin, want: "\x00\x01"},
+		{r: RenderAuto, in: bin, want: hex},
+		{r: RenderAuto, in: []byte("text"), want: "text"},
+		{r: RenderHex, in: bin, want: hex},
+		{r: RenderBase64, in: []byte("hi"), want: "aGk="},
+	}
+
+	for _, tt := range tests {
+		if got := tt.r.render(tt.in); got != tt.want {
+			t.Errorf("rendering %d of %q = %q, want %q", tt.r, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFetchRendering(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, nil, []byte{0xff})
+	m.produce("t", 0, nil, []byte("ok"))
+
+	cli, err := m.client(BinaryRendering(RenderAuto))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	var got []string
+	if err := cli.Fetch(Partition{Topic: "t", Partition: 0, End: 2}, 10, func(s string) { got = append(got, s) }); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{RenderHexDump([]byte{0xff}, 16), "ok"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
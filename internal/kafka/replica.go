@@ -0,0 +1,217 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// ErrNotReplica means a broker doesn't host the partition that was asked
+// for.
+var ErrNotReplica = errors.New("kafka: broker is not a replica of the partition")
+
+// FetchFromReplica sends up to end decoded messages, starting at
+// part.Offset, from broker brokerID's copy of the partition to cb, using
+// fetch requests sent straight to that broker.  It stops when cb returns
+// true, ctx is cancelled or it reaches the replica's high water mark.
+// Messages from aborted transactions are included, the point is to see
+// exactly what the replica has.
+//
+// Followers only serve fetches from clients (KIP-392) from kafka 2.4.0
+// on, so when KafkaVersion is older than that only the leader can be
+// read.  It returns ErrNotReplica if the broker doesn't host the
+// partition at all.
+func (c *Client) FetchFromReplica(ctx context.Context, part Partition, brokerID int32, end int64, cb func(Message) bool, opts ...CallOpt) error {
+	o := getCallOpts(opts)
+	b, err := c.replica(part, brokerID)
+	if err != nil {
+		return err
+	}
+
+	max := c.cfg.Consumer.Fetch.Default
+	offset := part.Offset
+	var n int64
+	for n < end {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		req := c.replicaFetch()
+		req.AddBlock(part.Topic, part.Partition, offset, max, -1)
+
+		start := time.Now()
+		resp, err := b.Fetch(req)
+		c.traced(TraceFetchBatch, b, part.Topic, part.Partition, start, err)
+		if err != nil {
+			return wrapErr("fetch from replica", part.Topic, part.Partition, offset, err)
+		}
+
+		block := resp.GetBlock(part.Topic, part.Partition)
+		switch {
+		case block == nil:
+			return wrapErr("fetch from replica", part.Topic, part.Partition, offset, fmt.Errorf("broker %d sent no data", brokerID))
+		case block.Err == sarama.ErrNotLeaderForPartition && req.Version < 11:
+			return wrapErr("fetch from replica", part.Topic, part.Partition, offset, fmt.Errorf("broker %d is a follower, reading from followers needs KafkaVersion 2.4.0 or later: %w", brokerID, block.Err))
+		case block.Err != sarama.ErrNoError:
+			return wrapErr("fetch from replica", part.Topic, part.Partition, offset, block.Err)
+		}
+
+		msgs, next := replicaMessages(part, block, offset)
+		if len(msgs) == 0 {
+			switch {
+			case next > offset:
+				// nothing but transaction markers
+				offset = next
+				continue
+			case offset >= block.HighWaterMarkOffset:
+				return nil
+			}
+
+			// the next batch doesn't fit in max
+			if max, err = c.growFetch(max); err != nil {
+				return wrapErr("fetch from replica", part.Topic, part.Partition, offset, err)
+			}
+			continue
+		}
+
+		for _, msg := range msgs {
+			offset = msg.Offset + 1
+			if err := c.limiter.wait(ctx, len(msg.Key)+len(msg.Value)); err != nil {
+				return err
+			}
+
+			val, ok, err := c.decode(ctx, o, part.Topic, msg.Offset, msg.Value)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			m := newMessage(part, msg)
+			m.Value = val
+			n++
+			if cb(m) || n >= end {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// replicaFetch is a fetch request that any replica will answer when the
+// brokers are new enough to serve reads from followers.
+func (c *Client) replicaFetch() *sarama.FetchRequest {
+	req := &sarama.FetchRequest{
+		Version:     4,
+		MaxWaitTime: int32(c.cfg.Consumer.MaxWaitTime.Nanoseconds() / 1e6),
+		MinBytes:    1,
+		MaxBytes:    sarama.MaxResponseSize,
+	}
+
+	if c.cfg.Version.IsAtLeast(sarama.V2_4_0_0) {
+		// sessionless, and a consumer's rack makes a follower serve it
+		req.Version = 11
+		req.SessionEpoch = -1
+		req.RackID = c.cfg.RackID
+	}
+	return req
+}
+
+// replica returns a connection to brokerID after checking that it
+// hosts part
+func (c *Client) replica(part Partition, brokerID int32) (*sarama.Broker, error) {
+	replicas, err := c.sarama.Replicas(part.Topic, part.Partition)
+	if err != nil {
+		return nil, wrapErr("get replicas", part.Topic, part.Partition, -1, err)
+	}
+
+	var hosted bool
+	for _, id := range replicas {
+		hosted = hosted || id == brokerID
+	}
+	if !hosted {
+		return nil, wrapErr("fetch from replica", part.Topic, part.Partition, -1, fmt.Errorf("%w: broker %d (replicas are %v)", ErrNotReplica, brokerID, replicas))
+	}
+
+	for _, b := range c.sarama.Brokers() {
+		if b.ID() != brokerID {
+			continue
+		}
+		if err := b.Open(c.cfg); err != nil && err != sarama.ErrAlreadyConnected {
+			return nil, wrapErr("connect to broker", part.Topic, part.Partition, -1, err)
+		}
+		return b, nil
+	}
+
+	return nil, wrapErr("fetch from replica", part.Topic, part.Partition, -1, fmt.Errorf("broker %d is not in the cluster metadata", brokerID))
+}
+
+// replicaMessages unpacks the records in a fetch response block,
+// dropping everything before offset, control records and batches cut off
+// by the fetch size.  It also returns the offset after the last complete
+// record in the block.
+func replicaMessages(part Partition, block *sarama.FetchResponseBlock, offset int64) ([]*sarama.ConsumerMessage, int64) {
+	var out []*sarama.ConsumerMessage
+	next := offset
+	add := func(o int64, key, val []byte, ts time.Time, headers []*sarama.RecordHeader) {
+		if o < offset {
+			return
+		}
+		if o >= next {
+			next = o + 1
+		}
+		out = append(out, &sarama.ConsumerMessage{
+			Topic:     part.Topic,
+			Partition: part.Partition,
+			Offset:    o,
+			Key:       key,
+			Value:     val,
+			Timestamp: ts,
+			Headers:   headers,
+		})
+	}
+
+	for _, records := range block.RecordsSet {
+		if batch := records.RecordBatch; batch != nil {
+			if batch.PartialTrailingRecord {
+				// cut off by the fetch size, it has to be fetched again
+				continue
+			}
+			if n := batch.FirstOffset + int64(batch.LastOffsetDelta) + 1; n > next {
+				next = n
+			}
+			if batch.Control {
+				continue
+			}
+			for _, rec := range batch.Records {
+				ts := batch.FirstTimestamp.Add(rec.TimestampDelta)
+				if batch.LogAppendTime {
+					ts = batch.MaxTimestamp
+				}
+				add(batch.FirstOffset+rec.OffsetDelta, rec.Key, rec.Value, ts, rec.Headers)
+			}
+		}
+
+		if set := records.MsgSet; set != nil {
+			for _, outer := range set.Messages {
+				inner := outer.Messages()
+				for _, mb := range inner {
+					o, ts := mb.Offset, mb.Msg.Timestamp
+					if mb.Msg.Version >= 1 {
+						// offsets of compressed v1 messages are relative
+						o += outer.Offset - inner[len(inner)-1].Offset
+						if mb.Msg.LogAppendTime {
+							ts = outer.Msg.Timestamp
+						}
+					}
+					add(o, mb.Msg.Key, mb.Msg.Value, ts, nil)
+				}
+			}
+		}
+	}
+	return out, next
+}
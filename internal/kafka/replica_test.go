@@ -0,0 +1,288 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+// truncatingProxy sits in front of a mock broker and cuts the records of
+// every fetch response down to the partition's max bytes, the way
+// brokers did before KIP-74, so a batch bigger than the fetch size
+// arrives partial.  It expects one partition per fetch.
+type truncatingProxy struct {
+	t      *testing.T
+	ln     net.Listener
+	target string
+}
+
+func newTruncatingProxy(t *testing.T, target string) *truncatingProxy {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &truncatingProxy{t: t, ln: ln, target: target}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go p.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return p
+}
+
+func (p *truncatingProxy) Addr() string {
+	return p.ln.Addr().String()
+}
+
+// fetch is what the proxy needs to know about a fetch request to cut
+// down its response
+type fetch struct {
+	version int16
+	max     int32
+}
+
+func (p *truncatingProxy) serve(conn net.Conn) {
+	defer conn.Close()
+	target, err := net.Dial("tcp", p.target)
+	if err != nil {
+		p.t.Error(err)
+		return
+	}
+	defer target.Close()
+
+	var lock sync.Mutex
+	fetches := map[int32]fetch{}
+
+	go func() {
+		defer target.Close()
+		for {
+			req, err := readFrame(conn)
+			if err != nil {
+				return
+			}
+			if binary.BigEndian.Uint16(req) == 1 {
+				lock.Lock()
+				fetches[int32(binary.BigEndian.Uint32(req[4:]))] = parseFetch(req)
+				lock.Unlock()
+			}
+			if err := writeFrame(target, req); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		resp, err := readFrame(target)
+		if err != nil {
+			return
+		}
+
+		lock.Lock()
+		f, ok := fetches[int32(binary.BigEndian.Uint32(resp))]
+		lock.Unlock()
+		if ok {
+			resp = truncateFetch(resp, f)
+		}
+		if err := writeFrame(conn, resp); err != nil {
+			return
+		}
+	}
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint32(size[:]))
+	_, err := io.ReadFull(r, buf)
+	return buf, err
+}
+
+func writeFrame(w io.Writer, buf []byte) error {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(buf)))
+	_, err := w.Write(append(size[:], buf...))
+	return err
+}
+
+// parseFetch reads the version and partition max bytes of a fetch
+// request for a single partition
+func parseFetch(req []byte) fetch {
+	f := fetch{version: int16(binary.BigEndian.Uint16(req[2:]))}
+	i := 8
+	i += 2 + int(int16(binary.BigEndian.Uint16(req[i:]))) // client id
+	i += 12                                               // replica id, max wait, min bytes
+	if f.version >= 3 {
+		i += 4 // max bytes
+	}
+	if f.version >= 4 {
+		i++ // isolation level
+	}
+	if f.version >= 7 {
+		i += 8 // session id and epoch
+	}
+	i += 4                                             // topic count
+	i += 2 + int(binary.BigEndian.Uint16(req[i:])) + 4 // topic, partition count
+	i += 4 + 8                                         // partition, fetch offset
+	if f.version >= 9 {
+		i += 4 // current leader epoch
+	}
+	if f.version >= 5 {
+		i += 8 // log start offset
+	}
+	f.max = int32(binary.BigEndian.Uint32(req[i:]))
+	return f
+}
+
+// truncateFetch cuts the records of the single partition in resp down to
+// f.max bytes
+func truncateFetch(resp []byte, f fetch) []byte {
+	i := 4 // correlation id
+	if f.version >= 1 {
+		i += 4 // throttle time
+	}
+	if f.version >= 7 {
+		i += 6 // error code and session id
+	}
+	if binary.BigEndian.Uint32(resp[i:]) == 0 {
+		return resp
+	}
+	i += 4
+	i += 2 + int(binary.BigEndian.Uint16(resp[i:])) + 4 // topic, partition count
+	i += 4 + 2 + 8                                      // partition, error, high water mark
+	if f.version >= 4 {
+		i += 8 // last stable offset
+	}
+	if f.version >= 5 {
+		i += 8 // log start offset
+	}
+	if f.version >= 4 {
+		if n := int32(binary.BigEndian.Uint32(resp[i:])); n > 0 {
+			i += 16 * int(n)
+		}
+		i += 4
+	}
+	if f.version >= 11 {
+		i += 4 // preferred read replica
+	}
+
+	if int32(binary.BigEndian.Uint32(resp[i:])) <= f.max {
+		return resp
+	}
+	binary.BigEndian.PutUint32(resp[i:], uint32(f.max))
+	return resp[:i+4+int(f.max)]
+}
+
+// newFetchBroker starts a mock broker, behind a truncatingProxy, that
+// leads partition 0 of topic t and answers fetches with resp.  The
+// partition's offsets run from 0 to the high water mark of resp.
+func newFetchBroker(t *testing.T, resp *sarama.FetchResponse) *truncatingProxy {
+	mb := sarama.NewMockBroker(t, 1)
+	t.Cleanup(mb.Close)
+	proxy := newTruncatingProxy(t, mb.Addr())
+
+	mb.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(proxy.Addr(), mb.BrokerID()).
+			SetController(mb.BrokerID()).
+			SetLeader("t", 0, mb.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetOffset("t", 0, sarama.OffsetOldest, 0).
+			SetOffset("t", 0, sarama.OffsetNewest, resp.GetBlock("t", 0).HighWaterMarkOffset),
+		"FetchRequest": sarama.NewMockSequence(resp),
+	})
+	return proxy
+}
+
+// TestFetchFromReplicaLargeBatch reads a batch bigger than Fetch.Default
+// from a broker that cuts batches off at the fetch size.
+func TestFetchFromReplicaLargeBatch(t *testing.T) {
+	big := strings.Repeat("x", 4096)
+	resp := &sarama.FetchResponse{Version: 4}
+	resp.AddRecord("t", 0, nil, sarama.StringEncoder(big), 0)
+	resp.AddRecord("t", 0, nil, sarama.StringEncoder("small"), 1)
+	resp.GetBlock("t", 0).HighWaterMarkOffset = 2
+	proxy := newFetchBroker(t, resp)
+
+	cli, err := New([]string{proxy.Addr()}, FetchDefaultBytes(1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	var got []string
+	err = cli.FetchFromReplica(context.Background(), Partition{Topic: "t", Partition: 0}, 1, 10, func(m Message) bool {
+		got = append(got, string(m.Value))
+		return false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != big || got[1] != "small" {
+		t.Errorf("got %d messages, want the big one and then small", len(got))
+	}
+}
+
+// TestFetchFromReplicaTooLarge gives up on a batch that doesn't fit in
+// FetchMaxBytes.
+func TestFetchFromReplicaTooLarge(t *testing.T) {
+	resp := &sarama.FetchResponse{Version: 4}
+	resp.AddRecord("t", 0, nil, sarama.StringEncoder(strings.Repeat("x", 4096)), 0)
+	resp.GetBlock("t", 0).HighWaterMarkOffset = 1
+	proxy := newFetchBroker(t, resp)
+
+	cli, err := New([]string{proxy.Addr()}, FetchDefaultBytes(512), FetchMaxBytes(1024))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	err = cli.FetchFromReplica(context.Background(), Partition{Topic: "t", Partition: 0}, 1, 10, func(Message) bool { return false })
+	if !errors.Is(err, sarama.ErrMessageTooLarge) {
+		t.Errorf("got %v, want ErrMessageTooLarge", err)
+	}
+}
+
+func TestGrowFetch(t *testing.T) {
+	tests := []struct {
+		fetchMax, in, want int32
+		wantErr            bool
+	}{
+		{in: 1024, want: 2048},
+		{in: 0, want: 1},
+		{in: sarama.MaxResponseSize/2 + 1, want: sarama.MaxResponseSize},
+		{in: sarama.MaxResponseSize, wantErr: true},
+		{fetchMax: 3000, in: 2048, want: 3000},
+		{fetchMax: 3000, in: 3000, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		cli := &Client{cfg: sarama.NewConfig()}
+		cli.cfg.Consumer.Fetch.Max = tt.fetchMax
+		got, err := cli.growFetch(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("growFetch(%d) with a max of %d: err = %v, want error %v", tt.in, tt.fetchMax, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr && !errors.Is(err, sarama.ErrMessageTooLarge) {
+			t.Errorf("growFetch(%d) with a max of %d: got %v, want ErrMessageTooLarge", tt.in, tt.fetchMax, err)
+		}
+		if got != tt.want {
+			t.Errorf("growFetch(%d) with a max of %d = %d, want %d", tt.in, tt.fetchMax, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Headers added by Requeue
+const (
+	HeaderRequeuedFrom = "x-kcli-requeued-from"
+	HeaderRequeuedAt   = "x-kcli-requeued-at"
+)
+
+// RequeueOpts configures a Requeue
+type RequeueOpts struct {
+	// Topic is where the message is produced to.  Defaults to the
+	// message's own topic.
+	Topic string
+
+	// PreserveKey produces the message with its key, so it lands on the
+	// partition its key hashes to.  Without it the message is produced
+	// without a key.
+	PreserveKey bool
+
+	// StripRetryHeaders drops the message's x-kcli-requeued-* headers,
+	// and any header with "retry" in its name, before the new ones are
+	// added.
+	StripRetryHeaders bool
+}
+
+// Requeue reads the raw message at ref and produces it again, with its
+// headers plus HeaderRequeuedFrom (<partition>/<offset>) and
+// HeaderRequeuedAt (RFC 3339), and returns where it was written.
+func (c *Client) Requeue(ctx context.Context, ref MessageRef, opts RequeueOpts) (int32, int64, error) {
+	msg, err := c.fetchRef(ctx, ref, callOpts{raw: true})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	topic := opts.Topic
+	if topic == "" {
+		topic = ref.Topic
+	}
+
+	prod, err := c.newProducer(func(*sarama.Config) {})
+	if err != nil {
+		return 0, 0, wrapErr("create producer", topic, -1, -1, err)
+	}
+	defer prod.Close()
+
+	p, o, err := prod.SendMessage(requeueMessage(msg, topic, opts, time.Now()))
+	if err != nil {
+		return 0, 0, wrapErr("requeue", topic, -1, -1, err)
+	}
+	return p, o, nil
+}
+
+func requeueMessage(msg Message, topic string, opts RequeueOpts, now time.Time) *sarama.ProducerMessage {
+	var key []byte
+	if opts.PreserveKey {
+		key = msg.Key
+	}
+
+	var headers []Header
+	for _, h := range msg.Headers {
+		if opts.StripRetryHeaders && isRetryHeader(h.Key) {
+			continue
+		}
+		headers = append(headers, h)
+	}
+
+	headers = append(headers,
+		Header{Key: HeaderRequeuedFrom, Value: []byte(fmt.Sprintf("%d/%d", msg.Partition.Partition, msg.Offset))},
+		Header{Key: HeaderRequeuedAt, Value: []byte(now.UTC().Format(time.RFC3339))},
+	)
+
+	return producerMessage(topic, 0, key, msg.Value, headers)
+}
+
+func isRetryHeader(key string) bool {
+	k := strings.ToLower(key)
+	return strings.HasPrefix(k, "x-kcli-requeued-") || strings.Contains(k, "retry")
+}
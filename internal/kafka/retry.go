@@ -0,0 +1,69 @@
+package kafka
+
+import (
+	"context"
+	"io"
+	"net"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+const (
+	defaultRetries      = 3
+	defaultRetryBackoff = 250 * time.Millisecond
+)
+
+// Retry sets how many times a read is restarted after the partition's
+// leader moves or its broker connection drops.  The wait before each
+// attempt doubles, starting at backoff.  Reads resume from the offset
+// after the last message that was delivered.  The default is 3 attempts
+// starting at 250ms, n = 0 disables retries.
+func Retry(n int, backoff time.Duration) Opt {
+	return func(c *Client) {
+		c.retries = n
+		c.retryBackoff = backoff
+	}
+}
+
+// OnRetry registers f to be called before each retry with the partition
+// (whose Offset is where the read will resume), the attempt number and
+// the error that caused it.
+func OnRetry(f func(info Partition, attempt int, err error)) Opt {
+	return func(c *Client) {
+		c.onRetry = f
+	}
+}
+
+// retriable reports whether err is caused by a leadership change or a
+// broken connection.
+func retriable(err error) bool {
+	switch err {
+	case sarama.ErrNotLeaderForPartition,
+		sarama.ErrLeaderNotAvailable,
+		sarama.ErrReplicaNotAvailable,
+		sarama.ErrBrokerNotAvailable,
+		sarama.ErrRequestTimedOut,
+		sarama.ErrNetworkException,
+		sarama.ErrNotConnected,
+		sarama.ErrOutOfBrokers,
+		io.EOF:
+		return true
+	}
+
+	_, ok := err.(net.Error)
+	return ok
+}
+
+// backoff refreshes the topic's metadata and waits before the given
+// attempt.
+func (c *Client) backoff(ctx context.Context, topic string, attempt int) error {
+	c.sarama.RefreshMetadata(topic)
+
+	select {
+	case <-time.After(c.retryBackoff << uint(attempt-1)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,94 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+func TestRetriable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: sarama.ErrNotLeaderForPartition, want: true},
+		{err: sarama.ErrBrokerNotAvailable, want: true},
+		{err: io.EOF, want: true},
+		{err: &netTimeout{}, want: true},
+		{err: sarama.ErrTopicAuthorizationFailed, want: false},
+		{err: errBoom, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := retriable(tt.err); got != tt.want {
+			t.Errorf("retriable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+type netTimeout struct{}
+
+func (netTimeout) Error() string   { return "i/o timeout" }
+func (netTimeout) Timeout() bool   { return true }
+func (netTimeout) Temporary() bool { return true }
+
+func TestRetryResumes(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, nil, []byte("a"))
+	m.produce("t", 0, nil, []byte("b"))
+	m.injectError("t", 0, sarama.ErrNotLeaderForPartition)
+
+	var retries []int64
+	cli, err := m.client(Retry(3, time.Millisecond), OnRetry(func(info Partition, attempt int, err error) {
+		retries = append(retries, info.Offset)
+		if attempt == 1 {
+			m.produce("t", 0, nil, []byte("c"))
+			m.produce("t", 0, nil, []byte("d"))
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	var got []string
+	_, next, err := cli.FetchN(context.Background(), Partition{Topic: "t", Partition: 0, End: 4}, 10, func(m Message) bool {
+		got = append(got, string(m.Value))
+		return false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"a", "b", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if want := []int64{2}; !reflect.DeepEqual(retries, want) {
+		t.Errorf("retried at %v, want %v", retries, want)
+	}
+	if next != 4 {
+		t.Errorf("got next %d, want 4", next)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, nil, []byte("a"))
+	m.injectError("t", 0, sarama.ErrNotLeaderForPartition)
+
+	cli, err := m.client(Retry(0, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	_, _, err = cli.FetchN(context.Background(), Partition{Topic: "t", Partition: 0, End: 2}, 10, func(Message) bool { return false })
+	if !errors.Is(err, sarama.ErrNotLeaderForPartition) {
+		t.Errorf("got %v, want %v", err, sarama.ErrNotLeaderForPartition)
+	}
+}
@@ -0,0 +1,185 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// SearchTarget selects which part of a message a search matches against.
+type SearchTarget int
+
+const (
+	// TargetValue matches the message value (the default)
+	TargetValue SearchTarget = iota
+	// TargetKey matches the message key
+	TargetKey
+	// TargetHeader matches header values
+	TargetHeader
+	// TargetAny matches the value, the key or any header
+	TargetAny
+)
+
+// SearchIn sets the part of the message that a search matches against.
+func SearchIn(t SearchTarget) CallOpt {
+	return func(o *callOpts) {
+		o.target = t
+	}
+}
+
+// SearchHeader makes a search match against the value of the header
+// called name.  Messages without that header never match.
+func SearchHeader(name string) CallOpt {
+	return func(o *callOpts) {
+		o.target = TargetHeader
+		o.header = name
+	}
+}
+
+// SearchRange bounds a search.  FromOffset and ToOffset bound the scan
+// directly (ToOffset is exclusive, and a zero value for either means the
+// partition's Offset or End is used).  When FromTime or
+// ToTime are set they are resolved to offsets with the offsets-for-times
+// API and take precedence over the offsets, so a ToTime before the first
+// message leaves nothing to search.  Bounds that fall outside of a
+// partition are clamped to its Start and End.
+type SearchRange struct {
+	FromOffset int64     `json:"from_offset"`
+	ToOffset   int64     `json:"to_offset"`
+	FromTime   time.Time `json:"from_time"`
+	ToTime     time.Time `json:"to_time"`
+}
+
+// InRange limits a search to the messages within r.
+func InRange(r SearchRange) CallOpt {
+	return func(o *callOpts) {
+		o.rng = r
+	}
+}
+
+// applyRange narrows info's Offset and End to the range r.
+func (c *Client) applyRange(info Partition, r SearchRange) (Partition, error) {
+	from, to := r.FromOffset, r.ToOffset
+	var err error
+	if !r.FromTime.IsZero() {
+		if from, err = c.offsetForTime(info, r.FromTime); err != nil {
+			return info, err
+		}
+	}
+
+	if !r.ToTime.IsZero() {
+		if to, err = c.offsetForTime(info, r.ToTime); err != nil {
+			return info, err
+		}
+	}
+
+	if from > 0 || !r.FromTime.IsZero() {
+		info.Offset = from
+	}
+
+	// a ToTime before the first message resolves to offset 0, which
+	// leaves nothing to search rather than meaning "up to End"
+	if (to > 0 || !r.ToTime.IsZero()) && to < info.End {
+		info.End = to
+	}
+
+	if info.End < info.Start {
+		info.End = info.Start
+	}
+
+	if info.Offset < info.Start {
+		info.Offset = info.Start
+	}
+
+	if info.Offset > info.End {
+		info.Offset = info.End
+	}
+
+	return info, nil
+}
+
+// offsetForTime returns the first offset in the partition whose timestamp
+// is at or after t, or the End of the partition if there is none.
+func (c *Client) offsetForTime(info Partition, t time.Time) (int64, error) {
+	o, err := c.sarama.GetOffset(info.Topic, info.Partition, t.UnixNano()/int64(time.Millisecond))
+	if err != nil {
+		return 0, wrapErr("get offset for time", info.Topic, info.Partition, -1, err)
+	}
+
+	if o < 0 {
+		return info.End, nil
+	}
+
+	return o, nil
+}
+
+// matcher returns a func that reports whether needle is contained in the
+// targeted part of a message.  If header is empty then every header's key
+// and value are checked.
+func (t SearchTarget) matcher(needle []byte, header string) func(*sarama.ConsumerMessage) bool {
+	value := func(msg *sarama.ConsumerMessage) bool { return bytes.Contains(msg.Value, needle) }
+	key := func(msg *sarama.ConsumerMessage) bool { return msg.Key != nil && bytes.Contains(msg.Key, needle) }
+	headers := func(msg *sarama.ConsumerMessage) bool {
+		for _, h := range msg.Headers {
+			if header == "" && (bytes.Contains(h.Key, needle) || bytes.Contains(h.Value, needle)) {
+				return true
+			}
+			if header != "" && string(h.Key) == header && bytes.Contains(h.Value, needle) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch t {
+	case TargetKey:
+		return key
+	case TargetHeader:
+		return headers
+	case TargetAny:
+		return func(msg *sarama.ConsumerMessage) bool { return value(msg) || key(msg) || headers(msg) }
+	default:
+		return value
+	}
+}
+
+// SearchBytes is like SearchAll but matches needle against the raw bytes
+// of each message, so the Decoder is never run.  Use ParseNeedle to build
+// a needle that contains non-printable bytes.
+func (c *Client) SearchBytes(ctx context.Context, info Partition, needle []byte, max int, cb func(offset int64), opts ...CallOpt) ([]int64, error) {
+	o := getCallOpts(opts)
+	o.raw = true
+	return c.searchAll(ctx, info, needle, max, o, cb)
+}
+
+// ParseNeedle turns a string that may contain \xHH escapes (ie
+// `\x00\x16avro`) into bytes.  Use \\ for a literal backslash.
+func ParseNeedle(s string) ([]byte, error) {
+	var out []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			out = append(out, s[i])
+			continue
+		}
+
+		switch {
+		case i+1 < len(s) && s[i+1] == '\\':
+			out = append(out, '\\')
+			i++
+		case i+3 < len(s) && s[i+1] == 'x':
+			b, err := hex.DecodeString(s[i+2 : i+4])
+			if err != nil {
+				return nil, fmt.Errorf("invalid hex escape %q in %q", s[i:i+4], s)
+			}
+			out = append(out, b[0])
+			i += 3
+		default:
+			return nil, fmt.Errorf("invalid escape at position %d in %q", i, s)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,180 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+func TestSearchTargets(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, []byte("k-needle"), []byte("v"))
+	m.produce("t", 0, []byte("k"), []byte("v-needle"))
+	m.produce("t", 0, nil, []byte("v"), &sarama.RecordHeader{Key: []byte("trace"), Value: []byte("h-needle")})
+	m.produce("t", 0, nil, []byte("v"), &sarama.RecordHeader{Key: []byte("other"), Value: []byte("h-needle")})
+	m.produce("t", 0, nil, []byte("v"), &sarama.RecordHeader{Key: []byte("needle"), Value: []byte("h")})
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	tests := []struct {
+		name string
+		opts []CallOpt
+		want []int64
+	}{
+		{name: "value", want: []int64{1}},
+		{name: "key", opts: []CallOpt{SearchIn(TargetKey)}, want: []int64{0}},
+		{name: "any header", opts: []CallOpt{SearchIn(TargetHeader)}, want: []int64{2, 3, 4}},
+		{name: "named header", opts: []CallOpt{SearchHeader("trace")}, want: []int64{2}},
+		{name: "any", opts: []CallOpt{SearchIn(TargetAny)}, want: []int64{0, 1, 2, 3, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			part := Partition{Topic: "t", Partition: 0, End: 5}
+			got, err := cli.SearchAll(context.Background(), part, "needle", 0, func(int64) {}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNeedle(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []byte
+		wantErr bool
+	}{
+		{in: "abc", want: []byte("abc")},
+		{in: `\x00\x16avro`, want: []byte("\x00\x16avro")},
+		{in: `a\xffb`, want: []byte("a\xffb")},
+		{in: `a\\x00`, want: []byte(`a\x00`)},
+		{in: `\xzz`, wantErr: true},
+		{in: `\x0`, wantErr: true},
+		{in: `ab\`, wantErr: true},
+		{in: `\n`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseNeedle(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseNeedle(%q) err = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !bytes.Equal(got, tt.want) {
+			t.Errorf("ParseNeedle(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSearchBytes(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, nil, []byte("avro"))
+	m.produce("t", 0, nil, []byte("\x00\x16avro"))
+	m.produce("t", 0, nil, []byte("\x00\x17avro"))
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	needle, err := ParseNeedle(`\x00\x16`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := cli.SearchBytes(context.Background(), Partition{Topic: "t", Partition: 0, End: 3}, needle, 0, func(int64) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// stripDecoder drops a 2 byte frame, the way a schema registry decoder
+// drops the magic byte and schema id
+type stripDecoder struct{}
+
+func (stripDecoder) Decode(_ string, data []byte) ([]byte, error) { return data[2:], nil }
+
+func TestSearchBytesSkipsDecoder(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, nil, []byte("\x00\x17avro"))
+	m.produce("t", 0, nil, []byte("\x00\x16avro"))
+
+	cli, err := m.client(WithDecoder(stripDecoder{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	needle, err := ParseNeedle(`\x00\x16avro`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	part := Partition{Topic: "t", Partition: 0, End: 2}
+	got, err := cli.SearchBytes(context.Background(), part, needle, 0, func(int64) {}, SearchIn(TargetAny))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got, err = cli.SearchAll(context.Background(), part, string(needle), 0, func(int64) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("SearchAll matched the decoded values at %v", got)
+	}
+}
+
+func benchmarkSearch(b *testing.B, search func(*Client, Partition) ([]int64, error)) {
+	m := newMockCluster()
+	val := bytes.Repeat([]byte("x"), 1024)
+	for i := 0; i < 1000; i++ {
+		m.produce("t", 0, nil, val)
+	}
+
+	cli, err := m.client(WithDecoder(stripDecoder{}))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer cli.Close()
+
+	part := Partition{Topic: "t", Partition: 0, End: 1000}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := search(cli, part); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSearchAll(b *testing.B) {
+	benchmarkSearch(b, func(cli *Client, part Partition) ([]int64, error) {
+		return cli.SearchAll(context.Background(), part, "needle", 0, func(int64) {})
+	})
+}
+
+func BenchmarkSearchBytes(b *testing.B) {
+	needle := []byte("needle")
+	benchmarkSearch(b, func(cli *Client, part Partition) ([]int64, error) {
+		return cli.SearchBytes(context.Background(), part, needle, 0, func(int64) {})
+	})
+}
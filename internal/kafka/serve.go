@@ -0,0 +1,263 @@
+package kafka
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultServeLimit = 100
+	serveFlushEvery   = 100
+)
+
+// ServeOpts configures Serve
+type ServeOpts struct {
+	// Token, when set, must be sent by every request as
+	// "Authorization: Bearer <Token>".
+	Token string
+
+	// MaxLimit caps the limit parameter of the messages endpoint.  0
+	// means no cap.
+	MaxLimit int
+}
+
+// Serve exposes c read-only over HTTP at addr until ctx is cancelled:
+//
+//	GET /topics                                         topic names
+//	GET /topics/{topic}                                 []Partition
+//	GET /topics/{topic}/partitions/{p}/messages         []Message
+//	    ?offset=(default the partition's Start)&limit=(default 100)
+//	GET /search?topic=&q=                               matching partitions
+//
+// Messages are streamed as they are read.  Work for a request stops as
+// soon as its client goes away.
+func Serve(ctx context.Context, c *Client, addr string, opts ServeOpts) error {
+	srv := &http.Server{Addr: addr, Handler: c.handler(opts)}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func (c *Client) handler(opts ServeOpts) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if opts.Token != "" {
+			auth := r.Header.Get("Authorization")
+			tok := strings.TrimPrefix(auth, "Bearer ")
+			if tok == auth || subtle.ConstantTimeCompare([]byte(tok), []byte(opts.Token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case len(path) == 1 && path[0] == "topics":
+			c.serveTopics(w)
+		case len(path) == 2 && path[0] == "topics":
+			c.serveTopic(w, path[1])
+		case len(path) == 5 && path[0] == "topics" && path[2] == "partitions" && path[4] == "messages":
+			c.serveMessages(w, r, path[1], path[3], opts)
+		case len(path) == 1 && path[0] == "search":
+			c.serveSearch(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func (c *Client) serveTopics(w http.ResponseWriter) {
+	topics, err := c.GetTopics()
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+	sort.Strings(topics)
+	serveJSON(w, topics)
+}
+
+func (c *Client) serveTopic(w http.ResponseWriter, topic string) {
+	parts, err := c.GetTopic(topic)
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+	serveJSON(w, parts)
+}
+
+func (c *Client) serveMessages(w http.ResponseWriter, r *http.Request, topic, partition string, opts ServeOpts) {
+	p, err := strconv.ParseInt(partition, 10, 32)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid partition %q", partition), http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	limit := int64(defaultServeLimit)
+	if s := q.Get("limit"); s != "" {
+		if limit, err = strconv.ParseInt(s, 10, 64); err != nil || limit < 1 {
+			http.Error(w, fmt.Sprintf("invalid limit %q", s), http.StatusBadRequest)
+			return
+		}
+	}
+	if opts.MaxLimit > 0 && limit > int64(opts.MaxLimit) {
+		limit = int64(opts.MaxLimit)
+	}
+
+	start, end, err := c.watermarks(topic, int32(p))
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+
+	part := Partition{Topic: topic, Partition: int32(p), Start: start, End: end, Offset: start}
+	if s := q.Get("offset"); s != "" {
+		if part.Offset, err = strconv.ParseInt(s, 10, 64); err != nil {
+			http.Error(w, fmt.Sprintf("invalid offset %q", s), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// The response starts with the first message so that errors that
+	// happen before then still get a proper status.
+	stream := newJSONStream(w)
+	_, _, err = c.FetchN(r.Context(), part, limit, func(m Message) bool {
+		return stream.write(m) != nil
+	})
+
+	if err != nil && !stream.started {
+		serveError(w, err)
+		return
+	}
+
+	if err != nil {
+		c.logf("serving %s/%d: %s", topic, p, err)
+	}
+	stream.close()
+}
+
+type searchResponse struct {
+	Partitions []Partition      `json:"partitions"`
+	Failures   []searchFailData `json:"failures,omitempty"`
+}
+
+type searchFailData struct {
+	Partition int32  `json:"partition"`
+	Error     string `json:"error"`
+}
+
+func (c *Client) serveSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	topic, s := q.Get("topic"), q.Get("q")
+	if topic == "" || s == "" {
+		http.Error(w, "topic and q are required", http.StatusBadRequest)
+		return
+	}
+
+	parts, err := c.topicPartitions(topic)
+	if err != nil {
+		serveError(w, err)
+		return
+	}
+
+	found, errs := c.SearchTopicStream(r.Context(), parts, s)
+	resp := searchResponse{Partitions: []Partition{}}
+	for p := range found {
+		resp.Partitions = append(resp.Partitions, p)
+	}
+
+	if r.Context().Err() != nil {
+		return
+	}
+
+	for len(errs) > 0 {
+		var f SearchFailure
+		if err := <-errs; errors.As(err, &f) {
+			resp.Failures = append(resp.Failures, searchFailData{Partition: f.Partition.Partition, Error: f.Err.Error()})
+		}
+	}
+
+	sortPartitions(resp.Partitions)
+	serveJSON(w, resp)
+}
+
+// jsonStream writes values as the elements of a JSON array, flushing
+// every so often so that large responses are sent in chunks.
+type jsonStream struct {
+	w       http.ResponseWriter
+	enc     *json.Encoder
+	n       int
+	started bool
+}
+
+func newJSONStream(w http.ResponseWriter) *jsonStream {
+	return &jsonStream{w: w, enc: json.NewEncoder(w)}
+}
+
+func (s *jsonStream) write(v interface{}) error {
+	sep := ","
+	if !s.started {
+		s.w.Header().Set("Content-Type", "application/json")
+		sep = "["
+		s.started = true
+	}
+
+	if _, err := s.w.Write([]byte(sep)); err != nil {
+		return err
+	}
+
+	if err := s.enc.Encode(v); err != nil {
+		return err
+	}
+
+	s.n++
+	if f, ok := s.w.(http.Flusher); ok && s.n%serveFlushEvery == 0 {
+		f.Flush()
+	}
+	return nil
+}
+
+func (s *jsonStream) close() {
+	if !s.started {
+		s.w.Header().Set("Content-Type", "application/json")
+		s.w.Write([]byte("["))
+	}
+	s.w.Write([]byte("]\n"))
+}
+
+func serveJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func serveError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	switch {
+	case errors.Is(err, ErrTopicNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrUnauthorized):
+		status = http.StatusForbidden
+	case errors.Is(err, ErrOffsetOutOfRange):
+		status = http.StatusRequestedRangeNotSatisfiable
+	case errors.Is(err, context.Canceled):
+		return
+	}
+	http.Error(w, err.Error(), status)
+}
@@ -0,0 +1,92 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestServeAuth(t *testing.T) {
+	_, cli := newTestClient(t, "t", []string{"a"})
+	h := cli.handler(ServeOpts{Token: "secret"})
+
+	tests := []struct {
+		auth string
+		want int
+	}{
+		{auth: "", want: http.StatusUnauthorized},
+		{auth: "secret", want: http.StatusUnauthorized},
+		{auth: "Bearer wrong", want: http.StatusUnauthorized},
+		{auth: "Basic secret", want: http.StatusUnauthorized},
+		{auth: "Bearer secret", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/topics", nil)
+		if tt.auth != "" {
+			r.Header.Set("Authorization", tt.auth)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("Authorization %q: got status %d, want %d", tt.auth, w.Code, tt.want)
+		}
+	}
+}
+
+func TestServeTopics(t *testing.T) {
+	m, cli := newTestClient(t, "b", []string{"a"})
+	m.createTopic("a", 1)
+
+	w := httptest.NewRecorder()
+	cli.handler(ServeOpts{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/topics", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("got status %d and content type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	var got []string
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("%s: %s", w.Body, err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestServeMessagesCancel goes away while a messages request is waiting
+// on a slow fetch, which has to stop the scan.
+func TestServeMessagesCancel(t *testing.T) {
+	m, cli := newTestClient(t, "t", []string{"a", "b", "c"})
+	m.stall("t", 0, 1, time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodGet, "/topics/t/partitions/0/messages?limit=3", nil).WithContext(ctx)
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		cli.handler(ServeOpts{}).ServeHTTP(w, r)
+		close(done)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the scan kept going after the request was cancelled")
+	}
+
+	var got []Message
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("%s: %s", w.Body, err)
+	}
+	if len(got) != 1 || string(got[0].Value) != "a" {
+		t.Errorf("got %d messages, want only a", len(got))
+	}
+}
@@ -0,0 +1,162 @@
+package kafka
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Sink is where ExportTo writes.  Open is called once for each partition
+// before its first message (partitions without messages aren't opened)
+// and the writer it returns is closed once the partition has been
+// exported.  Each Write to it is a whole JSON line so
+// a sink can start a new file (or object) between any two writes.  Close
+// is called at the end of the export.
+type Sink interface {
+	Open(part Partition) (io.WriteCloser, error)
+	Close() error
+}
+
+// writerSink sends every partition to one writer, which it doesn't close
+type writerSink struct {
+	w io.Writer
+}
+
+func (s writerSink) Open(Partition) (io.WriteCloser, error) { return nopCloser{s.w}, nil }
+
+func (s writerSink) Close() error { return nil }
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// RotatingSink gets its writers from Create, ie to put each partition in
+// objects of a caller's object storage.  When MaxBytes is set a
+// partition's output is closed and the next one created before it passes
+// MaxBytes (of JSON, before compression).  n counts a partition's
+// outputs from 1.  A single message bigger than MaxBytes gets an output
+// of its own.  With Gzip every output is a gzip stream of its own, so each
+// can be decompressed without the others.
+type RotatingSink struct {
+	Create   func(part Partition, n int) (io.WriteCloser, error)
+	MaxBytes int64
+	Gzip     bool
+}
+
+// Open creates the partition's first output
+func (s *RotatingSink) Open(part Partition) (io.WriteCloser, error) {
+	w := &rotatingWriter{sink: s, part: part}
+	if err := w.next(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Close implements Sink
+func (s *RotatingSink) Close() error { return nil }
+
+// FileSink writes each partition to its own file in Dir called
+// <topic>-<partition>.jsonl (.jsonl.gz with Gzip).  When MaxBytes is set
+// the files are rotated as by RotatingSink and numbered
+// <topic>-<partition>-0001.jsonl and so on.
+type FileSink struct {
+	Dir      string
+	Gzip     bool
+	MaxBytes int64
+}
+
+// Open creates Dir if needed and the partition's first file
+func (s *FileSink) Open(part Partition) (io.WriteCloser, error) {
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	r := &RotatingSink{MaxBytes: s.MaxBytes, Gzip: s.Gzip, Create: func(part Partition, n int) (io.WriteCloser, error) {
+		return os.Create(s.path(part, n))
+	}}
+	return r.Open(part)
+}
+
+// Close implements Sink
+func (s *FileSink) Close() error { return nil }
+
+func (s *FileSink) path(part Partition, n int) string {
+	name := fmt.Sprintf("%s-%d", part.Topic, part.Partition)
+	if s.MaxBytes > 0 {
+		name = fmt.Sprintf("%s-%04d", name, n)
+	}
+
+	name += ".jsonl"
+	if s.Gzip {
+		name += ".gz"
+	}
+	return filepath.Join(s.Dir, name)
+}
+
+// rotatingWriter is a partition's current output of a RotatingSink
+type rotatingWriter struct {
+	sink    *RotatingSink
+	part    Partition
+	n       int
+	written int64
+
+	out io.WriteCloser
+	gz  *gzip.Writer
+}
+
+func (r *rotatingWriter) Write(p []byte) (int, error) {
+	if r.sink.MaxBytes > 0 && r.written > 0 && r.written+int64(len(p)) > r.sink.MaxBytes {
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	var w io.Writer = r.out
+	if r.gz != nil {
+		w = r.gz
+	}
+
+	n, err := w.Write(p)
+	r.written += int64(n)
+	return n, err
+}
+
+// next closes the current output, if there is one, and starts the next
+func (r *rotatingWriter) next() error {
+	if err := r.Close(); err != nil {
+		return err
+	}
+
+	r.n++
+	out, err := r.sink.Create(r.part, r.n)
+	if err != nil {
+		return err
+	}
+
+	r.out, r.written = out, 0
+	if r.sink.Gzip {
+		r.gz = gzip.NewWriter(out)
+	}
+	return nil
+}
+
+// Close ends the gzip stream, if there is one, before closing the output
+func (r *rotatingWriter) Close() error {
+	if r.out == nil {
+		return nil
+	}
+
+	out, gz := r.out, r.gz
+	r.out, r.gz = nil, nil
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			out.Close()
+			return err
+		}
+	}
+	return out.Close()
+}
@@ -0,0 +1,64 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+
+	"github.com/IBM/sarama"
+)
+
+// SizeStats describes the sizes (key plus value, see Message.Size) of a
+// sample of messages.  MaxOffset is the offset of the largest one.
+type SizeStats struct {
+	Sampled   int     `json:"sampled"`
+	Min       int     `json:"min"`
+	Max       int     `json:"max"`
+	Mean      float64 `json:"mean"`
+	P95       int     `json:"p95"`
+	MaxOffset int64   `json:"max_offset"`
+}
+
+// SizeStats reads up to sample messages of part, starting at part.Offset,
+// and reports their sizes.  Values are not decoded.
+func (c *Client) SizeStats(ctx context.Context, part Partition, sample int) (SizeStats, error) {
+	st := SizeStats{MaxOffset: -1}
+	if sample <= 0 {
+		return st, nil
+	}
+
+	var sizes []int
+	var total int
+	err := c.consume(ctx, part, int64(sample), func(msg *sarama.ConsumerMessage) bool {
+		n := len(msg.Key) + len(msg.Value)
+		if n > st.Max || st.MaxOffset < 0 {
+			st.Max = n
+			st.MaxOffset = msg.Offset
+		}
+		total += n
+		sizes = append(sizes, n)
+		return false
+	})
+
+	if err != nil {
+		return st, err
+	}
+
+	st.Sampled = len(sizes)
+	if len(sizes) == 0 {
+		return st, nil
+	}
+
+	sort.Ints(sizes)
+	st.Min = sizes[0]
+	st.Mean = float64(total) / float64(len(sizes))
+	st.P95 = percentile(sizes, 95)
+	return st, nil
+}
+
+// LargerThan returns a Matcher for messages whose Size is greater than n
+// bytes.  Combined with SearchAll, an empty search string and RawBytes
+// (searches size the decoded value otherwise) it finds the oversized
+// records in a partition.
+func LargerThan(n int) Matcher {
+	return MatcherFunc(func(msg Message) bool { return msg.Size > n })
+}
@@ -0,0 +1,73 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// OffsetSnapshot is the high water mark of every partition of a set of
+// topics at Time.  It is JSON serializable so a test can save it and read
+// what was produced since in a later process.
+type OffsetSnapshot struct {
+	Time    time.Time                  `json:"time"`
+	Offsets map[string]map[int32]int64 `json:"offsets"`
+}
+
+// SnapshotOffsets captures the high water marks of topics for
+// ConsumeSince.
+func (c *Client) SnapshotOffsets(topics []string) (OffsetSnapshot, error) {
+	snap := OffsetSnapshot{Time: time.Now(), Offsets: map[string]map[int32]int64{}}
+	for _, topic := range topics {
+		hwm, err := c.HighWaterMarks(topic)
+		if err != nil {
+			return snap, err
+		}
+		snap.Offsets[topic] = hwm
+	}
+	return snap, nil
+}
+
+// ConsumeSince sends every message produced to the topics of snap after
+// it was taken, up to the high water marks at the time of the call, to cb
+// (see FetchTopic).  Partitions added since the snapshot are read from
+// their oldest message.  It stops early if cb returns true.
+func (c *Client) ConsumeSince(ctx context.Context, snap OffsetSnapshot, cb func(Message) bool, opts ...CallOpt) error {
+	var parts []Partition
+	var end int64
+	for _, topic := range sortedSnapshotTopics(snap) {
+		all, err := c.topicPartitions(topic)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range all {
+			if o, ok := snap.Offsets[topic][p.Partition]; ok && o > p.Offset {
+				p.Offset = o
+			}
+			if p.Offset >= p.End {
+				continue
+			}
+			if n := p.End - p.Offset; n > end {
+				end = n
+			}
+			parts = append(parts, p)
+		}
+	}
+
+	if len(parts) == 0 {
+		return nil
+	}
+
+	_, err := c.FetchTopic(ctx, parts, end, cb, opts...)
+	return err
+}
+
+func sortedSnapshotTopics(snap OffsetSnapshot) []string {
+	out := make([]string, 0, len(snap.Offsets))
+	for t := range snap.Offsets {
+		out = append(out, t)
+	}
+	sort.Strings(out)
+	return out
+}
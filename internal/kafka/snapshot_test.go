@@ -0,0 +1,61 @@
+package kafka
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestConsumeSince(t *testing.T) {
+	m, cli := newTestClient(t, "t", []string{"a", "b"}, []string{"c"})
+
+	snap, err := cli.SnapshotOffsets([]string{"t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]map[int32]int64{"t": {0: 2, 1: 1}}; !reflect.DeepEqual(snap.Offsets, want) {
+		t.Fatalf("got %v, want %v", snap.Offsets, want)
+	}
+
+	m.produce("t", 0, nil, []byte("d"))
+	m.produce("t", 0, nil, []byte("e"))
+	m.produce("t", 2, nil, []byte("f"))
+
+	var lock sync.Mutex
+	var got []string
+	err = cli.ConsumeSince(context.Background(), snap, func(msg Message) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		got = append(got, string(msg.Value))
+		return false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// partition 1 has nothing new and partition 2 was added since the
+	// snapshot, so it is read from the start
+	sort.Strings(got)
+	if want := []string{"d", "e", "f"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestConsumeSinceNothingNew(t *testing.T) {
+	_, cli := newTestClient(t, "t", []string{"a"})
+
+	snap, err := cli.SnapshotOffsets([]string{"t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cli.ConsumeSince(context.Background(), snap, func(msg Message) bool {
+		t.Errorf("got %q, want nothing", msg.Value)
+		return false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
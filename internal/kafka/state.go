@@ -0,0 +1,32 @@
+package kafka
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// SaveState writes states as JSON so that the browsing positions
+// (topic, partition, offset and filter) can be restored with LoadState.
+func SaveState(w io.Writer, states []Partition) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(states)
+}
+
+// LoadState reads partitions that were written by SaveState.  The
+// offsets may have expired since they were saved, see ResumeValid.
+func LoadState(r io.Reader) ([]Partition, error) {
+	var out []Partition
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ResumeValid returns copies of parts with fresh Start and End values.
+// Offsets that are no longer inside of [Start, End] (because retention
+// removed the messages or the partition was truncated) are clamped and
+// the partition's Clamped field is set.
+func (c *Client) ResumeValid(parts []Partition) ([]Partition, error) {
+	return c.RefreshPartitions(parts)
+}
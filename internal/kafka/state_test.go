@@ -0,0 +1,63 @@
+package kafka
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSaveLoadState(t *testing.T) {
+	states := []Partition{
+		{Topic: "a", Partition: 0, Start: 0, End: 10, Offset: 4, Filter: "name == 'x'"},
+		{Topic: "b", Partition: 3, Start: 5, End: 6, Offset: 5},
+	}
+
+	var buf bytes.Buffer
+	if err := SaveState(&buf, states); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadState(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, states) {
+		t.Errorf("got %+v, want %+v", got, states)
+	}
+}
+
+func TestResumeValid(t *testing.T) {
+	m := newMockCluster()
+	for i := 0; i < 10; i++ {
+		m.produce("t", 0, nil, []byte("x"))
+	}
+	m.expire("t", 0, 4)
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	got, err := cli.ResumeValid([]Partition{
+		{Topic: "t", Partition: 0, Start: 0, End: 8, Offset: 2},
+		{Topic: "t", Partition: 0, Start: 0, End: 8, Offset: 6},
+		{Topic: "t", Partition: 0, Start: 0, End: 20, Offset: 15},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Partition{
+		{Topic: "t", Partition: 0, Start: 4, End: 10, Offset: 4, Clamped: true},
+		{Topic: "t", Partition: 0, Start: 4, End: 10, Offset: 6},
+		{Topic: "t", Partition: 0, Start: 4, End: 10, Offset: 10, Clamped: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := cli.ResumeValid([]Partition{{Topic: "gone", Partition: 0}}); err == nil {
+		t.Error("expected an error for a topic that doesn't exist")
+	}
+}
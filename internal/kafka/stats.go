@@ -0,0 +1,143 @@
+package kafka
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// sampleTimeout bounds how long a single partition is sampled for
+const sampleTimeout = 5 * time.Second
+
+// TopicStats describes how the messages of a topic are spread across its
+// partitions.  Skew is the ratio of the largest partition's message count
+// to the smallest's (it is 0 when the smallest partition is empty).
+type TopicStats struct {
+	Topic        string           `json:"topic"`
+	Messages     int64            `json:"messages"`
+	DistinctKeys uint64           `json:"distinct_keys"`
+	Largest      int32            `json:"largest"`
+	Smallest     int32            `json:"smallest"`
+	Skew         float64          `json:"skew"`
+	Partitions   []PartitionStats `json:"partitions"`
+}
+
+// PartitionStats describes a single partition.  Messages is End-Start and
+// the sizes and distinct key estimate come from a sample of the most
+// recent messages.
+type PartitionStats struct {
+	Partition    int32   `json:"partition"`
+	Messages     int64   `json:"messages"`
+	Sampled      int     `json:"sampled"`
+	AvgSize      float64 `json:"avg_size"`
+	P50Size      int     `json:"p50_size"`
+	P95Size      int     `json:"p95_size"`
+	P99Size      int     `json:"p99_size"`
+	MaxSize      int     `json:"max_size"`
+	DistinctKeys uint64  `json:"distinct_keys"`
+}
+
+// PartitionStats samples up to sampleSize of the most recent messages of
+// each partition of topic.  Each partition is sampled for at most a few
+// seconds so that huge partitions are never scanned in full.
+func (c *Client) PartitionStats(ctx context.Context, topic string, sampleSize int) (TopicStats, error) {
+	ts := TopicStats{Topic: topic}
+	parts, err := c.topicPartitions(topic)
+	if err != nil {
+		return ts, err
+	}
+
+	var keys hll
+	for _, part := range parts {
+		ps, h, err := c.samplePartition(ctx, part, sampleSize)
+		if err != nil {
+			return ts, err
+		}
+
+		keys.merge(h)
+		ts.Messages += ps.Messages
+		ts.Partitions = append(ts.Partitions, ps)
+	}
+
+	ts.DistinctKeys = keys.estimate()
+	if len(ts.Partitions) == 0 {
+		return ts, nil
+	}
+
+	largest, smallest := ts.Partitions[0], ts.Partitions[0]
+	for _, ps := range ts.Partitions {
+		if ps.Messages > largest.Messages {
+			largest = ps
+		}
+		if ps.Messages < smallest.Messages {
+			smallest = ps
+		}
+	}
+
+	ts.Largest = largest.Partition
+	ts.Smallest = smallest.Partition
+	if smallest.Messages > 0 {
+		ts.Skew = float64(largest.Messages) / float64(smallest.Messages)
+	}
+
+	return ts, nil
+}
+
+func (c *Client) samplePartition(ctx context.Context, part Partition, n int) (PartitionStats, *hll, error) {
+	ps := PartitionStats{Partition: part.Partition, Messages: part.End - part.Start}
+	h := &hll{}
+
+	part.Offset = part.End - int64(n)
+	if part.Offset < part.Start {
+		part.Offset = part.Start
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sampleTimeout)
+	defer cancel()
+
+	var sizes []int
+	err := c.consume(ctx, part, int64(n), func(msg *sarama.ConsumerMessage) bool {
+		sizes = append(sizes, len(msg.Key)+len(msg.Value))
+		if msg.Key != nil {
+			h.add(msg.Key)
+		}
+		return false
+	})
+
+	if err != nil && err != context.DeadlineExceeded {
+		return ps, nil, err
+	}
+
+	ps.Sampled = len(sizes)
+	ps.DistinctKeys = h.estimate()
+	if len(sizes) == 0 {
+		return ps, h, nil
+	}
+
+	sort.Ints(sizes)
+	var total int
+	for _, s := range sizes {
+		total += s
+	}
+
+	ps.AvgSize = float64(total) / float64(len(sizes))
+	ps.P50Size = percentile(sizes, 50)
+	ps.P95Size = percentile(sizes, 95)
+	ps.P99Size = percentile(sizes, 99)
+	ps.MaxSize = sizes[len(sizes)-1]
+	return ps, h, nil
+}
+
+// percentile returns the pth percentile of the sorted values
+func percentile(sorted []int, p int) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := (len(sorted)*p + 99) / 100
+	if i > 0 {
+		i--
+	}
+	return sorted[i]
+}
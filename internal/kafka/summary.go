@@ -0,0 +1,130 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// TopicSummary is a one line description of a topic.  Messages is the
+// sum of End - Start over its partitions and Labels holds whatever the
+// Client's TopicAnnotators said about it.
+type TopicSummary struct {
+	Topic      string            `json:"topic"`
+	Partitions int               `json:"partitions"`
+	Messages   int64             `json:"messages"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+// TopicAnnotator adds labels (ie owner: payments) to topic summaries.
+type TopicAnnotator interface {
+	Annotate(topic string) (map[string]string, error)
+}
+
+// AnnotatorFunc turns a func into a TopicAnnotator
+type AnnotatorFunc func(topic string) (map[string]string, error)
+
+// Annotate calls f(topic)
+func (f AnnotatorFunc) Annotate(topic string) (map[string]string, error) { return f(topic) }
+
+// WithAnnotator adds a to the annotators GetTopicSummaries asks for
+// labels.  When annotators return the same label the one added last
+// wins.
+func WithAnnotator(a TopicAnnotator) Opt {
+	return func(c *Client) {
+		c.annotators = append(c.annotators, a)
+	}
+}
+
+// GetTopicSummaries summarizes topics (or every topic if none are given)
+// in name order.
+func (c *Client) GetTopicSummaries(topics ...string) ([]TopicSummary, error) {
+	if len(topics) == 0 {
+		var err error
+		if topics, err = c.GetTopics(); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]TopicSummary, 0, len(topics))
+	for _, topic := range topics {
+		parts, err := c.topicPartitions(topic)
+		if err != nil {
+			return nil, err
+		}
+
+		s := TopicSummary{Topic: topic, Partitions: len(parts)}
+		for _, p := range parts {
+			s.Messages += p.End - p.Start
+		}
+
+		if s.Labels, err = c.annotate(topic); err != nil {
+			return nil, err
+		}
+
+		out = append(out, s)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Topic < out[j].Topic })
+	return out, nil
+}
+
+func (c *Client) annotate(topic string) (map[string]string, error) {
+	var labels map[string]string
+	for _, a := range c.annotators {
+		l, err := a.Annotate(topic)
+		if err != nil {
+			return nil, fmt.Errorf("annotate %s: %w", topic, err)
+		}
+
+		for k, v := range l {
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			labels[k] = v
+		}
+	}
+	return labels, nil
+}
+
+// RecordAnnotator labels topics from the JSON records of a compacted
+// topic.  Each record looks like
+//
+//	{"topic": "orders", "labels": {"owner": "payments"}}
+//
+// The topic is read once, by NewRecordAnnotator.  Build a new one to pick
+// up changes.
+type RecordAnnotator struct {
+	labels map[string]map[string]string
+}
+
+type annotationRecord struct {
+	Topic  string            `json:"topic"`
+	Labels map[string]string `json:"labels"`
+}
+
+// NewRecordAnnotator materializes topic (see Materialize) and keeps the
+// labels of the records in it.  Records that aren't valid JSON or don't
+// name a topic are skipped.  If more than one record names the same topic
+// the one with the greatest key wins.
+func (c *Client) NewRecordAnnotator(ctx context.Context, topic string, opts ...CallOpt) (*RecordAnnotator, error) {
+	r := &RecordAnnotator{labels: map[string]map[string]string{}}
+	_, err := c.Materialize(ctx, topic, func(_, value []byte) {
+		var rec annotationRecord
+		if err := json.Unmarshal(value, &rec); err != nil || rec.Topic == "" {
+			return
+		}
+		r.labels[rec.Topic] = rec.Labels
+	}, opts...)
+
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Annotate implements TopicAnnotator
+func (r *RecordAnnotator) Annotate(topic string) (map[string]string, error) {
+	return r.labels[topic], nil
+}
@@ -0,0 +1,188 @@
+package kafka
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+const defaultStatsInterval = time.Second
+
+// Stats is a snapshot of how much the Client has read.  Messages and
+// Bytes (keys plus values) are totals since the Client was created,
+// MessagesPerSec and BytesPerSec are the rates since the previous
+// snapshot, and Active lists the partitions being read right now with
+// Offset set to the last offset read.  InFlightBytes is how much of the
+// MaxInFlightBytes budget is in use.
+type Stats struct {
+	Time           time.Time   `json:"time"`
+	Messages       int64       `json:"messages"`
+	Bytes          int64       `json:"bytes"`
+	MessagesPerSec float64     `json:"messages_per_sec"`
+	BytesPerSec    float64     `json:"bytes_per_sec"`
+	Active         []Partition `json:"active"`
+	InFlightBytes  int64       `json:"in_flight_bytes"`
+}
+
+// WithStats calls hook with a Stats snapshot every second (or the
+// interval set with StatsInterval) while the Client is reading.  Fetches,
+// searches, iterators and watches all count.  hook is called from its own
+// goroutine and snapshots are dropped, never queued, while it is busy so
+// a slow hook can't slow down consuming.
+func WithStats(hook func(Stats)) Opt {
+	return func(c *Client) {
+		if c.meter == nil {
+			c.meter = &meter{interval: defaultStatsInterval}
+		}
+		c.meter.hook = hook
+	}
+}
+
+// StatsInterval sets how often WithStats' hook is called.
+func StatsInterval(d time.Duration) Opt {
+	return func(c *Client) {
+		if c.meter == nil {
+			c.meter = &meter{}
+		}
+		c.meter.interval = d
+	}
+}
+
+// meter counts what every consume loop reads.  A nil meter counts
+// nothing.
+type meter struct {
+	hook     func(Stats)
+	interval time.Duration
+	inFlight *inFlight
+
+	messages int64
+	bytes    int64
+
+	mu     sync.Mutex
+	active map[*activePartition]bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+type activePartition struct {
+	topic     string
+	partition int32
+	offset    int64
+}
+
+func (m *meter) run() {
+	if m == nil || m.hook == nil {
+		return
+	}
+
+	if m.interval <= 0 {
+		m.interval = defaultStatsInterval
+	}
+
+	m.active = map[*activePartition]bool{}
+	m.done = make(chan struct{})
+	out := make(chan Stats, 1)
+
+	m.wg.Add(2)
+	go func() {
+		defer m.wg.Done()
+		for s := range out {
+			m.hook(s)
+		}
+	}()
+
+	go func() {
+		defer m.wg.Done()
+		defer close(out)
+		t := time.NewTicker(m.interval)
+		defer t.Stop()
+
+		last := Stats{Time: time.Now()}
+		for {
+			select {
+			case <-t.C:
+				s := m.snapshot(last)
+				if s.Messages == last.Messages && len(s.Active) == 0 && len(last.Active) == 0 {
+					continue
+				}
+				last = s
+				select {
+				case out <- s:
+				default:
+				}
+			case <-m.done:
+				return
+			}
+		}
+	}()
+}
+
+func (m *meter) snapshot(last Stats) Stats {
+	s := Stats{
+		Time:     time.Now(),
+		Messages: atomic.LoadInt64(&m.messages),
+		Bytes:    atomic.LoadInt64(&m.bytes),
+
+		InFlightBytes: m.inFlight.bytes(),
+	}
+
+	if secs := s.Time.Sub(last.Time).Seconds(); secs > 0 {
+		s.MessagesPerSec = float64(s.Messages-last.Messages) / secs
+		s.BytesPerSec = float64(s.Bytes-last.Bytes) / secs
+	}
+
+	m.mu.Lock()
+	for ap := range m.active {
+		s.Active = append(s.Active, Partition{Topic: ap.topic, Partition: ap.partition, Offset: atomic.LoadInt64(&ap.offset)})
+	}
+	m.mu.Unlock()
+
+	sortPartitions(s.Active)
+	return s
+}
+
+// start registers a partition that is about to be read
+func (m *meter) start(topic string, partition int32, offset int64) *activePartition {
+	if m == nil || m.active == nil {
+		return nil
+	}
+
+	ap := &activePartition{topic: topic, partition: partition, offset: offset}
+	m.mu.Lock()
+	m.active[ap] = true
+	m.mu.Unlock()
+	return ap
+}
+
+// stop unregisters a partition that start returned
+func (m *meter) stop(ap *activePartition) {
+	if ap == nil {
+		return
+	}
+
+	m.mu.Lock()
+	delete(m.active, ap)
+	m.mu.Unlock()
+}
+
+// record counts a message read from ap
+func (m *meter) record(ap *activePartition, msg *sarama.ConsumerMessage) {
+	if ap == nil {
+		return
+	}
+
+	atomic.AddInt64(&m.messages, 1)
+	atomic.AddInt64(&m.bytes, int64(len(msg.Key)+len(msg.Value)))
+	atomic.StoreInt64(&ap.offset, msg.Offset)
+}
+
+func (m *meter) close() {
+	if m == nil || m.done == nil {
+		return
+	}
+	close(m.done)
+	m.wg.Wait()
+}
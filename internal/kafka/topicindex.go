@@ -0,0 +1,171 @@
+package kafka
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TopicIndex is a snapshot of the cluster's topic names for fast
+// completion.  It is safe for concurrent use.
+type TopicIndex struct {
+	c *Client
+
+	mu     sync.RWMutex
+	topics []string
+	err    error
+
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// TopicIndex reads the topic list and, if refresh > 0, re-reads it
+// every refresh until the index is closed.  A failed refresh keeps the
+// previous topics (see Err).
+func (c *Client) TopicIndex(refresh time.Duration) (*TopicIndex, error) {
+	idx := &TopicIndex{c: c, done: make(chan struct{})}
+	if err := idx.refresh(); err != nil {
+		return nil, err
+	}
+
+	if refresh > 0 {
+		idx.wg.Add(1)
+		go idx.loop(refresh)
+	}
+	return idx, nil
+}
+
+func (idx *TopicIndex) loop(refresh time.Duration) {
+	defer idx.wg.Done()
+	t := time.NewTicker(refresh)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err := idx.refresh(); err != nil {
+				idx.c.logf("refreshing topic index: %s", err)
+			}
+		case <-idx.done:
+			return
+		}
+	}
+}
+
+func (idx *TopicIndex) refresh() error {
+	topics, err := idx.c.GetTopics()
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.err = err
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(topics)
+	idx.topics = topics
+	return nil
+}
+
+// Err returns the error of the most recent refresh, if it failed.
+func (idx *TopicIndex) Err() error {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.err
+}
+
+// Topics returns every topic, sorted.
+func (idx *TopicIndex) Topics() []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return append([]string(nil), idx.topics...)
+}
+
+// Prefix returns, in order, up to limit topics that start with s (all of
+// them when limit <= 0).
+func (idx *TopicIndex) Prefix(s string, limit int) []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var out []string
+	for i := sort.SearchStrings(idx.topics, s); i < len(idx.topics) && strings.HasPrefix(idx.topics[i], s); i++ {
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+		out = append(out, idx.topics[i])
+	}
+	return out
+}
+
+// Match returns up to limit topics (all of them when limit <= 0) that
+// contain the characters of s in order, best matches first.  Matches
+// score higher when the characters are adjacent, when they start at the
+// beginning of the name (or, less so, of a word after '.', '-' or '_')
+// and when the name is short.
+func (idx *TopicIndex) Match(s string, limit int) []string {
+	idx.mu.RLock()
+	type scored struct {
+		topic string
+		score int
+	}
+	var matches []scored
+	for _, t := range idx.topics {
+		if score, ok := fuzzyScore(s, t); ok {
+			matches = append(matches, scored{t, score})
+		}
+	}
+	idx.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].topic < matches[j].topic
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	out := make([]string, len(matches))
+	for i, m := range matches {
+		out[i] = m.topic
+	}
+	return out
+}
+
+// fuzzyScore reports whether pattern is a case insensitive subsequence
+// of s and, if so, how good a match it is.
+func fuzzyScore(pattern, s string) (int, bool) {
+	p, t := strings.ToLower(pattern), strings.ToLower(s)
+	score, j, last := 0, 0, -2
+	for i := 0; i < len(t) && j < len(p); i++ {
+		if t[i] != p[j] {
+			continue
+		}
+
+		score++
+		if i == last+1 {
+			score += 3
+		}
+		switch {
+		case i == 0:
+			score += 4
+		case strings.IndexByte(".-_", t[i-1]) >= 0:
+			score += 2
+		}
+		last = i
+		j++
+	}
+
+	if j < len(p) {
+		return 0, false
+	}
+	return score*100 - len(t), true
+}
+
+// Close stops refreshing the index.  It is safe to call more than once.
+func (idx *TopicIndex) Close() {
+	idx.once.Do(func() { close(idx.done) })
+	idx.wg.Wait()
+}
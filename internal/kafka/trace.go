@@ -0,0 +1,187 @@
+package kafka
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// The operations reported to a WithTracing hook.
+const (
+	TraceMetadata    = "metadata"
+	TraceGetOffset   = "getoffset"
+	TraceConsumeDial = "consume-dial"
+	TraceFetchBatch  = "fetch-batch"
+)
+
+// TraceEvent is one round trip (or wait) on kafka.  Broker is the
+// address of the broker the request went to when it is known.  For a
+// fetch-batch Duration is how long the consumer waited for the batch to
+// arrive, later messages from the same batch are not reported.
+type TraceEvent struct {
+	Time      time.Time     `json:"time"`
+	Op        string        `json:"op"`
+	Broker    string        `json:"broker,omitempty"`
+	Topic     string        `json:"topic,omitempty"`
+	Partition int32         `json:"partition"`
+	Duration  time.Duration `json:"duration"`
+	Err       error         `json:"-"`
+}
+
+// WithTracing calls hook for every metadata request, offset lookup,
+// partition consumer dial and fetched batch.  hook is called
+// synchronously from the goroutine that made the request so it must be
+// fast (and safe to call concurrently).  TraceCollector.Record is a
+// ready made hook.
+func WithTracing(hook func(TraceEvent)) Opt {
+	return func(c *Client) {
+		c.trace = hook
+	}
+}
+
+// traced reports an op that began at start to the Client's trace hook.
+func (c *Client) traced(op string, broker *sarama.Broker, topic string, partition int32, start time.Time, err error) {
+	if c.trace == nil {
+		return
+	}
+	traceEvent(c.trace, op, broker, topic, partition, start, err)
+}
+
+func traceEvent(hook func(TraceEvent), op string, broker *sarama.Broker, topic string, partition int32, start time.Time, err error) {
+	e := TraceEvent{
+		Time:      start,
+		Op:        op,
+		Topic:     topic,
+		Partition: partition,
+		Duration:  time.Since(start),
+		Err:       err,
+	}
+	if broker != nil {
+		e.Broker = broker.Addr()
+	}
+	hook(e)
+}
+
+// dial starts a partition consumer and traces how long it took.
+func (c *Client) dial(consumer sarama.Consumer, topic string, partition int32, offset int64) (sarama.PartitionConsumer, error) {
+	start := time.Now()
+	pc, err := consumer.ConsumePartition(topic, partition, offset)
+	if c.trace != nil {
+		b, _ := c.sarama.Leader(topic, partition)
+		c.traced(TraceConsumeDial, b, topic, partition, start, err)
+	}
+	return pc, err
+}
+
+// tracingClient reports the metadata and offset requests made through
+// the saramaClient it wraps.
+type tracingClient struct {
+	saramaClient
+	hook func(TraceEvent)
+}
+
+func (t *tracingClient) Topics() ([]string, error) {
+	start := time.Now()
+	topics, err := t.saramaClient.Topics()
+	traceEvent(t.hook, TraceMetadata, nil, "", -1, start, err)
+	return topics, err
+}
+
+func (t *tracingClient) Partitions(topic string) ([]int32, error) {
+	start := time.Now()
+	partitions, err := t.saramaClient.Partitions(topic)
+	traceEvent(t.hook, TraceMetadata, nil, topic, -1, start, err)
+	return partitions, err
+}
+
+func (t *tracingClient) RefreshMetadata(topics ...string) error {
+	start := time.Now()
+	err := t.saramaClient.RefreshMetadata(topics...)
+	var topic string
+	if len(topics) == 1 {
+		topic = topics[0]
+	}
+	traceEvent(t.hook, TraceMetadata, nil, topic, -1, start, err)
+	return err
+}
+
+func (t *tracingClient) GetOffset(topic string, partition int32, at int64) (int64, error) {
+	start := time.Now()
+	n, err := t.saramaClient.GetOffset(topic, partition, at)
+	// the leader is cached by now so this doesn't add a request
+	b, _ := t.saramaClient.Leader(topic, partition)
+	traceEvent(t.hook, TraceGetOffset, b, topic, partition, start, err)
+	return n, err
+}
+
+// TraceCollector aggregates TraceEvents per operation.  Pass its Record
+// method to WithTracing.
+type TraceCollector struct {
+	mu  sync.Mutex
+	ops map[string]*traceOp
+}
+
+type traceOp struct {
+	errors int64
+	bench  *bench
+}
+
+// TraceSummary is what a TraceCollector has seen of one operation.
+type TraceSummary struct {
+	Count  int64         `json:"count"`
+	Errors int64         `json:"errors"`
+	P50    time.Duration `json:"p50"`
+	P95    time.Duration `json:"p95"`
+	P99    time.Duration `json:"p99"`
+	Max    time.Duration `json:"max"`
+}
+
+// NewTraceCollector returns an empty TraceCollector.
+func NewTraceCollector() *TraceCollector {
+	return &TraceCollector{ops: map[string]*traceOp{}}
+}
+
+// Record adds e to the collector.
+func (t *TraceCollector) Record(e TraceEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	op, ok := t.ops[e.Op]
+	if !ok {
+		op = &traceOp{bench: newBench()}
+		t.ops[e.Op] = op
+	}
+
+	if e.Err != nil {
+		op.errors++
+	}
+	op.bench.add(0, e.Duration)
+}
+
+// Summary returns the counts and latency percentiles of each operation
+// recorded so far.
+func (t *TraceCollector) Summary() map[string]TraceSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make(map[string]TraceSummary, len(t.ops))
+	for name, op := range t.ops {
+		r := op.bench.result()
+		out[name] = TraceSummary{
+			Count:  r.Messages,
+			Errors: op.errors,
+			P50:    r.LatencyP50,
+			P95:    r.LatencyP95,
+			P99:    r.LatencyP99,
+			Max:    r.LatencyMax,
+		}
+	}
+	return out
+}
+
+// MarshalJSON dumps the collector's Summary.
+func (t *TraceCollector) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Summary())
+}
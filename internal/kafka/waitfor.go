@@ -0,0 +1,86 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// HighWaterMarks returns the offset that the next message produced to
+// each partition of topic will get.  Pass it to WaitFor with FromOffsets
+// to also see messages produced between the snapshot and the call.
+func (c *Client) HighWaterMarks(topic string) (map[int32]int64, error) {
+	partitions, err := c.sarama.Partitions(topic)
+	if err != nil {
+		return nil, wrapErr("get partitions", topic, -1, -1, err)
+	}
+
+	out := make(map[int32]int64, len(partitions))
+	for _, p := range partitions {
+		n, err := c.sarama.GetOffset(topic, p, sarama.OffsetNewest)
+		if err != nil {
+			return nil, wrapErr("get offsets", topic, p, -1, err)
+		}
+		out[p] = n
+	}
+	return out, nil
+}
+
+// FromOffsets makes WaitFor start reading each partition at the given
+// offset (as returned by HighWaterMarks) instead of at the newest
+// message.  Partitions that aren't in offsets are read from their
+// oldest message.
+func FromOffsets(offsets map[int32]int64) CallOpt {
+	return func(o *callOpts) {
+		o.startOffsets = offsets
+	}
+}
+
+// WaitFor tails every partition of topic, starting at the newest message
+// (or at the offsets set with FromOffsets), and returns the first decoded
+// message that satisfies m.  It returns ErrTimeout if no message matched
+// within timeout.
+func (c *Client) WaitFor(ctx context.Context, topic string, m Matcher, timeout time.Duration, opts ...CallOpt) (Message, error) {
+	o := getCallOpts(append(opts, WithMatcher(m)))
+	start := func(int32) int64 { return sarama.OffsetNewest }
+	if o.startOffsets != nil {
+		start = func(p int32) int64 {
+			if n, ok := o.startOffsets[p]; ok {
+				return n
+			}
+			return sarama.OffsetOldest
+		}
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var out Message
+	var found bool
+	err := c.tail(ctx, topic, o, start, func(msg *sarama.ConsumerMessage) (bool, error) {
+		msg, ok, err := c.decodeMessage(ctx, o, msg)
+		if err != nil || !ok {
+			return false, err
+		}
+
+		part := Partition{Topic: msg.Topic, Partition: msg.Partition}
+		if !o.matches(part, msg) {
+			return false, nil
+		}
+
+		out, found = newMessage(part, msg), true
+		return true, nil
+	})
+
+	switch {
+	case found:
+		return out, nil
+	case err == context.DeadlineExceeded && parent.Err() == nil:
+		return Message{}, wrapErr("wait for message", topic, -1, -1, ErrTimeout)
+	case err == nil:
+		err = parent.Err()
+	}
+	return Message{}, err
+}
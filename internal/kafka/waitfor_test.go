@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWaitForFromOffsets(t *testing.T) {
+	m := newMockCluster()
+	m.createTopic("t", 2)
+	m.produce("t", 0, nil, []byte("old match"))
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	hwms, err := cli.HighWaterMarks("t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[int32]int64{0: 1, 1: 0}; !reflect.DeepEqual(hwms, want) {
+		t.Errorf("got high water marks %v, want %v", hwms, want)
+	}
+
+	// produced between the snapshot and the call
+	m.produce("t", 1, nil, []byte("other"))
+	m.produce("t", 1, nil, []byte("new match"))
+
+	msg, err := cli.WaitFor(context.Background(), "t", Contains("match"), 5*time.Second, FromOffsets(hwms))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg.Value) != "new match" || msg.Partition.Partition != 1 || msg.Offset != 1 {
+		t.Errorf("got %s at %d/%d, want new match at 1/1", msg.Value, msg.Partition.Partition, msg.Offset)
+	}
+}
+
+func TestWaitForNewest(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, nil, []byte("old match"))
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		m.produce("t", 0, nil, []byte("new match"))
+	}()
+
+	msg, err := cli.WaitFor(context.Background(), "t", Contains("match"), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg.Value) != "new match" {
+		t.Errorf("got %s, want new match", msg.Value)
+	}
+}
+
+func TestWaitForTimeout(t *testing.T) {
+	m := newMockCluster()
+	m.produce("t", 0, nil, []byte("x"))
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	_, err = cli.WaitFor(context.Background(), "t", Contains("x"), 100*time.Millisecond)
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("got %v, want %v", err, ErrTimeout)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cli.WaitFor(ctx, "t", Contains("x"), time.Minute)
+	if err != context.Canceled {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+}
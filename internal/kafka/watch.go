@@ -0,0 +1,186 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Watch tails every partition of topic, starting at the newest message,
+// and calls cb with each decoded message that satisfies m until ctx is
+// cancelled.  If partitions are added to the topic while it is being
+// watched they are picked up (from their oldest message), see
+// PartitionRefresh and OnNewPartitions.
+func (c *Client) Watch(ctx context.Context, topic string, m Matcher, cb func(Message), opts ...CallOpt) error {
+	o := getCallOpts(append(opts, WithMatcher(m)))
+	return c.tail(ctx, topic, o, func(int32) int64 { return sarama.OffsetNewest }, func(msg *sarama.ConsumerMessage) (bool, error) {
+		msg, ok, err := c.decodeMessage(ctx, o, msg)
+		if err != nil || !ok {
+			return false, err
+		}
+
+		part := Partition{Topic: msg.Topic, Partition: msg.Partition}
+		if o.matches(part, msg) {
+			cb(newMessage(part, msg))
+		}
+		return false, nil
+	})
+}
+
+// tail consumes every partition of topic from the offset returned by
+// start until ctx is cancelled, f returns true, or f returns an error.
+// Calls to f are serialized.  Partitions that are added while tailing
+// are consumed from their oldest offset and reported to
+// o.onNewPartitions.
+func (c *Client) tail(ctx context.Context, topic string, o callOpts, start func(int32) int64, f func(*sarama.ConsumerMessage) (bool, error)) error {
+	consumer, err := c.newConsumer(func(*sarama.Config) {})
+	if err != nil {
+		return err
+	}
+	defer consumer.Close()
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var lock sync.Mutex
+	var ferr error
+	fail := func(err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if ferr == nil {
+			ferr = err
+		}
+		cancel()
+	}
+
+	handle := func(msg *sarama.ConsumerMessage) {
+		lock.Lock()
+		defer lock.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+
+		stop, err := f(msg)
+		if err != nil {
+			ferr = err
+		}
+		if stop || err != nil {
+			cancel()
+		}
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	running := map[int32]bool{}
+	startNew := func(initial bool) error {
+		partitions, err := c.sarama.Partitions(topic)
+		if err != nil {
+			return wrapErr("get partitions", topic, -1, -1, err)
+		}
+
+		var added []int32
+		for _, p := range partitions {
+			if running[p] {
+				continue
+			}
+
+			offset := sarama.OffsetOldest
+			if initial {
+				offset = start(p)
+			}
+
+			pc, err := c.dial(consumer, topic, p, offset)
+			if err != nil {
+				return wrapErr("consume", topic, p, offset, err)
+			}
+
+			running[p] = true
+			if !initial {
+				added = append(added, p)
+			}
+			wg.Add(1)
+			ap := c.meter.start(topic, p, offset)
+			go func(pc sarama.PartitionConsumer) {
+				defer wg.Done()
+				defer pc.Close()
+				defer c.meter.stop(ap)
+
+				// sarama closes both channels when the partition
+				// consumer gives up
+				msgs, errs := pc.Messages(), pc.Errors()
+				for msgs != nil || errs != nil {
+					select {
+					case msg, ok := <-msgs:
+						if !ok {
+							msgs = nil
+							continue
+						}
+						c.meter.record(ap, msg)
+						handle(msg)
+					case cerr, ok := <-errs:
+						if !ok {
+							errs = nil
+							continue
+						}
+						// sarama recovers from leadership moves on its own
+						if retriable(cerr.Err) {
+							c.logf("tailing %s/%d: %s", cerr.Topic, cerr.Partition, cerr.Err)
+							continue
+						}
+						fail(wrapErr("consume", cerr.Topic, cerr.Partition, -1, c.deletedErr(cerr.Topic, c.fetchError(cerr.Err))))
+						return
+					case <-ctx.Done():
+						return
+					}
+				}
+			}(pc)
+		}
+
+		if len(added) > 0 {
+			c.logf("new partitions of %s: %v", topic, added)
+			if o.onNewPartitions != nil {
+				o.onNewPartitions(topic, added)
+			}
+		}
+		return nil
+	}
+
+	if err := startNew(true); err != nil {
+		// stop the partitions that did start before wg.Wait
+		cancel()
+		return err
+	}
+
+	// a nil channel never fires, PartitionRefresh(0) turns the check off
+	var refresh <-chan time.Time
+	if c.partitionRefresh > 0 {
+		t := time.NewTicker(c.partitionRefresh)
+		defer t.Stop()
+		refresh = t.C
+	}
+
+	for {
+		select {
+		case <-refresh:
+			if err := c.sarama.RefreshMetadata(topic); err != nil {
+				continue
+			}
+			if err := startNew(false); err != nil {
+				cancel()
+				return err
+			}
+		case <-ctx.Done():
+			cancel()
+			lock.Lock()
+			defer lock.Unlock()
+			if ferr != nil {
+				return ferr
+			}
+			return parent.Err()
+		}
+	}
+}
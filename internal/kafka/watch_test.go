@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+func TestWatchConsumerError(t *testing.T) {
+	m, cli := newTestClient(t, "t", []string{"a"})
+	if !cli.cfg.Consumer.Return.Errors {
+		t.Fatal("consumer errors aren't returned")
+	}
+	m.injectError("t", 0, errBoom)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := cli.Watch(ctx, "t", MatcherFunc(func(Message) bool { return true }), func(Message) {})
+	var kerr *KafkaError
+	if !errors.As(err, &kerr) || !errors.Is(err, errBoom) {
+		t.Fatalf("got %v, want a *KafkaError wrapping %v", err, errBoom)
+	}
+	if kerr.Topic != "t" || kerr.Partition != 0 {
+		t.Errorf("got %s/%d, want t/0", kerr.Topic, kerr.Partition)
+	}
+}
+
+func TestWatchRetriableError(t *testing.T) {
+	m, cli := newTestClient(t, "t", []string{"a"})
+	m.injectError("t", 0, sarama.ErrNotLeaderForPartition)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		m.produce("t", 0, nil, []byte("b"))
+	}()
+
+	var got []string
+	err := cli.Watch(ctx, "t", MatcherFunc(func(Message) bool { return true }), func(msg Message) {
+		got = append(got, string(msg.Value))
+		cancel()
+	})
+	if err != context.Canceled {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+	if want := []string{"b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestTailDialError fails to start the second partition after the first
+// one is running, which has to stop the first and return at once rather
+// than when the timeout runs out.
+func TestTailDialError(t *testing.T) {
+	m := newMockCluster()
+	m.createTopic("t", 2)
+	m.produce("t", 0, nil, []byte("a"))
+
+	cli, err := m.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := cli.WaitFor(context.Background(), "t", Contains("b"), time.Minute, FromOffsets(map[int32]int64{0: 1, 1: 99}))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		var kerr *KafkaError
+		if !errors.As(err, &kerr) || kerr.Partition != 1 {
+			t.Errorf("got %v, want an error for partition 1", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the dial error didn't stop the partitions that were already running")
+	}
+}
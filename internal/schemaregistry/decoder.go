@@ -0,0 +1,85 @@
+package schemaregistry
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// Decoder decodes messages written by the registry's Avro serializers (a
+// zero byte, the big endian schema id and the Avro binary datum) into
+// JSON.  It satisfies kafka.Decoder.
+type Decoder struct {
+	c *Client
+
+	lock   sync.Mutex
+	codecs map[int]*goavro.Codec
+}
+
+// NewDecoder returns a Decoder that looks schemas up with c
+func NewDecoder(c *Client) *Decoder {
+	return &Decoder{c: c, codecs: map[int]*goavro.Codec{}}
+}
+
+// Decode implements kafka.Decoder
+func (d *Decoder) Decode(topic string, data []byte) ([]byte, error) {
+	return d.DecodeContext(context.Background(), topic, data)
+}
+
+// DecodeContext implements kafka.ContextDecoder, ctx bounds fetching the
+// schema the first time an id is seen.
+func (d *Decoder) DecodeContext(ctx context.Context, topic string, data []byte) ([]byte, error) {
+	id, payload, err := Split(data)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err := d.Codec(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	native, _, err := codec.NativeFromBinary(payload)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode avro with schema %d: %s", id, err)
+	}
+
+	return codec.TextualFromNative(nil, native)
+}
+
+// Codec returns the (cached) codec for the schema with the given id
+func (d *Decoder) Codec(ctx context.Context, id int) (*goavro.Codec, error) {
+	d.lock.Lock()
+	codec, ok := d.codecs[id]
+	d.lock.Unlock()
+	if ok {
+		return codec, nil
+	}
+
+	s, err := d.c.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err = goavro.NewCodec(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid avro schema %d: %s", id, err)
+	}
+
+	d.lock.Lock()
+	d.codecs[id] = codec
+	d.lock.Unlock()
+	return codec, nil
+}
+
+// Split separates a registry framed message into its schema id and
+// payload.
+func Split(data []byte) (int, []byte, error) {
+	if len(data) < 5 || data[0] != 0 {
+		return 0, nil, fmt.Errorf("message is not in the schema registry wire format")
+	}
+	return int(binary.BigEndian.Uint32(data[1:5])), data[5:], nil
+}
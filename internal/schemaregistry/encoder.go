@@ -0,0 +1,114 @@
+package schemaregistry
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// Encoder turns JSON into messages in the registry's wire format, the
+// inverse of Decoder.  Each subject's schema is looked up once, it is
+// the latest version unless RegisterSchema gave one.  It satisfies
+// kafka.AvroEncoder.
+type Encoder struct {
+	c        *Client
+	register map[string]string
+
+	lock     sync.Mutex
+	subjects map[string]*subjectCodec
+}
+
+type subjectCodec struct {
+	id    int
+	avro  *goavro.Codec
+	plain *goavro.Codec
+}
+
+// EncoderOpt is a func that sets an attribute on an Encoder
+type EncoderOpt func(*Encoder)
+
+// RegisterSchema makes the Encoder register schema under subject (which
+// is a no-op if the registry has it already) and encode the subject's
+// values with it instead of the latest version.
+func RegisterSchema(subject, schema string) EncoderOpt {
+	return func(e *Encoder) {
+		e.register[subject] = schema
+	}
+}
+
+// NewEncoder returns an Encoder that looks schemas up with c
+func NewEncoder(c *Client, opts ...EncoderOpt) *Encoder {
+	e := &Encoder{c: c, register: map[string]string{}, subjects: map[string]*subjectCodec{}}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Encode converts value, either plain JSON or Avro's JSON encoding (what
+// Decoder produces), to Avro binary with subject's schema and frames it
+// with the schema id.  The error of a value that doesn't fit the schema
+// names the field that doesn't.
+func (e *Encoder) Encode(ctx context.Context, subject string, value []byte) ([]byte, error) {
+	sc, err := e.codec(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	native, _, err := sc.plain.NativeFromTextual(value)
+	if err != nil {
+		var aerr error
+		if native, _, aerr = sc.avro.NativeFromTextual(value); aerr != nil {
+			return nil, fmt.Errorf("value doesn't match schema %d of %s: %s", sc.id, subject, err)
+		}
+	}
+
+	header := make([]byte, 5)
+	binary.BigEndian.PutUint32(header[1:], uint32(sc.id))
+	out, err := sc.avro.BinaryFromNative(header, native)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode avro with schema %d: %s", sc.id, err)
+	}
+	return out, nil
+}
+
+func (e *Encoder) codec(ctx context.Context, subject string) (*subjectCodec, error) {
+	e.lock.Lock()
+	sc, ok := e.subjects[subject]
+	e.lock.Unlock()
+	if ok {
+		return sc, nil
+	}
+
+	var id int
+	schema, ok := e.register[subject]
+	if ok {
+		var err error
+		if id, err = e.c.Register(ctx, subject, schema); err != nil {
+			return nil, err
+		}
+	} else {
+		s, err := e.c.GetLatest(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		id, schema = s.ID, s.Schema
+	}
+
+	sc = &subjectCodec{id: id}
+	var err error
+	if sc.avro, err = goavro.NewCodec(schema); err != nil {
+		return nil, fmt.Errorf("invalid avro schema %d: %s", id, err)
+	}
+	if sc.plain, err = goavro.NewCodecForStandardJSON(schema); err != nil {
+		return nil, fmt.Errorf("invalid avro schema %d: %s", id, err)
+	}
+
+	e.lock.Lock()
+	e.subjects[subject] = sc
+	e.lock.Unlock()
+	return sc, nil
+}
@@ -0,0 +1,219 @@
+// Package schemaregistry is a small client for the Confluent schema
+// registry REST API.  Apart from Register it only reads.
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTimeout bounds every request unless HTTPClient says otherwise,
+// so that a registry that hangs can't stall a consume.
+const defaultTimeout = 10 * time.Second
+
+// Schema is a version of a subject's schema
+type Schema struct {
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+	ID      int    `json:"id"`
+	Type    string `json:"schemaType,omitempty"`
+	Schema  string `json:"schema"`
+}
+
+// Client talks to a schema registry.  Schemas fetched by id or by
+// subject and version never change so they are cached; subject lists and
+// latest versions are always fetched.  A Client is safe for concurrent
+// use.
+type Client struct {
+	url  string
+	user string
+	pass string
+	http *http.Client
+
+	lock      sync.Mutex
+	byID      map[int]string
+	byVersion map[string]Schema
+}
+
+// Opt is a func that sets an attribute on a Client
+type Opt func(*Client)
+
+// BasicAuth sets the credentials sent with every request
+func BasicAuth(user, pass string) Opt {
+	return func(c *Client) {
+		c.user = user
+		c.pass = pass
+	}
+}
+
+// HTTPClient sets the http.Client used for requests (ie for TLS or
+// timeouts).  It defaults to a client with a 10 second timeout.
+func HTTPClient(h *http.Client) Opt {
+	return func(c *Client) {
+		c.http = h
+	}
+}
+
+// New returns a Client for the registry at addr (ie http://localhost:8081)
+func New(addr string, opts ...Opt) (*Client, error) {
+	if _, err := url.Parse(addr); err != nil {
+		return nil, fmt.Errorf("invalid schema registry url %q: %s", addr, err)
+	}
+
+	c := &Client{
+		url:       strings.TrimRight(addr, "/"),
+		http:      &http.Client{Timeout: defaultTimeout},
+		byID:      map[int]string{},
+		byVersion: map[string]Schema{},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// ListSubjects returns every subject in the registry
+func (c *Client) ListSubjects(ctx context.Context) ([]string, error) {
+	var out []string
+	return out, c.get(ctx, "/subjects", &out)
+}
+
+// GetVersions returns the versions of subject
+func (c *Client) GetVersions(ctx context.Context, subject string) ([]int, error) {
+	var out []int
+	return out, c.get(ctx, "/subjects/"+url.PathEscape(subject)+"/versions", &out)
+}
+
+// GetSchema returns a version of subject
+func (c *Client) GetSchema(ctx context.Context, subject string, version int) (Schema, error) {
+	key := subject + "/" + strconv.Itoa(version)
+	c.lock.Lock()
+	s, ok := c.byVersion[key]
+	c.lock.Unlock()
+	if ok {
+		return s, nil
+	}
+
+	if err := c.get(ctx, "/subjects/"+url.PathEscape(subject)+"/versions/"+strconv.Itoa(version), &s); err != nil {
+		return s, err
+	}
+
+	c.cache(s)
+	return s, nil
+}
+
+// GetLatest returns the newest version of subject
+func (c *Client) GetLatest(ctx context.Context, subject string) (Schema, error) {
+	var s Schema
+	if err := c.get(ctx, "/subjects/"+url.PathEscape(subject)+"/versions/latest", &s); err != nil {
+		return s, err
+	}
+
+	c.cache(s)
+	return s, nil
+}
+
+// GetByID returns the schema with the given id, which is what the
+// registry's serializers put at the front of each message.
+func (c *Client) GetByID(ctx context.Context, id int) (string, error) {
+	c.lock.Lock()
+	s, ok := c.byID[id]
+	c.lock.Unlock()
+	if ok {
+		return s, nil
+	}
+
+	var resp struct {
+		Schema string `json:"schema"`
+	}
+	if err := c.get(ctx, "/schemas/ids/"+strconv.Itoa(id), &resp); err != nil {
+		return "", err
+	}
+
+	c.lock.Lock()
+	c.byID[id] = resp.Schema
+	c.lock.Unlock()
+	return resp.Schema, nil
+}
+
+// Register adds schema to subject, unless it is there already, and
+// returns its id.
+func (c *Client) Register(ctx context.Context, subject, schema string) (int, error) {
+	body, err := json.Marshal(map[string]string{"schema": schema})
+	if err != nil {
+		return 0, err
+	}
+
+	var resp struct {
+		ID int `json:"id"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/subjects/"+url.PathEscape(subject)+"/versions", bytes.NewReader(body), &resp); err != nil {
+		return 0, err
+	}
+
+	c.lock.Lock()
+	c.byID[resp.ID] = schema
+	c.lock.Unlock()
+	return resp.ID, nil
+}
+
+func (c *Client) cache(s Schema) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.byVersion[s.Subject+"/"+strconv.Itoa(s.Version)] = s
+	if s.ID > 0 {
+		c.byID[s.ID] = s.Schema
+	}
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	return c.do(ctx, http.MethodGet, path, nil, out)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequest(method, c.url+path, body)
+	if err != nil {
+		return err
+	}
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	}
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.pass)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		var e struct {
+			Code    int    `json:"error_code"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &e) == nil && e.Message != "" {
+			return fmt.Errorf("schema registry %s: %s (%d)", path, e.Message, e.Code)
+		}
+		return fmt.Errorf("schema registry %s: %s", path, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"sort"
@@ -121,12 +122,15 @@ func newTopic(cli *kafka.Client, t string, width, height int, flashMessage chan<
 
 func (t *topic) search(s string, cb func(int64, int64)) (int64, error) {
 	results, err := t.cli.SearchTopic(t.partitions, s, false, cb)
-	if err != nil || len(results) == 0 {
+	var partial *kafka.PartialError
+	if err != nil && !errors.As(err, &partial) || len(results) == 0 {
 		return -1, err
 	}
 	t.partitions = results
 
-	return int64(len(results)), nil
+	// a *kafka.PartialError is returned with the results so the
+	// partitions that couldn't be searched are shown with them
+	return int64(len(results)), err
 }
 
 func (t *topic) jump(i int64) error {
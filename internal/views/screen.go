@@ -1,6 +1,7 @@
 package views
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -187,21 +188,22 @@ func (s *screen) doSearch() {
 	for {
 		s.view = "body"
 		term := <-s.searchChan
-		var i int
 		n, err := s.body.search(term, func(a, b int64) {
-			if i%10 == 0 {
+			if b > 0 {
 				s.flashMessage <- fmt.Sprintf(strings.Repeat("|", int(int64(s.width)*a/b)))
 			}
-			i++
 		})
 
-		if err != nil {
+		var partial *kafka.PartialError
+		if err != nil && !(errors.As(err, &partial) && n > 0) {
 			s.flashMessage <- fmt.Sprintf("error: %s", err)
 			return
 		}
 
 		if n > 0 {
-			if s.body.stack.name() == "topic" {
+			if partial != nil {
+				s.flashMessage <- fmt.Sprintf("%d partitions matched %s, %s", n, term, partial)
+			} else if s.body.stack.name() == "topic" {
 				s.flashMessage <- fmt.Sprintf("%d partitions matched %s", n, term)
 			} else {
 				s.flashMessage <- fmt.Sprintf("found a match at offset %d", n)
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/cswank/kcli/internal/kafka"
+	"github.com/cswank/kcli/internal/schemaregistry"
 	"github.com/cswank/kcli/internal/views"
 
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
@@ -20,6 +21,7 @@ var (
 	partition = kingpin.Flag("partition", "go directly to a partition of a topic").Short('p').Default("-1").Int()
 	offset    = kingpin.Flag("offset", "go directly to a message").Short('o').Default("-1").Int()
 	decoder   = kingpin.Flag("decoder", "path to a plugin to decode kafka messages").Short('d').String()
+	registry  = kingpin.Flag("registry", "schema registry url, decodes avro messages (credentials are read from KCLI_REGISTRY_USERNAME and KCLI_REGISTRY_PASSWORD)").Short('r').String()
 	f         *os.File
 )
 
@@ -28,6 +30,7 @@ func init() {
 }
 
 func main() {
+	openLog()
 	cli := connect()
 	setLogout()
 	err := views.NewGui(cli, *topic, *partition, *offset)
@@ -45,6 +48,12 @@ func connect() *kafka.Client {
 	if *decoder != "" {
 		dec := getDecoder(*decoder)
 		opts = []kafka.Opt{kafka.WithDecoder(dec)}
+	} else if *registry != "" {
+		opts = []kafka.Opt{kafka.WithDecoder(getRegistryDecoder(*registry))}
+	}
+
+	if f != nil {
+		opts = append(opts, kafka.Debug(f))
 	}
 
 	cli, err := kafka.New(getAddresses(*addrs), opts...)
@@ -55,13 +64,20 @@ func connect() *kafka.Client {
 	return cli
 }
 
-func setLogout() {
+// openLog creates the --log file before connecting so that the kafka
+// client's debug output goes to it too.
+func openLog() {
 	if *logout != "" {
 		var err error
 		f, err = os.Create(*logout)
 		if err != nil {
 			log.Fatal(err)
 		}
+	}
+}
+
+func setLogout() {
+	if f != nil {
 		log.SetOutput(f)
 
 	} else {
@@ -97,3 +113,17 @@ func getDecoder(pth string) kafka.Decoder {
 
 	return dec
 }
+
+func getRegistryDecoder(addr string) kafka.Decoder {
+	var opts []schemaregistry.Opt
+	if user := os.Getenv("KCLI_REGISTRY_USERNAME"); user != "" {
+		opts = append(opts, schemaregistry.BasicAuth(user, os.Getenv("KCLI_REGISTRY_PASSWORD")))
+	}
+
+	c, err := schemaregistry.New(addr, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return schemaregistry.NewDecoder(c)
+}